@@ -6,6 +6,7 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/GuyARoss/orbit/internal"
 	"github.com/GuyARoss/orbit/internal/srcpack"
@@ -79,10 +80,26 @@ var buildCMD = &cobra.Command{
 func init() {
 	var pageaudit string
 	var mode string
+	var precompress bool
+	var hashBundles bool
+	var bundleGraceWindow time.Duration
+	var multiEntry bool
 
 	buildCMD.PersistentFlags().StringVar(&pageaudit, "audit_path", "", "file path used to output an audit file for the pages")
 	viper.BindPFlag("audit_path", buildCMD.PersistentFlags().Lookup("audit_path"))
 
 	buildCMD.PersistentFlags().StringVar(&mode, "mode", "production", "specifies the underlying bundler mode to run in")
 	viper.BindPFlag("mode", buildCMD.PersistentFlags().Lookup("mode"))
+
+	buildCMD.PersistentFlags().BoolVar(&precompress, "precompress", true, "creates gzip (.gz), brotli (.br) & zstd (.zst) sidecars of the bundle files, so they are not compressed for each request")
+	viper.BindPFlag("precompress", buildCMD.PersistentFlags().Lookup("precompress"))
+
+	buildCMD.PersistentFlags().BoolVar(&hashBundles, "hash_bundles", true, "references the bundle files by a content-hashed name, so that redeploys do not serve stale bundles")
+	viper.BindPFlag("hash_bundles", buildCMD.PersistentFlags().Lookup("hash_bundles"))
+
+	buildCMD.PersistentFlags().DurationVar(&bundleGraceWindow, "bundle_grace_window", 7*24*time.Hour, "duration that the content-hashed bundle files of previous builds are kept")
+	viper.BindPFlag("bundle_grace_window", buildCMD.PersistentFlags().Lookup("bundle_grace_window"))
+
+	buildCMD.PersistentFlags().BoolVar(&multiEntry, "multi_entry", true, "bundles the pages with a single multi-entry webpack config, which splits the node modules shared between the pages into a shared chunk, set to false to bundle each page on its own")
+	viper.BindPFlag("multi_entry", buildCMD.PersistentFlags().Lookup("multi_entry"))
 }
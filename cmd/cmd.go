@@ -25,6 +25,9 @@ func init() {
 	var spaEntry string
 	var spaOutDir string
 	var experimentalFeatures []string
+	var reactVersion int
+	var jsxFramework string
+	var ssrBackend string
 
 	buildCmds := [4]*cobra.Command{
 		buildCMD, devCMD, initCMD, deployCMD,
@@ -55,6 +58,15 @@ func init() {
 		cmd.PersistentFlags().StringVar(&spaEntry, "spa_entry_path", "", "when specified this entry should be the file name of the entrypoint file. note this command will force the application to become an SPA")
 		viper.BindPFlag("spa_entry_path", cmd.PersistentFlags().Lookup("spa_entry_path"))
 
+		cmd.PersistentFlags().IntVar(&reactVersion, "react_version", 0, "specifies the major version of react to target, left blank will use the installed version of react")
+		viper.BindPFlag("react_version", cmd.PersistentFlags().Lookup("react_version"))
+
+		cmd.PersistentFlags().StringVar(&jsxFramework, "jsx_framework", "react", "specifies the framework that renders jsx pages which do not declare one with 'orbit:framework', either 'react' or 'preact'")
+		viper.BindPFlag("jsx_framework", cmd.PersistentFlags().Lookup("jsx_framework"))
+
+		cmd.PersistentFlags().StringVar(&ssrBackend, "ssr_backend", "node", "specifies the renderer used to server side render pages, either 'node' or 'embedded' (requires a build with the 'goja' tag)")
+		viper.BindPFlag("ssr_backend", cmd.PersistentFlags().Lookup("ssr_backend"))
+
 		cmd.PersistentFlags().StringVar(&spaOutDir, "spa_out_dir", "./dist", "output directory to write an SPA, requires 'spa_entry_path' to be set")
 		viper.BindPFlag("spa_out_dir", cmd.PersistentFlags().Lookup("spa_out_dir"))
 	}
@@ -36,6 +36,7 @@ var deployCMD = &cobra.Command{
 		}
 
 		staticBuild := internal.NewStaticBuild(buildOpts, viper.GetString("static_out_dir"))
+		staticBuild.SSRBackend = buildOpts.SSRBackend
 		err = staticBuild.Build(components)
 
 		if err != nil {
@@ -74,6 +74,7 @@ var devCMD = &cobra.Command{
 		go devServer.RedirectionBundler()
 
 		http.HandleFunc("/ws", reloader.HandleWebSocket)
+		http.HandleFunc("/log", reloader.HandleLog)
 
 		logger.Info(fmt.Sprintf("Hot reload server started on port '%d'", viper.GetInt("hotreloadport")))
 		logger.Info("You will still need to run your application")
@@ -20,6 +20,8 @@ Experimental Options:
 
 ssr 	enables the usage of ssr functionality for available web wrappers 
 swc 	enables the usage of the swc compiler in place of babel
+islands	enables partial hydration of react pages, only the components declared with "// orbit:island" are hydrated
+esbuild	enables the usage of esbuild in place of webpack for the client bundles of the react, preact & javascript pages
 	
 		`)
 	},
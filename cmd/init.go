@@ -17,6 +17,24 @@ import (
 	"github.com/spf13/viper"
 )
 
+// frameworkDependencies are the node modules of the component frameworks that can be selected with "frameworks",
+// the wrappers of these frameworks fail to verify their requirements when the modules are not installed.
+var frameworkDependencies = map[string]map[string]string{
+	"vue": {
+		"vue":                  "^3.2.0",
+		"vue-loader":           "^16.8.3",
+		"@vue/compiler-sfc":    "^3.2.0",
+		"@vue/server-renderer": "^3.2.0",
+	},
+	"svelte": {
+		"svelte":        "^3.59.0",
+		"svelte-loader": "^3.1.9",
+	},
+	"preact": {
+		"preact": "^10.11.0",
+	},
+}
+
 var initCMD = &cobra.Command{
 	Use:  "init",
 	Long: "initializes the project directory",
@@ -24,10 +42,11 @@ var initCMD = &cobra.Command{
 		nodeDependencies := map[string]string{
 			"@babel/core": "^7.11.1",
 			"@babel/plugin-proposal-export-default-from": "^7.12.13",
-			"@babel/polyfill":     "^7.12.1",
-			"@babel/preset-env":   "^7.11.0",
-			"@babel/preset-react": "^7.10.4",
-			"babel-loader":        "^8.1.0",
+			"@babel/polyfill":          "^7.12.1",
+			"@babel/preset-env":        "^7.11.0",
+			"@babel/preset-react":      "^7.10.4",
+			"@babel/preset-typescript": "^7.18.6",
+			"babel-loader":             "^8.1.0",
 
 			// note: SWC is currently being used as a experimental replacement for babel
 			// plans to deprecate babel will exist in future revisions of this program.
@@ -46,6 +65,41 @@ var initCMD = &cobra.Command{
 			"webpack":             "^4.44.1",
 			"webpack-cli":         "^3.3.12",
 			"webpack-merge":       "^5.8.0",
+
+			// the server bundle loads the inlined descriptors of its grpc services with "loadFileDescriptorSetFromBuffer"
+			"@grpc/grpc-js":      "^1.8.0",
+			"@grpc/proto-loader": "^0.7.0",
+		}
+
+		// react 18 is opt-in, as it requires the react 18 wrappers that use the root api
+		if viper.GetInt("react_version") >= 18 {
+			nodeDependencies["react"] = "^18.2.0"
+			nodeDependencies["react-dom"] = "^18.2.0"
+		}
+
+		// esbuild bundles the client pages in place of webpack
+		for _, feature := range viper.GetStringSlice("experimental") {
+			if feature == "esbuild" {
+				nodeDependencies["esbuild"] = "^0.17.19"
+			}
+		}
+
+		frameworks := viper.GetStringSlice("frameworks")
+
+		// preact renders the jsx pages in place of react, the react packages are kept for the pages that opt back in
+		if viper.GetString("jsx_framework") == "preact" {
+			frameworks = append(frameworks, "preact")
+		}
+
+		for _, framework := range frameworks {
+			deps, ok := frameworkDependencies[framework]
+			if !ok {
+				log.Fatalf("unknown framework '%s', expected one of 'vue', 'svelte' or 'preact'", framework)
+			}
+
+			for name, version := range deps {
+				nodeDependencies[name] = version
+			}
 		}
 
 		pkgJson := &internal.PackageJSONTemplate{
@@ -78,8 +132,11 @@ var initCMD = &cobra.Command{
 			Assets: []fs.DirEntry{
 				ats.AssetEntry(assets.WebPackConfig),
 				ats.AssetEntry(assets.SSRProtoFile),
+				ats.AssetEntry(assets.SSRHealthProto),
 				ats.AssetEntry(assets.JsWebPackConfig),
 				ats.AssetEntry(assets.WebPackSWCConfig),
+				ats.AssetEntry(assets.SSRWebPackConfig),
+				ats.AssetEntry(assets.SSRWebPackSWCConfig),
 			},
 		}).Make()
 
@@ -88,3 +145,10 @@ var initCMD = &cobra.Command{
 		}
 	},
 }
+
+func init() {
+	var frameworks []string
+
+	initCMD.PersistentFlags().StringSliceVar(&frameworks, "frameworks", []string{}, "comma delimited list of the component frameworks used alongside react e.g 'vue,svelte', the node modules of each framework are installed")
+	viper.BindPFlag("frameworks", initCMD.PersistentFlags().Lookup("frameworks"))
+}
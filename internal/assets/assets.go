@@ -24,7 +24,11 @@ const (
 	Tests            AssetKey = "orbit_test.go"
 	PrimaryPackage   AssetKey = "orbit.go"
 	SSRProtoFile     AssetKey = "com.proto"
-	JsWebPackConfig  AssetKey = "jsbase.config.js"
+	SSRHealthProto   AssetKey = "health.proto"
+	SSRWebPackConfig AssetKey = "ssr-base.config.js"
+	// SSRWebPackSWCConfig is the base config of the server bundles when swc is preferred over babel
+	SSRWebPackSWCConfig AssetKey = "ssr-swc-base.config.js"
+	JsWebPackConfig     AssetKey = "jsbase.config.js"
 )
 
 func WriteFile(toDir string, f fs.DirEntry) error {
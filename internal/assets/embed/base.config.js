@@ -42,7 +42,7 @@ module.exports = {
                 ],
             },
             {
-                test: /\.(js|jsx)$/,
+                test: /\.(js|jsx|ts|tsx)$/,
                 exclude: /node_modules/,
                 use: {
                     loader: "babel-loader",
@@ -54,7 +54,8 @@ module.exports = {
                                     "useBuiltIns": "entry"
                                 }
                             ],
-                            "@babel/preset-react"
+                            "@babel/preset-react",
+                            "@babel/preset-typescript"
                         ],
                         "plugins": [
                             "@babel/plugin-proposal-class-properties",
@@ -75,7 +76,7 @@ module.exports = {
         ]
     },
     resolve: {
-        extensions: ['.js', '.jsx'],
+        extensions: ['.js', '.jsx', '.ts', '.tsx'],
         modules: ['node_modules', path.resolve(__dirname, './')]
     },
 };
\ No newline at end of file
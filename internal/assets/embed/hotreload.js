@@ -34,7 +34,7 @@ async function createSocket() {
 async function initHotReload() {
     const primaryKeys = Array.from(document.getElementsByClassName("orbit_bk")).map(x => {
         const k = x.attributes["src"].value.split("/")
-        return k[k.length -1].replace(".js", "")
+        return k[k.length -1].split(".")[0]
     })
     
     try {
@@ -54,10 +54,12 @@ async function initHotReload() {
                 case "reload": {
                     resetNotices()
                     window.location.reload()
+                    break
                 }
                 case "logger": {
-                    const [logLevel, message] = incoming?.value
-                    attachNoticeFrame(message, logLevel, 'compiler')
+                    const [logLevel, message, origin = 'compiler'] = incoming?.value
+                    attachNoticeFrame(message, logLevel, origin)
+                    break
                 }
             }
         }
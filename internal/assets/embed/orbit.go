@@ -1,304 +1,1883 @@
 package orbit
 
 import (
+	"bytes"
 	"compress/gzip"
+	"container/list"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 // Request is the standard request payload for the orbit page handler
 // this is just a fancy wrapper around the http request & response that will also assist
 // the rendering of bundled pages & incoming path slugs
+// providing PageProps as the data of "RenderPages" renders each of the pages with its own props.
 type Request struct {
-	RenderPage  func(page PageRender, data interface{})
-	RenderPages func(data interface{}, pages ...PageRender)
+	// RenderPage & RenderPages write the rendered pages to the response. the returned error is for reporting only,
+	// the response has already been written (e.g the error page or the error boundary of a streamed response)
+	// so callers should not write to the response again.
+	RenderPage  func(page PageRender, data interface{}) error
+	RenderPages func(data interface{}, pages ...PageRender) error
 	Request     *http.Request
 	Response    http.ResponseWriter
 	Slugs       map[string]string
+
+	renderHooks []RenderHook
+	head        *Head
+}
+
+// Head returns the head of the document for this request, tags added to the head
+// are applied to the document of each of the pages rendered by the request.
+func (c *Request) Head() *Head {
+	if c.head == nil {
+		c.head = &Head{}
+	}
+
+	return c.head
+}
+
+// HandlerFunc is the standard function signature for an orbit page handler
+type HandlerFunc func(c *Request)
+
+// Middleware wraps an orbit page handler, allowing logic to be ran before and/or after the handler.
+// to inspect the pages and props of a render before it happens, middleware can use "Request.BeforeRender"
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// RenderHook is ran before the pages of a request are rendered, returning false will cancel the render.
+// a hook that cancels a render is expected to write its own response.
+type RenderHook func(c *Request, pages []PageRender, data interface{}) bool
+
+// BeforeRender attaches a hook that will be ran before the pages of this request are rendered
+func (c *Request) BeforeRender(hook RenderHook) {
+	c.renderHooks = append(c.renderHooks, hook)
+}
+
+// runRenderHooks runs each of the render hooks in the order that they were attached,
+// returns false if any of the hooks have canceled the render.
+func (c *Request) runRenderHooks(pages []PageRender, data interface{}) bool {
+	for _, hook := range c.renderHooks {
+		if !hook(c, pages, data) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// chainMiddleware wraps the handler with the provided middleware, where the first middleware is the outermost
+func chainMiddleware(handler HandlerFunc, middleware ...Middleware) HandlerFunc {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+
+	return handler
+}
+
+// DefaultPage defines the standard behavior for a orbit page handler
+type DefaultPage interface {
+	Handle(*Request)
+}
+
+// htmlDoc represents the html fragment of a rendered page, the fragment is
+// inserted into the base document (e.g "public/index.html") when it is written.
+// a page renderer may also set the status of the response or redirect the request.
+type htmlDoc struct {
+	Head     []string
+	Body     []string
+	Status   int
+	Redirect string
+	// fallback denotes that the server side render of the page failed & that the page is rendered on the client,
+	// these fragments are not cached so that the page is server side rendered again by the next request.
+	fallback bool
+}
+
+// fragment renders the document out as a single html fragment
+func (s *htmlDoc) fragment() string {
+	return strings.Join(s.Head, "") + strings.Join(s.Body, "")
+}
+
+// insertInto inserts the fragment at the end of the head & body of the document, head elements
+// of the fragment replace the matching elements of the document e.g a rendered <title>.
+func (s *htmlDoc) insertInto(doc *HTMLDocument) *HTMLDocument {
+	for _, h := range s.Head {
+		if match := headElementMatch(h); match != nil {
+			doc.RemoveHeadElements(match)
+		}
+
+		doc.Insert(HeadEnd, h)
+	}
+
+	doc.Insert(BodyEnd, s.Body...)
+
+	return doc
+}
+
+// merge appends the other fragment to this fragment, the first status & redirect of the fragments is used.
+func (s *htmlDoc) merge(other *htmlDoc) {
+	s.Head = append(s.Head, other.Head...)
+	s.Body = append(s.Body, other.Body...)
+
+	if s.Status == 0 {
+		s.Status = other.Status
+	}
+
+	if s.Redirect == "" {
+		s.Redirect = other.Redirect
+	}
+
+	s.fallback = s.fallback || other.fallback
+}
+
+// headTag is a single tag of the document head, tags with the same key replace each other
+// & the match is used to remove the tag from the base document (e.g "public/index.html").
+type headTag struct {
+	key   string
+	html  string
+	match func(t *HTMLToken) bool
+}
+
+// Head manages the tags of the document head for a render, these tags are merged with the base document
+// where a tag replaces any of the matching tags e.g setting the title replaces the title of "public/index.html".
+type Head struct {
+	tags []headTag
+}
+
+// matchTitle matches the title element of the document
+func matchTitle(t *HTMLToken) bool {
+	return t.Name == "title"
+}
+
+// matchAttrTag matches the html element with an attribute of the provided value e.g <meta name="description">
+func matchAttrTag(element string, attr string, value string) func(t *HTMLToken) bool {
+	return func(t *HTMLToken) bool {
+		v, ok := t.Attr(attr)
+		return t.Name == element && ok && strings.EqualFold(v, value)
+	}
+}
+
+// headElementMatch finds the match of the elements that the head element replaces, only the title,
+// named meta tags & the canonical link replace other elements.
+func headElementMatch(element string) func(t *HTMLToken) bool {
+	for _, t := range HTMLTokenize(element) {
+		if t.Type != HTMLStartTagToken && t.Type != HTMLSelfClosingTagToken {
+			continue
+		}
+
+		switch t.Name {
+		case "title":
+			return matchTitle
+		case "meta":
+			if name, ok := t.Attr("name"); ok {
+				return matchAttrTag("meta", "name", name)
+			}
+
+			if property, ok := t.Attr("property"); ok {
+				return matchAttrTag("meta", "property", property)
+			}
+		case "link":
+			if rel, _ := t.Attr("rel"); strings.EqualFold(rel, "canonical") {
+				return matchAttrTag("link", "rel", "canonical")
+			}
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
+func (h *Head) add(tag headTag) *Head {
+	h.tags = append(h.tags, tag)
+	return h
+}
+
+// Title sets the title of the document
+func (h *Head) Title(title string) *Head {
+	return h.add(headTag{
+		key:   "title",
+		html:  fmt.Sprintf("<title>%s</title>", html.EscapeString(title)),
+		match: matchTitle,
+	})
+}
+
+// Meta sets the content of the meta tag with the provided name e.g "description"
+func (h *Head) Meta(name string, content string) *Head {
+	return h.add(headTag{
+		key:   "meta:name:" + name,
+		html:  fmt.Sprintf(`<meta name="%s" content="%s" />`, html.EscapeString(name), html.EscapeString(content)),
+		match: matchAttrTag("meta", "name", name),
+	})
+}
+
+// Property sets the content of the meta tag with the provided property, this is used by OpenGraph tags e.g "og:title"
+func (h *Head) Property(property string, content string) *Head {
+	return h.add(headTag{
+		key:   "meta:property:" + property,
+		html:  fmt.Sprintf(`<meta property="%s" content="%s" />`, html.EscapeString(property), html.EscapeString(content)),
+		match: matchAttrTag("meta", "property", property),
+	})
+}
+
+// Link adds a link tag to the document, canonical links replace the canonical link of the base document.
+func (h *Head) Link(rel string, href string) *Head {
+	if rel == "canonical" {
+		return h.Canonical(href)
+	}
+
+	return h.add(headTag{
+		key:  fmt.Sprintf("link:%s:%s", rel, href),
+		html: fmt.Sprintf(`<link rel="%s" href="%s" />`, html.EscapeString(rel), html.EscapeString(href)),
+	})
+}
+
+// Canonical sets the canonical link of the document
+func (h *Head) Canonical(href string) *Head {
+	return h.add(headTag{
+		key:   "link:canonical",
+		html:  fmt.Sprintf(`<link rel="canonical" href="%s" />`, html.EscapeString(href)),
+		match: matchAttrTag("link", "rel", "canonical"),
+	})
+}
+
+// JSONLD adds the structured data to the document as a JSON-LD script
+func (h *Head) JSONLD(data interface{}) error {
+	// the json encoder escapes html characters, so the data is not able to close the script
+	d, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	h.add(headTag{html: fmt.Sprintf(`<script type="application/ld+json">%s</script>`, d)})
+	return nil
+}
+
+// extend creates a head that includes the tags of both heads, where the tags of the other head take precedence
+func (h *Head) extend(other *Head) *Head {
+	merged := &Head{}
+	if h != nil {
+		merged.tags = append(merged.tags, h.tags...)
+	}
+
+	if other != nil {
+		merged.tags = append(merged.tags, other.tags...)
+	}
+
+	return merged
+}
+
+// apply merges the tags into the head of the document, removing any of the tags of the document that they replace.
+// when multiple tags share a key, the last of these tags is used in place of the first.
+func (h *Head) apply(doc *HTMLDocument) {
+	if h == nil || len(h.tags) == 0 {
+		return
+	}
+
+	tags := make([]string, 0, len(h.tags))
+	position := make(map[string]int)
+
+	for _, tag := range h.tags {
+		if tag.match != nil {
+			doc.RemoveHeadElements(tag.match)
+		}
+
+		if tag.key == "" {
+			tags = append(tags, tag.html)
+			continue
+		}
+
+		if i, ok := position[tag.key]; ok {
+			tags[i] = tag.html
+			continue
+		}
+
+		position[tag.key] = len(tags)
+		tags = append(tags, tag.html)
+	}
+
+	doc.Insert(HeadEnd, tags...)
+}
+
+// staticHeadTag is a tag of the document head that has been declared by the page component
+type staticHeadTag struct {
+	token string
+	name  string
+	value string
+}
+
+// pagesHead creates the head from the static head tags of the provided pages
+func pagesHead(pages ...PageRender) *Head {
+	h := &Head{}
+
+	for _, p := range pages {
+		for _, tag := range staticHeads[p] {
+			switch tag.token {
+			case "orbit:title":
+				h.Title(tag.value)
+			case "orbit:meta":
+				h.Meta(tag.name, tag.value)
+			case "orbit:property":
+				h.Property(tag.name, tag.value)
+			case "orbit:link":
+				h.Link(tag.name, tag.value)
+			}
+		}
+	}
+
+	return h
+}
+
+// parseStaticDocument attempts to find the specified document and return it as a string
+func parseStaticDocument(path string) (string, error) {
+	_, err := os.Stat(path)
+	if !os.IsNotExist(err) {
+		f, _ := ioutil.ReadFile(path)
+
+		return string(f), nil
+	}
+
+	return "", fmt.Errorf("static document does not exist for '%s'", publicDir)
+}
+
+// manifestTag creates the orbit manifest script that the bundles read their props from
+func manifestTag(data []byte) string {
+	return fmt.Sprintf(`<script id="orbit_manifest" type="application/json">%s</script>`, data)
+}
+
+// pageManifestTag creates the orbit manifest script of a page that is rendered with its own props,
+// the bundle of the page reads from this manifest in place of the shared manifest.
+func pageManifestTag(page PageRender, data []byte) string {
+	return fmt.Sprintf(`<script id="orbit_manifest_%s" type="application/json">%s</script>`, page, data)
+}
+
+// PageProps are the props of each of the pages of a render, this allows pages rendered
+// together (e.g micro-frontends) to receive different props without their names colliding.
+type PageProps map[PageRender]interface{}
+
+// renderProps are the marshaled props of a render, the pages either share the same props or have their own
+type renderProps struct {
+	shared []byte
+	pages  map[PageRender][]byte
+}
+
+// sharedProps creates the render props where each of the pages share the same props
+func sharedProps(data []byte) *renderProps {
+	return &renderProps{shared: data}
+}
+
+// newRenderProps marshals the props of the render, each page has its own props when the data is PageProps.
+// pages without props in the PageProps are rendered with empty props.
+func newRenderProps(data interface{}, pages ...PageRender) (*renderProps, error) {
+	props, ok := data.(PageProps)
+	if !ok {
+		d, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+
+		return sharedProps(d), nil
+	}
+
+	rp := &renderProps{shared: []byte("{}"), pages: make(map[PageRender][]byte)}
+	for _, p := range pages {
+		pageData, ok := props[p]
+		if !ok {
+			continue
+		}
+
+		d, err := json.Marshal(pageData)
+		if err != nil {
+			return nil, err
+		}
+
+		rp.pages[p] = d
+	}
+
+	return rp, nil
+}
+
+// of finds the props of the page
+func (p *renderProps) of(page PageRender) []byte {
+	if d, ok := p.pages[page]; ok {
+		return d
+	}
+
+	return p.shared
+}
+
+// manifest creates the manifest scripts of the pages, pages with their own props are written to their own manifest
+func (p *renderProps) manifest(pages ...PageRender) []string {
+	if p.pages == nil {
+		return []string{manifestTag(p.shared)}
+	}
+
+	tags := make([]string, 0, len(pages))
+	written := make(map[PageRender]bool)
+
+	for _, page := range pages {
+		if written[page] {
+			continue
+		}
+
+		written[page] = true
+		tags = append(tags, pageManifestTag(page, p.of(page)))
+	}
+
+	return tags
+}
+
+// documentShell creates the part of the html document that does not depend on the output of the
+// page renderers, this includes the web wrapper dependencies, the orbit manifest & the head of static pages.
+// the body of each static page is returned as a fragment so that it can be placed in order with the other pages.
+func documentShell(props *renderProps, pages ...PageRender) (*htmlDoc, map[PageRender]*htmlDoc) {
+	head := make([]string, 0)
+	isWritten := make(map[string]bool)
+	staticFragments := make(map[PageRender]*htmlDoc)
+	manifestPages := make([]PageRender, 0)
+
+	for _, p := range pages {
+		// if the page is of static origin, we first check to see if it exists on the file system
+		// if it does, it will be applied to the current html document, rather than returned directly
+		// this is to support the usage of static html within micro-frontends
+		if staticResourceMap[p] {
+			staticDocument, err := parseStaticDocument(fmt.Sprintf("%s%c%s", http.Dir(bundleDir), os.PathSeparator, p))
+			if err == nil {
+				doc := ParseHTML(staticDocument)
+
+				head = append(head, doc.HeadHTML())
+				staticFragments[p] = &htmlDoc{Head: []string{}, Body: []string{doc.BodyHTML()}}
+				continue
+			}
+		}
+
+		pv := wrapDocRender[p]
+		if pv == nil {
+			continue
+		}
+		manifestPages = append(manifestPages, p)
+
+		// the dependencies of the pages are only written once, as pages share the requirements
+		// of their web wrapper & the shared chunk of the pages that are bundled together.
+		// pages of the same web wrapper can still depend on different shared chunks.
+		for _, dependency := range pageDependencies[p] {
+			if !isWritten[dependency] {
+				isWritten[dependency] = true
+				head = append(head, dependency)
+			}
+		}
+	}
+
+	if len(manifestPages) > 0 {
+		head = append(head, props.manifest(manifestPages...)...)
+	}
+
+	return &htmlDoc{Head: head, Body: []string{}}, staticFragments
+}
+
+// RenderError occurs when a page could not be rendered by its web wrapper
+type RenderError struct {
+	Page PageRender
+	Err  error
+}
+
+func (e *RenderError) Error() string {
+	return fmt.Sprintf("failed to render page '%s': %s", e.Page, e.Err)
+}
+
+func (e *RenderError) Unwrap() error { return e.Err }
+
+// bareError is a render error that is responded to with only its status, the error page of the status is not rendered
+type bareError struct {
+	err error
+}
+
+func (e *bareError) Error() string { return e.err.Error() }
+
+func (e *bareError) Unwrap() error { return e.err }
+
+// renderFragment renders a single page into its own html fragment using the page's web wrapper
+func renderFragment(ctx context.Context, page PageRender, data []byte) (*htmlDoc, error) {
+	fragment := &htmlDoc{Head: []string{}, Body: []string{}}
+
+	if op := wrapDocRender[page]; op != nil {
+		doc, err := op.fn(ctx, string(page), data, fragment)
+		if err != nil {
+			return nil, &RenderError{Page: page, Err: err}
+		}
+
+		return doc, nil
+	}
+
+	return fragment, nil
+}
+
+// fragmentRenderer renders a single page into its own html fragment
+type fragmentRenderer func(ctx context.Context, page PageRender, data []byte) (*htmlDoc, error)
+
+// batchRenderFunction renders multiple pages of a web wrapper with a single call, the fragments are in the order of the pages
+type batchRenderFunction func(ctx context.Context, pages []string, data [][]byte) ([]*htmlDoc, error)
+
+// batchRenderers are the render functions of the web wrappers that are able to render multiple pages with
+// a single call (e.g server side rendered pages), keyed by the version of the web wrapper.
+var batchRenderers = map[string]batchRenderFunction{}
+
+// batchFragments renders the pages of each web wrapper that supports batching with a single call, where
+// the returned fragment renderer provides these fragments & renders the rest of the pages with the renderer.
+// pages that are excluded (e.g pages that are cached) are always rendered with the renderer.
+func batchFragments(ctx context.Context, render fragmentRenderer, exclude func(PageRender) bool, props *renderProps, pages ...PageRender) fragmentRenderer {
+	groups := make(map[string][]PageRender)
+	versions := make([]string, 0)
+
+	for _, p := range pages {
+		op := wrapDocRender[p]
+		if op == nil || staticResourceMap[p] || exclude(p) || batchRenderers[op.version] == nil {
+			continue
+		}
+
+		if _, ok := groups[op.version]; !ok {
+			versions = append(versions, op.version)
+		}
+
+		groups[op.version] = append(groups[op.version], p)
+	}
+
+	batched := make(map[PageRender]renderedFragment)
+	for _, version := range versions {
+		group := groups[version]
+
+		// a single page does not benefit from batching
+		if len(group) < 2 {
+			continue
+		}
+
+		keys := make([]string, len(group))
+		data := make([][]byte, len(group))
+		for i, p := range group {
+			keys[i] = string(p)
+			data[i] = props.of(p)
+		}
+
+		docs, err := batchRenderers[version](ctx, keys, data)
+		for i, p := range group {
+			if err != nil {
+				batched[p] = renderedFragment{err: &RenderError{Page: p, Err: err}}
+				continue
+			}
+
+			batched[p] = renderedFragment{doc: docs[i]}
+		}
+	}
+
+	if len(batched) == 0 {
+		return render
+	}
+
+	return func(ctx context.Context, page PageRender, data []byte) (*htmlDoc, error) {
+		if fragment, ok := batched[page]; ok {
+			return fragment.doc, fragment.err
+		}
+
+		return render(ctx, page, data)
+	}
+}
+
+// buildHTMLPages creates the html document given data for orbits manifest and the page's
+// each of the pages is rendered in order, after the previous page has finished rendering.
+func buildHTMLPages(ctx context.Context, render fragmentRenderer, props *renderProps, pages ...PageRender) (*htmlDoc, error) {
+	doc, staticFragments := documentShell(props, pages...)
+
+	for _, p := range pages {
+		fragment := staticFragments[p]
+		if fragment == nil {
+			var err error
+			if fragment, err = render(ctx, p, props.of(p)); err != nil {
+				return nil, err
+			}
+		}
+
+		doc.merge(fragment)
+	}
+
+	return doc, nil
+}
+
+// errorBoundary creates the fragment that is written in place of a page that failed to render
+type errorBoundary func(err error) *htmlDoc
+
+type renderedFragment struct {
+	doc *htmlDoc
+	err error
+	// chunk is a part of a streamed page that is written before the fragment of the page
+	chunk string
+}
+
+// fragmentStream is the stream of a page that is rendered for a streamed response, pages that are able to stream
+// their render (e.g react 18 pages) write each chunk of the page as it is rendered, which is followed by the fragment.
+type fragmentStream chan renderedFragment
+
+func (c fragmentStream) Write(p []byte) (int, error) {
+	c <- renderedFragment{chunk: string(p)}
+	return len(p), nil
+}
+
+// ErrStreamedStatus occurs when a page of a streamed response sets a status or redirect, which cannot be applied
+// as the status of the response has already been written. the fragment of the page is still written.
+var ErrStreamedStatus = errors.New("the status or redirect of the page cannot be applied to a streamed response")
+
+// streamHTMLPages writes the document shell to the response before any of the pages have been rendered,
+// the pages are then rendered concurrently & each fragment is flushed to the response (in order) once it is complete.
+// since the status has already been written, a page that fails to render is replaced with the error boundary,
+// the first of these errors is returned once the document has been completed. for the same reason, a page that
+// sets its own status or redirect fails with ErrStreamedStatus. pages that stream their render are flushed as
+// each chunk of the page is rendered.
+func streamHTMLPages(ctx context.Context, rw http.ResponseWriter, flusher http.Flusher, status int, base *HTMLDocument, render fragmentRenderer, props *renderProps, boundary errorBoundary, pages ...PageRender) error {
+	shell, staticFragments := documentShell(props, pages...)
+	shell.insertInto(base)
+
+	fragments := make([]fragmentStream, len(pages))
+	for i, p := range pages {
+		fragments[i] = make(fragmentStream, 1)
+
+		if fragment := staticFragments[p]; fragment != nil {
+			fragments[i] <- renderedFragment{doc: fragment}
+			continue
+		}
+
+		go func(c fragmentStream, page PageRender) {
+			doc, err := render(context.WithValue(ctx, renderStreamKey, c), page, props.of(page))
+			c <- renderedFragment{doc: doc, err: err}
+		}(fragments[i], p)
+	}
+
+	rw.WriteHeader(status)
+	io.WriteString(rw, base.RenderOpen())
+	flusher.Flush()
+
+	var renderErr error
+	for i, c := range fragments {
+		// the chunks of a streamed page are written until the fragment of the page has been rendered
+		fragment := <-c
+		for ; fragment.doc == nil && fragment.err == nil; fragment = <-c {
+			io.WriteString(rw, fragment.chunk)
+			flusher.Flush()
+		}
+
+		if fragment.err != nil {
+			if renderErr == nil {
+				renderErr = fragment.err
+			}
+
+			fragment.doc = boundary(fragment.err)
+		}
+
+		if (fragment.doc.Status != 0 && fragment.doc.Status != status) || fragment.doc.Redirect != "" {
+			if renderErr == nil {
+				renderErr = &RenderError{Page: pages[i], Err: ErrStreamedStatus}
+			}
+		}
+
+		io.WriteString(rw, fragment.doc.fragment())
+		flusher.Flush()
+	}
+
+	io.WriteString(rw, base.RenderClose())
+	flusher.Flush()
+
+	return renderErr
+}
+
+// reportDevError forwards request errors to the hot reload server during dev mode,
+// where they are displayed by the error overlay of the connected browser.
+var reportDevError = func(r *http.Request, err error) {
+	if CurrentDevMode != DevBundleMode {
+		return
+	}
+
+	message := fmt.Sprintf("<b>%s %s</b><pre>%s</pre>", html.EscapeString(r.Method), html.EscapeString(r.URL.Path), html.EscapeString(err.Error()))
+	body, merr := json.Marshal(map[string]interface{}{"level": 2, "message": message, "origin": "server"})
+	if merr != nil {
+		return
+	}
+
+	go func() {
+		resp, err := http.Post(fmt.Sprintf("http://localhost:%d/log", hotReloadPort), "application/json", bytes.NewReader(body))
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+}
+
+// bundleFile finds the name of the bundle file for the bundle key, the content-hashed name
+// from the bundle manifest is preferred so that long lived cache policies never serve a stale bundle.
+func bundleFile(bundleKey string) string {
+	name := fmt.Sprintf("%s.js", bundleKey)
+	if hashed, ok := bundleManifest[name]; ok {
+		return hashed
+	}
+
+	return name
+}
+
+// debugManifestScript provides the "getManifest" debug function, "getManifest(page)" reads the manifest of the page
+// while "getManifest()" reads the shared manifest or when pages are rendered with their own props, the manifests
+// of each of the pages keyed by their page key.
+const debugManifestScript = `<script class="debug"> const getManifest = (page) => {
+	const read = (el) => el && JSON.parse(el.textContent);
+	const shared = document.getElementById("orbit_manifest");
+	if (page) return read(document.getElementById("orbit_manifest_" + page)) || read(shared);
+	if (shared) return read(shared);
+	return Object.fromEntries(Array.from(document.querySelectorAll("script[id^='orbit_manifest_']"), (el) => [el.id.slice("orbit_manifest_".length), read(el)]));
+} </script>`
+
+// defaultHTMLDoc builds a standard html doc for orbit that also verifies the public directory
+// if override data exits, then it will use that as a base for the HTML document
+func defaultHTMLDoc(override string) *HTMLDocument {
+	// the html override that will provide a basis for the default html doc
+	base := ParseHTML(override)
+	base.Insert(HeadStart, `<meta charset="utf-8" />`)
+
+	// we allow some special operations on the dom for debugging, currently supporting:
+	// - getting the contents of orbit manifest with the function "getManifest"
+	if CurrentDevMode == DevBundleMode {
+		base.Insert(BodyStart,
+			debugManifestScript,
+			`<script class="debug" src="/p/hotreload.js"> </script>`,
+			fmt.Sprintf(`<script class="debug" id="debug_data" type="application/json">{ "hotReloadPort": %d }</script>`, hotReloadPort),
+		)
+	}
+
+	return base
+}
+
+// routeSegmentKind represents how a single segment of a route pattern is matched, the kinds are
+// ordered by their precedence where the lowest kind is the most specific.
+type routeSegmentKind int
+
+const (
+	staticSegment routeSegmentKind = iota
+	regexSegment
+	slugSegment
+	optionalSegment
+	catchAllSegment
+)
+
+type routeSegment struct {
+	kind  routeSegmentKind
+	value string
+	regex *regexp.Regexp
+}
+
+// route is a single pattern registered to the router
+type route struct {
+	method   string
+	pattern  string
+	segments []routeSegment
+	subtree  bool
+	order    int
+	handler  http.HandlerFunc
+}
+
+// parseRoutePattern parses a route pattern in the form of "[METHOD ]/path", where each segment of the path can be:
+//   - a static segment e.g "/users"
+//   - a slug "{id}", a slug with a regex constraint "{id:[0-9]+}" or an optional slug "{id?}"
+//   - a catch-all slug "{rest...}" that matches the remainder of the path, this must be the final segment
+//
+// patterns that end with a trailing slash match any path under it (like http.ServeMux) e.g "/" matches all paths.
+func parseRoutePattern(pattern string) (*route, error) {
+	r := &route{pattern: pattern, segments: make([]routeSegment, 0)}
+
+	path := strings.TrimSpace(pattern)
+	if i := strings.Index(path, " "); i > 0 {
+		r.method = strings.ToUpper(path[:i])
+		path = strings.TrimSpace(path[i+1:])
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("route pattern '%s' must begin with '/'", pattern)
+	}
+
+	r.subtree = strings.HasSuffix(path, "/")
+
+	for _, p := range splitPath(path) {
+		segment, err := parseRouteSegment(pattern, p)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(r.segments) > 0 {
+			prev := r.segments[len(r.segments)-1]
+
+			if prev.kind == catchAllSegment {
+				return nil, fmt.Errorf("route pattern '%s' must end with its catch-all slug", pattern)
+			}
+
+			if prev.kind == optionalSegment && segment.kind != optionalSegment && segment.kind != catchAllSegment {
+				return nil, fmt.Errorf("route pattern '%s' can only have optional slugs after an optional slug", pattern)
+			}
+		}
+
+		r.segments = append(r.segments, segment)
+	}
+
+	return r, nil
+}
+
+// parseRouteSegment parses a single segment of the route pattern
+func parseRouteSegment(pattern string, p string) (routeSegment, error) {
+	if !strings.HasPrefix(p, "{") || !strings.HasSuffix(p, "}") {
+		return routeSegment{kind: staticSegment, value: p}, nil
+	}
+
+	name := p[1 : len(p)-1]
+	segment := routeSegment{kind: slugSegment, value: name}
+
+	// the constraint follows the first ":" of the slug, so the suffixes of the name are not read from the constraint
+	// e.g "{id:[0-9]?}" is a constrained slug, while "{id?:[0-9]+}" is an optional constrained slug.
+	if parts := strings.SplitN(name, ":", 2); len(parts) == 2 {
+		regex, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", parts[1]))
+		if err != nil {
+			return segment, fmt.Errorf("route pattern '%s' has an invalid constraint: %s", pattern, err)
+		}
+
+		name = parts[0]
+		segment = routeSegment{kind: regexSegment, value: name, regex: regex}
+	}
+
+	switch {
+	case strings.HasSuffix(name, "..."):
+		if segment.regex != nil {
+			return segment, fmt.Errorf("route pattern '%s' has a constrained catch-all slug", pattern)
+		}
+
+		segment = routeSegment{kind: catchAllSegment, value: strings.TrimSuffix(name, "...")}
+	case strings.HasSuffix(name, "?"):
+		segment = routeSegment{kind: optionalSegment, value: strings.TrimSuffix(name, "?"), regex: segment.regex}
+	}
+
+	if segment.value == "" {
+		return segment, fmt.Errorf("route pattern '%s' contains an unnamed slug", pattern)
+	}
+
+	return segment, nil
+}
+
+// splitPath splits the path into each of its non-empty segments
+func splitPath(path string) []string {
+	trimmed := strings.Trim(path, "/")
+	if trimmed == "" {
+		return []string{}
+	}
+
+	return strings.Split(trimmed, "/")
+}
+
+// match attempts to match the provided path segments, returning the slugs found in the path
+func (rt *route) match(parts []string) (map[string]string, bool) {
+	slugs := make(map[string]string)
+
+	for i, segment := range rt.segments {
+		if segment.kind == catchAllSegment {
+			slugs[segment.value] = strings.Join(parts[minInt(i, len(parts)):], "/")
+			return slugs, true
+		}
+
+		if i >= len(parts) {
+			if segment.kind == optionalSegment {
+				continue
+			}
+
+			return nil, false
+		}
+
+		switch segment.kind {
+		case staticSegment:
+			if parts[i] != segment.value {
+				return nil, false
+			}
+		default:
+			// optional slugs may also be constrained
+			if segment.regex != nil && !segment.regex.MatchString(parts[i]) {
+				return nil, false
+			}
+			slugs[segment.value] = parts[i]
+		}
+	}
+
+	if len(parts) > len(rt.segments) && !rt.subtree {
+		return nil, false
+	}
+
+	return slugs, true
+}
+
+// allowsMethod determines if the route accepts the request method, routes that accept GET also accept HEAD.
+func (rt *route) allowsMethod(method string) bool {
+	return rt.method == "" || rt.method == method || (rt.method == http.MethodGet && method == http.MethodHead)
+}
+
+// precedes determines if the route should be matched before the other route. Routes are compared segment by segment
+// where the more specific segment kind wins, followed by the longer route, exact matches before subtree matches,
+// method constrained routes before unconstrained ones & lastly the order in which they were registered.
+func (rt *route) precedes(other *route) bool {
+	for i := 0; i < len(rt.segments) && i < len(other.segments); i++ {
+		if rt.segments[i].kind != other.segments[i].kind {
+			return rt.segments[i].kind < other.segments[i].kind
+		}
+	}
+
+	if len(rt.segments) != len(other.segments) {
+		return len(rt.segments) > len(other.segments)
+	}
+
+	if rt.subtree != other.subtree {
+		return !rt.subtree
+	}
+
+	if (rt.method == "") != (other.method == "") {
+		return rt.method != ""
+	}
+
+	return rt.order < other.order
+}
+
+// minInt is named apart from the "min" builtin, which would otherwise be shadowed within the generated package
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// router matches incoming requests to registered routes with a deterministic precedence
+type router struct {
+	routes   []*route
+	notFound http.HandlerFunc
+}
+
+var ErrDuplicateRoute = errors.New("route pattern has already been registered")
+
+// handle registers the handler to the provided route pattern
+func (rr *router) handle(pattern string, handler http.HandlerFunc) error {
+	r, err := parseRoutePattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range rr.routes {
+		if existing.method == r.method && existing.pattern == r.pattern {
+			return fmt.Errorf("%w: '%s'", ErrDuplicateRoute, pattern)
+		}
+	}
+
+	r.order = len(rr.routes)
+	r.handler = handler
+
+	rr.routes = append(rr.routes, r)
+	sort.SliceStable(rr.routes, func(i, j int) bool {
+		return rr.routes[i].precedes(rr.routes[j])
+	})
+
+	return nil
+}
+
+// find finds the first route that matches the path & method of the request, if a route matches the
+// path but not the method, the methods that would have been allowed are returned instead.
+func (rr *router) find(r *http.Request) (*route, map[string]string, []string) {
+	parts := splitPath(r.URL.Path)
+	allowed := make([]string, 0)
+	seen := make(map[string]bool)
+
+	for _, rt := range rr.routes {
+		slugs, ok := rt.match(parts)
+		if !ok {
+			continue
+		}
+
+		if !rt.allowsMethod(r.Method) {
+			// many routes of the same method can match the path, each method is only allowed once
+			if !seen[rt.method] {
+				allowed = append(allowed, rt.method)
+				seen[rt.method] = true
+			}
+			continue
+		}
+
+		return rt, slugs, nil
+	}
+
+	return nil, nil, allowed
+}
+
+func (rr *router) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	rt, slugs, allowed := rr.find(r)
+
+	if rt != nil {
+		rt.handler(rw, r.WithContext(context.WithValue(r.Context(), routeSlugsKey, slugs)))
+		return
+	}
+
+	if len(allowed) > 0 {
+		rw.Header().Set("Allow", strings.Join(allowed, ", "))
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if rr.notFound != nil {
+		rr.notFound(rw, r)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNotFound)
+}
+
+type routeCtxKey string
+
+const routeSlugsKey routeCtxKey = "routeSlugs"
+
+// renderRequestKey is the context key of the http request that the pages are being rendered for
+const renderRequestKey routeCtxKey = "renderRequest"
+
+// renderRequest finds the http request that the pages are being rendered for, this is provided to server side renderers
+func renderRequest(ctx context.Context) *http.Request {
+	r, _ := ctx.Value(renderRequestKey).(*http.Request)
+	return r
+}
+
+// ForwardedRequestHeaders are the headers of the request that are provided to server side renderers, the other
+// headers (e.g "Cookie" or "Authorization") are not provided so that a render cannot depend on the user of the request.
+var ForwardedRequestHeaders = []string{"Accept-Language", "User-Agent"}
+
+const renderStreamKey routeCtxKey = "renderStream"
+
+// renderStream is the writer of the page that is being rendered for a streamed response, where nil denotes that
+// the response is not streamed. the chunks written to the stream are written to the response before the fragment.
+func renderStream(ctx context.Context) io.Writer {
+	w, _ := ctx.Value(renderStreamKey).(io.Writer)
+	return w
+}
+
+// renderRequestVary is the part of the request that is provided to server side renderers, the cached renders
+// of a page vary by it as the render of a page can depend on it (e.g the locale of the request).
+func renderRequestVary(ctx context.Context) string {
+	r := renderRequest(ctx)
+	if r == nil {
+		return ""
+	}
+
+	vary := strings.Builder{}
+	vary.WriteString(fmt.Sprintf("%s %s %s", r.Method, r.Host, r.URL.RequestURI()))
+
+	for _, header := range ForwardedRequestHeaders {
+		vary.WriteString(fmt.Sprintf("\n%s: %s", http.CanonicalHeaderKey(header), strings.Join(r.Header.Values(header), ", ")))
+	}
+
+	return vary.String()
+}
+
+// CacheEntry is a rendered page fragment that is held by a render cache
+type CacheEntry struct {
+	Head       []string
+	Body       []string
+	Status     int
+	Redirect   string
+	RenderedAt time.Time
+}
+
+// RenderCache stores the rendered fragments of pages, so that pages rendered with identical props (for an identical
+// request e.g the url & ForwardedRequestHeaders) do not need to be rendered again. implementations are required to
+// be safe for concurrent use.
+type RenderCache interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, entry *CacheEntry)
+	Delete(key string)
+}
+
+// CachePolicy determines how long the rendered fragments of a page are cached for.
+// once the TTL has elapsed, the stale fragment continues to be served for the StaleWhileRevalidate
+// duration while it is rendered again in the background.
+type CachePolicy struct {
+	TTL                  time.Duration
+	StaleWhileRevalidate time.Duration
+}
+
+// memoryCache is an in-memory render cache that evicts the least recently used entries
+type memoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// NewMemoryCache creates an in-memory render cache that holds at most maxEntries fragments
+func NewMemoryCache(maxEntries int) RenderCache {
+	return &memoryCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+func (m *memoryCache) Get(key string) (*CacheEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	m.order.MoveToFront(el)
+	return el.Value.(*memoryCacheItem).entry, true
+}
+
+func (m *memoryCache) Set(key string, entry *CacheEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.entries[key]; ok {
+		el.Value.(*memoryCacheItem).entry = entry
+		m.order.MoveToFront(el)
+		return
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryCacheItem{key: key, entry: entry})
+
+	for m.maxEntries > 0 && m.order.Len() > m.maxEntries {
+		oldest := m.order.Back()
+
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryCacheItem).key)
+	}
+}
+
+func (m *memoryCache) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.entries[key]; ok {
+		m.order.Remove(el)
+		delete(m.entries, key)
+	}
+}
+
+// defaultCacheEntries is the size of the render cache that is used when a cache has not been provided
+const defaultCacheEntries = 1000
+
+// renderCacheKey creates the cache key of a page rendered with the provided props for the request of the context
+func renderCacheKey(ctx context.Context, page PageRender, data []byte) string {
+	h := sha256.New()
+	h.Write(data)
+	h.Write([]byte{0})
+	h.Write([]byte(renderRequestVary(ctx)))
+
+	return fmt.Sprintf("%s:%x", page, h.Sum(nil))
+}
+
+// muxHandle is used to inject the base mux handler behavior
+type MuxHandler interface {
+	HandleFunc(string, func(http.ResponseWriter, *http.Request))
+	Handle(string, http.Handler)
+	ServeHTTP(http.ResponseWriter, *http.Request)
+}
+
+type Serve struct {
+	mux         MuxHandler
+	doc         *HTMLDocument
+	pageLoaders map[PageRender]PageLoader
+	streaming   bool
+	middleware  []Middleware
+	router      *router
+	routerBound bool
+	errorPages  map[int]PageRender
+	bundleOpts  *bundleServerOpts
+
+	renderCache   RenderCache
+	cachePolicies map[PageRender]CachePolicy
+	revalidating  map[string]bool
+	cacheMu       sync.Mutex
+}
+
+var ErrPageNotInRouteTable = errors.New("the provided page cannot be used with SetPageProps or SetPageLoader, these pages require the orbit:route prefix")
+
+// ErrPageNotFound can be returned by a page loader to respond with the not found page
+var ErrPageNotFound = errors.New("page not found")
+
+// PageLoader loads the props of a route table page for the incoming request
+type PageLoader func(c *Request) (interface{}, error)
+
+// RedirectError can be returned by a page loader to redirect the request rather than rendering the page
+type RedirectError struct {
+	URL    string
+	Status int
 }
 
-// DefaultPage defines the standard behavior for a orbit page handler
-type DefaultPage interface {
-	Handle(*Request)
+func (e *RedirectError) Error() string {
+	return fmt.Sprintf("redirect (%d) to '%s'", e.Status, e.URL)
 }
 
-// htmlDoc represents a basic document model that will be rendered upon build request
-type htmlDoc struct {
-	Head []string
-	Body []string
+// Redirect creates an error that redirects the request to the url with the provided status
+func Redirect(url string, status int) error {
+	return &RedirectError{URL: url, Status: status}
 }
 
-// render renders the document out to a single string
-func (s *htmlDoc) render() string {
-	return fmt.Sprintf(`
-	<!doctype html>
-	<html lang="en">
-	<head>%s</head>
-	<body>%s</body>
-	</html>`, strings.Join(s.Head, ""), strings.Join(s.Body, ""))
+// SetPageProps sets the constant props of a route table page
+func (s *Serve) SetPageProps(page PageRender, handler func() map[string]interface{}) error {
+	return s.SetPageLoader(page, func(c *Request) (interface{}, error) {
+		return handler(), nil
+	})
 }
 
-func (s *htmlDoc) merge(doc *htmlDoc) *htmlDoc {
-	s.Body = append(doc.Body, s.Body...)
-	s.Head = append(doc.Head, s.Head...)
+// SetPageLoader sets the loader that creates the props of a route table page for each request.
+// the loader can return ErrPageNotFound or the result of Redirect to avoid rendering the page,
+// any other error is handled by the error page of a 500 status.
+func (s *Serve) SetPageLoader(page PageRender, loader PageLoader) error {
+	if routeTable[page] == "" {
+		return ErrPageNotInRouteTable
+	}
 
-	return s
+	if s.pageLoaders == nil {
+		s.pageLoaders = make(map[PageRender]PageLoader)
+	}
+
+	s.pageLoaders[page] = loader
+	return nil
 }
 
-// parseStaticDocument attempts to find the specified document and return it as a string
-func parseStaticDocument(path string) (string, error) {
-	_, err := os.Stat(path)
-	if !os.IsNotExist(err) {
-		f, _ := ioutil.ReadFile(path)
+// loadPage runs the loader of the route table page & renders the page with the loaded props
+func (s *Serve) loadPage(c *Request, page PageRender) {
+	var props interface{} = make(map[string]interface{})
 
-		return string(f), nil
+	if loader := s.pageLoaders[page]; loader != nil {
+		loaded, err := loader(c)
+
+		var redirect *RedirectError
+		switch {
+		case errors.As(err, &redirect):
+			http.Redirect(c.Response, c.Request, redirect.URL, redirect.Status)
+			return
+		case errors.Is(err, ErrPageNotFound):
+			s.getRouter().notFound(c.Response, c.Request)
+			return
+		case err != nil:
+			s.writeError(c.Response, c.Request, http.StatusInternalServerError, err)
+			return
+		}
+
+		props = loaded
 	}
 
-	return "", fmt.Errorf("static document does not exist for '%s'", publicDir)
+	c.RenderPage(page, props)
 }
 
-// build buildHTMLPages creates the htmldocument given data for orbits manifest and the page's
-func buildHTMLPages(data []byte, pages ...PageRender) *htmlDoc {
-	body := make([]string, 0)
-	head := make([]string, 0)
-	isWrapped := make(map[string]bool)
+// Use attaches middleware that will be ran for every orbit page handler, this includes
+// the handlers for pages in the route table but excludes the bundle fileserver.
+// global middleware is ran before any of the middleware that is attached to a specific route.
+func (s *Serve) Use(middleware ...Middleware) {
+	s.middleware = append(s.middleware, middleware...)
+}
 
-	for _, p := range pages {
-		// if the page is of static origin, we first check to see if it exists on the file system
-		// if it does, it will be applied to the current html document, rather than returned directly
-		// this is to support the usage of static html within micro-frontends
-		if staticResourceMap[p] {
-			staticDocument, err := parseStaticDocument(fmt.Sprintf("%s%c%s", http.Dir(bundleDir), os.PathSeparator, p))
-			if err == nil {
-				body = append(body, innerHTML(string(staticDocument), "<body>", "</body>"))
-				head = append(head, innerHTML(string(staticDocument), "<head>", "</head>"))
-				continue
-			}
-		}
+// SetStreaming toggles the streaming render mode. When enabled, the head & static shell of the document
+// are flushed to the client immediately & each page fragment is streamed as soon as it has been rendered.
+// streaming requires the response writer to implement http.Flusher, otherwise the document is buffered.
+// page loaders & render hooks are ran before the response is started, so their redirects are still applied.
+// the status or redirect set by a page while it renders cannot be applied, the render fails with ErrStreamedStatus.
+func (s *Serve) SetStreaming(enabled bool) {
+	s.streaming = enabled
+}
 
-		// wrapping page content should only happen once as it just creates
-		// the requirements for the specific web wrapper to work correctly
-		pv := wrapDocRender[p]
-		if pv != nil && !isWrapped[pv.version] {
-			isWrapped[pv.version] = true
+// SetRenderCache sets the cache that is used to store the rendered fragments of pages with a cache policy
+func (s *Serve) SetRenderCache(cache RenderCache) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
 
-			for _, b := range pageDependencies[p] {
-				head = append(head, b)
-			}
-		}
+	s.renderCache = cache
+}
+
+// SetCachePolicy enables render caching for the page, pages without a policy are rendered for every request.
+// if a render cache has not been set, an in-memory cache is used.
+func (s *Serve) SetCachePolicy(page PageRender, policy CachePolicy) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	if s.cachePolicies == nil {
+		s.cachePolicies = make(map[PageRender]CachePolicy)
 	}
 
-	html := &htmlDoc{
-		Head: head,
-		Body: body,
+	if s.renderCache == nil {
+		s.renderCache = NewMemoryCache(defaultCacheEntries)
 	}
 
-	ctx := context.Background()
-	for _, p := range pages {
-		if op := wrapDocRender[p]; op != nil {
-			html, ctx = op.fn(ctx, string(p), data, html)
+	s.cachePolicies[page] = policy
+}
+
+// hasCachePolicy reports if the page is rendered using the render cache
+func (s *Serve) hasCachePolicy(page PageRender) bool {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	_, ok := s.cachePolicies[page]
+	return ok
+}
+
+// renderCachedFragment renders the page fragment using the render cache if the page has a cache policy
+func (s *Serve) renderCachedFragment(ctx context.Context, page PageRender, data []byte) (*htmlDoc, error) {
+	s.cacheMu.Lock()
+	policy, ok := s.cachePolicies[page]
+	cache := s.renderCache
+	s.cacheMu.Unlock()
+
+	if !ok || cache == nil {
+		return renderFragment(ctx, page, data)
+	}
+
+	// the cached fragments contain the entire page, so cached pages are not streamed
+	ctx = context.WithValue(ctx, renderStreamKey, nil)
+	key := renderCacheKey(ctx, page, data)
+
+	if entry, ok := cache.Get(key); ok {
+		age := time.Since(entry.RenderedAt)
+
+		if age < policy.TTL {
+			return entry.fragment(), nil
+		}
+
+		if age < policy.TTL+policy.StaleWhileRevalidate {
+			s.revalidate(ctx, cache, key, page, data)
+			return entry.fragment(), nil
 		}
+
+		cache.Delete(key)
+	}
+
+	fragment, err := renderFragment(ctx, page, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fragment.fallback {
+		cache.Set(key, newCacheEntry(fragment))
 	}
 
-	return html
+	return fragment, nil
 }
 
-// innerHTML is a utility function that assists with the parsing the content of html tags
-// it does this by returning the subset of the two provided strings "subStart" & "subEnd"
-func innerHTML(str string, subStart string, subEnd string) string {
-	return strings.Split(strings.Join(strings.Split(str, subStart)[1:], ""), subEnd)[0]
+// revalidateTimeout is the deadline of a background render, so that a renderer that hangs does not hold the revalidation
+const revalidateTimeout = 30 * time.Second
+
+// detachedContext keeps the values of the request context (e.g the request that is provided to the renderers),
+// without its cancellation, as the background render outlives the request.
+type detachedContext struct {
+	context.Context
 }
 
-// defaultHTMLDoc builds a standard html doc for orbit that also verifies the public directory
-// if override data exits, then it will use that as a base for the HTML document
-func defaultHTMLDoc(override string) *htmlDoc {
-	base := &htmlDoc{Head: []string{`<meta charset="utf-8" />`}, Body: []string{}}
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
 
-	// we allow some special operations on the dom for debugging, currently supporting:
-	// - getting the contents of orbit manifest with the function "getManifest"
-	if CurrentDevMode == DevBundleMode {
-		base.Body = append(base.Body, `<script class="debug"> const getManifest = () => JSON.parse(document.getElementById("orbit_manifest").textContent) </script>`)
-		base.Body = append(base.Body, `<script class="debug" src="/p/hotreload.js"> </script>`)
-		base.Body = append(base.Body, fmt.Sprintf(`<script class="debug" id="debug_data" type="application/json">{ "hotReloadPort": %d }</script>`, hotReloadPort))
+// revalidate renders the page in the background & replaces the stale entry in the cache,
+// only a single render is performed for each key regardless of how many requests are served stale.
+func (s *Serve) revalidate(ctx context.Context, cache RenderCache, key string, page PageRender, data []byte) {
+	s.cacheMu.Lock()
+	if s.revalidating == nil {
+		s.revalidating = make(map[string]bool)
 	}
 
-	// the html override that will provide a basis for the default html doc
-	if override != "" {
-		base.Body = append(base.Body, innerHTML(string(override), "<body>", "</body>"))
-		base.Head = append(base.Head, innerHTML(string(override), "<head>", "</head>"))
+	if s.revalidating[key] {
+		s.cacheMu.Unlock()
+		return
+	}
+
+	s.revalidating[key] = true
+	s.cacheMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(detachedContext{ctx}, revalidateTimeout)
+
+	go func() {
+		defer cancel()
+
+		// a fallback render would replace the stale render with the page that is rendered on the client
+		if fragment, err := renderFragment(ctx, page, data); err == nil && !fragment.fallback {
+			cache.Set(key, newCacheEntry(fragment))
+		}
+
+		s.cacheMu.Lock()
+		delete(s.revalidating, key)
+		s.cacheMu.Unlock()
+	}()
+}
+
+func newCacheEntry(fragment *htmlDoc) *CacheEntry {
+	return &CacheEntry{
+		Head:       append([]string{}, fragment.Head...),
+		Body:       append([]string{}, fragment.Body...),
+		Status:     fragment.Status,
+		Redirect:   fragment.Redirect,
+		RenderedAt: time.Now(),
+	}
+}
+
+// fragment creates a copy of the cached fragment, so that the cached entry cannot be modified
+func (e *CacheEntry) fragment() *htmlDoc {
+	return &htmlDoc{
+		Head:     append([]string{}, e.Head...),
+		Body:     append([]string{}, e.Body...),
+		Status:   e.Status,
+		Redirect: e.Redirect,
 	}
+}
+
+// documentBase creates a copy of the base document with the head of the render applied,
+// the static head tags of the pages are applied first, so that the request head takes precedence.
+func (s *Serve) documentBase(head *Head, pages ...PageRender) *HTMLDocument {
+	base := s.doc.Clone()
+
+	pagesHead(pages...).extend(head).apply(base)
 
 	return base
 }
 
-// parseSlug will parse slugs from the incoming path provided initial slugKeys and return a map of the slugs
-// in map[string]string form where the key is the slug name & value is the value as it appears in the path
-func parseSlug(slugKeys map[int]string, path string) map[string]string {
-	slugs := make(map[string]string)
-	if len(slugKeys) > 0 {
-		paths := strings.Split(path, "/")
+// writeHTMLPages renders the provided pages to the response writer
+func (s *Serve) writeHTMLPages(rw http.ResponseWriter, r *http.Request, status int, head *Head, props *renderProps, pages ...PageRender) error {
+	base := s.documentBase(head, pages...)
+	ctx := context.WithValue(r.Context(), renderRequestKey, r)
 
-		for idx, p := range paths {
-			key := slugKeys[idx]
-			if key != "" {
-				slugs[key] = p
-			}
+	if flusher, ok := rw.(http.Flusher); ok && s.streaming {
+		return streamHTMLPages(ctx, rw, flusher, status, base, s.renderCachedFragment, props, s.errorBoundary(r), pages...)
+	}
+
+	render := batchFragments(ctx, s.renderCachedFragment, s.hasCachePolicy, props, pages...)
+
+	doc, err := buildHTMLPages(ctx, render, props, pages...)
+	if err != nil {
+		s.writeError(rw, r, http.StatusInternalServerError, err)
+		return err
+	}
+
+	if doc.Redirect != "" {
+		redirectStatus := doc.Status
+		if redirectStatus < 300 || redirectStatus > 399 {
+			redirectStatus = http.StatusFound
 		}
+
+		http.Redirect(rw, r, doc.Redirect, redirectStatus)
+		return nil
+	}
+
+	if doc.Status != 0 {
+		status = doc.Status
 	}
 
-	return slugs
+	rw.WriteHeader(status)
+	rw.Write([]byte(doc.insertInto(base).Render()))
+
+	return nil
 }
 
-// parsePathSlugs will parse initial slugs found in a path, these slugs can be identified with
-// the "{" char prepended & "}" appended to the path/subpath e.g "/path/{thing}" will represent "thing" as a slug key.
-func parsePathSlugs(path string) (map[int]string, string) {
-	slugKeys := make(map[int]string)
+// SetErrorPage sets the page that is rendered when a request fails with the provided status code.
+// the page receives the "status", "message" & "path" of the request as props, during dev mode
+// the underlying error is also provided with the "error" prop.
+func (s *Serve) SetErrorPage(status int, page PageRender) {
+	if s.errorPages == nil {
+		s.errorPages = make(map[int]PageRender)
+	}
 
-	validInitial := make([]string, 0)
-	slide := true
-	if strings.Contains(path, "{") {
-		paths := strings.Split(path, "/")
-		for idx, p := range paths {
-			if strings.Contains(p, "{") {
-				slide = false
-				slugKeys[idx] = p[1 : len(p)-1]
-			}
+	s.errorPages[status] = page
+}
 
-			if slide {
-				validInitial = append(validInitial, p)
-			}
-		}
+// errorProps creates the props that are provided to an error page
+func errorProps(r *http.Request, status int, err error) map[string]interface{} {
+	props := map[string]interface{}{
+		"status":  status,
+		"message": http.StatusText(status),
+		"path":    r.URL.Path,
 	}
 
-	finalPath := path
-	if len(slugKeys) > 0 {
-		finalPath = fmt.Sprintf("%s/", strings.Join(validInitial, "/"))
+	if CurrentDevMode == DevBundleMode && err != nil {
+		props["error"] = err.Error()
 	}
 
-	return slugKeys, finalPath
+	return props
 }
 
-// muxHandle is used to inject the base mux handler behavior
-type MuxHandler interface {
-	HandleFunc(string, func(http.ResponseWriter, *http.Request))
-	Handle(string, http.Handler)
-	ServeHTTP(http.ResponseWriter, *http.Request)
+// writeError writes the error page of the status to the response, if an error page has not been
+// set for the status (or it fails to render, or the error is bare) then only the status is written.
+func (s *Serve) writeError(rw http.ResponseWriter, r *http.Request, status int, err error) {
+	if err != nil {
+		reportDevError(r, err)
+	}
+
+	var bare *bareError
+	page, ok := s.errorPages[status]
+	if !ok || errors.As(err, &bare) {
+		rw.WriteHeader(status)
+		return
+	}
+
+	d, merr := json.Marshal(errorProps(r, status, err))
+	if merr != nil {
+		rw.WriteHeader(status)
+		return
+	}
+
+	doc, rerr := buildHTMLPages(r.Context(), renderFragment, sharedProps(d), page)
+	if rerr != nil {
+		reportDevError(r, rerr)
+		rw.WriteHeader(status)
+		return
+	}
+
+	rw.WriteHeader(status)
+	rw.Write([]byte(doc.insertInto(s.documentBase(nil, page)).Render()))
 }
 
-type Serve struct {
-	mux              MuxHandler
-	doc              *htmlDoc
-	pagePropHandlers map[PageRender]func() map[string]interface{}
+// errorBoundary creates the error boundary for a streamed response. error pages cannot be used here
+// as the document has already been started, so a placeholder is written in place of the failed page.
+func (s *Serve) errorBoundary(r *http.Request) errorBoundary {
+	return func(err error) *htmlDoc {
+		reportDevError(r, err)
+
+		page := ""
+		var renderErr *RenderError
+		if errors.As(err, &renderErr) {
+			page = string(renderErr.Page)
+		}
+
+		detail := ""
+		if CurrentDevMode == DevBundleMode {
+			detail = html.EscapeString(err.Error())
+		}
+
+		return &htmlDoc{
+			Head: []string{},
+			Body: []string{fmt.Sprintf(`<div class="orbit_error_boundary" data-page="%s">%s</div>`, html.EscapeString(page), detail)},
+		}
+	}
 }
 
-var ErrPageNotInRouteTable = errors.New("the provided page cannot be used with SetPageProps, these pages require the orbit:route prefix")
+// SetNotFoundPage sets the page that is rendered when a request does not match any of the routes,
+// the page is rendered with a 404 status and receives the request path as the "path" prop.
+func (s *Serve) SetNotFoundPage(page PageRender) {
+	s.getRouter().notFound = func(rw http.ResponseWriter, r *http.Request) {
+		d, err := json.Marshal(map[string]interface{}{"path": r.URL.Path})
+		if err != nil {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
 
-func (s *Serve) SetPageProps(page PageRender, handler func() map[string]interface{}) error {
-	if routeTable[page] == "" {
-		return ErrPageNotInRouteTable
+		s.writeHTMLPages(rw, r, http.StatusNotFound, nil, sharedProps(d), page)
 	}
+}
 
-	s.pagePropHandlers[page] = handler
-	return nil
+func (s *Serve) getRouter() *router {
+	if s.router == nil {
+		s.router = &router{routes: make([]*route, 0)}
+
+		// by default, the error page of the 404 status is used when a page is not found
+		s.router.notFound = func(rw http.ResponseWriter, r *http.Request) {
+			s.writeError(rw, r, http.StatusNotFound, nil)
+		}
+	}
+
+	return s.router
 }
 
-// HandleFunc attaches a handler to the specified pattern, this handler will be
-// ran upon a match of the request path during an incoming http request.
-func (s *Serve) HandleFunc(path string, handler func(c *Request)) {
-	slugs, path := parsePathSlugs(path)
+// bindRouter binds the router to the mux, this only needs to happen once as the
+// router is responsible for dispatching all of the requests that are not for bundles.
+func (s *Serve) bindRouter() {
+	if s.routerBound {
+		return
+	}
 
-	s.mux.HandleFunc(path, func(rw http.ResponseWriter, r *http.Request) {
-		requestSlugs := make(map[string]string)
+	s.routerBound = true
+	s.mux.HandleFunc("/", s.getRouter().ServeHTTP)
+}
 
-		if len(slugs) > 0 {
-			requestSlugs = parseSlug(slugs, r.URL.Path)
+// HandleFunc attaches a handler to the specified pattern, this handler will be
+// ran upon a match of the request path during an incoming http request.
+// patterns support method constraints, slugs, constraints & catch-alls e.g "GET /users/{id:[0-9]+}/{rest...}"
+// any provided middleware will only be ran for this route, after the global middleware.
+func (s *Serve) HandleFunc(path string, handler func(c *Request), middleware ...Middleware) {
+	err := s.getRouter().handle(path, func(rw http.ResponseWriter, r *http.Request) {
+		requestSlugs, _ := r.Context().Value(routeSlugsKey).(map[string]string)
+		if requestSlugs == nil {
+			requestSlugs = make(map[string]string)
+		}
 
-			if len(requestSlugs) != len(slugs) {
-				rw.WriteHeader(http.StatusBadRequest)
-				return
-			}
+		ctx := &Request{
+			Request:  r,
+			Response: rw,
+			Slugs:    requestSlugs,
 		}
 
-		renderPage := func(page PageRender, data interface{}) {
+		renderPage := func(page PageRender, data interface{}) error {
 			if staticResourceMap[page] {
 				if staticDocument, err := parseStaticDocument(fmt.Sprintf("%s%c%s", http.Dir(bundleDir), os.PathSeparator, page)); err == nil {
 					rw.Write([]byte(staticDocument))
-					return
+					return nil
 				}
 			}
 
-			d, err := json.Marshal(data)
+			props, err := newRenderProps(data, page)
 			if err != nil {
-				rw.WriteHeader(http.StatusInternalServerError)
-				return
+				s.writeError(rw, r, http.StatusInternalServerError, err)
+				return err
 			}
 
-			doc := buildHTMLPages(d, page)
-			doc.merge(s.doc)
-
-			rw.WriteHeader(http.StatusOK)
-			rw.Write([]byte(doc.render()))
+			return s.writeHTMLPages(rw, r, http.StatusOK, ctx.head, props, page)
 		}
 
-		renderPages := func(data interface{}, pages ...PageRender) {
+		renderPages := func(data interface{}, pages ...PageRender) error {
 			// renderPage (single) has some optimizations for micro-frontends
 			// that should be preferred over the generalized method
 			if len(pages) == 1 {
-				renderPage(pages[0], data)
-				return
+				return renderPage(pages[0], data)
 			}
 
-			d, err := json.Marshal(data)
+			props, err := newRenderProps(data, pages...)
 			if err != nil {
-				rw.WriteHeader(http.StatusInternalServerError)
-				return
+				s.writeError(rw, r, http.StatusInternalServerError, err)
+				return err
 			}
 
-			doc := buildHTMLPages(d, pages...)
-			doc.merge(s.doc)
+			return s.writeHTMLPages(rw, r, http.StatusOK, ctx.head, props, pages...)
+		}
+
+		ctx.RenderPage = func(page PageRender, data interface{}) error {
+			if !ctx.runRenderHooks([]PageRender{page}, data) {
+				return nil
+			}
 
-			rw.WriteHeader(http.StatusOK)
-			rw.Write([]byte(doc.render()))
+			return renderPage(page, data)
 		}
 
-		ctx := &Request{
-			RenderPage:  renderPage,
-			RenderPages: renderPages,
-			Request:     r,
-			Response:    rw,
-			Slugs:       requestSlugs,
+		ctx.RenderPages = func(data interface{}, pages ...PageRender) error {
+			if !ctx.runRenderHooks(pages, data) {
+				return nil
+			}
+
+			return renderPages(data, pages...)
 		}
 
-		handler(ctx)
+		chain := append(append([]Middleware{}, s.middleware...), middleware...)
+		chainMiddleware(handler, chain...)(ctx)
 	})
+
+	// similar to the http.ServeMux, an invalid pattern is considered a programming error
+	if err != nil {
+		panic(err)
+	}
+
+	s.bindRouter()
 }
 
 // HandlePage attaches an orbit page to the specified pattern, this handler will be
 // ran upon a match of the request path during an incoming http request
-func (s *Serve) HandlePage(path string, dp DefaultPage) {
-	s.HandleFunc(path, dp.Handle)
+func (s *Serve) HandlePage(path string, dp DefaultPage, middleware ...Middleware) {
+	s.HandleFunc(path, dp.Handle, middleware...)
+}
+
+// Option configures the orbit server upon creation
+type Option func(*Serve)
+
+type bundleCacheRule struct {
+	pattern      string
+	cacheControl string
+}
+
+// bundleServerOpts are the options of the bundle fileserver bound to the "/p/" directory
+type bundleServerOpts struct {
+	cacheRules         []bundleCacheRule
+	etags              bool
+	compressionMinSize int64
+}
+
+func defaultBundleServerOpts() *bundleServerOpts {
+	return &bundleServerOpts{
+		cacheRules:         make([]bundleCacheRule, 0),
+		etags:              true,
+		compressionMinSize: 1024,
+	}
+}
+
+// WithBundleCachePolicy sets the Cache-Control header of the bundle files that match the pattern. the pattern
+// is matched with "path.Match" against the path of the file relative to the bundle directory e.g "*.js".
+// rules are matched in the order that they were provided, files without a matching rule use the policy of the bundle mode.
+func WithBundleCachePolicy(pattern string, cacheControl string) Option {
+	return func(s *Serve) {
+		s.bundleOpts.cacheRules = append(s.bundleOpts.cacheRules, bundleCacheRule{pattern: pattern, cacheControl: cacheControl})
+	}
+}
+
+// WithETags toggles the ETag header of bundle files, etags are enabled by default.
+func WithETags(enabled bool) Option {
+	return func(s *Serve) {
+		s.bundleOpts.etags = enabled
+	}
+}
+
+// WithCompressionMinSize sets the size (in bytes) that a bundle file needs to be before it is compressed, defaults to 1024.
+func WithCompressionMinSize(size int64) Option {
+	return func(s *Serve) {
+		s.bundleOpts.compressionMinSize = size
+	}
+}
+
+// cacheControl finds the cache policy for the bundle file
+func (o *bundleServerOpts) cacheControl(name string) string {
+	for _, rule := range o.cacheRules {
+		if ok, _ := path.Match(rule.pattern, name); ok {
+			return rule.cacheControl
+		}
+	}
+
+	switch CurrentDevMode {
+	case ProdBundleMode:
+		return "public, max-age=31536000, immutable"
+	default:
+		return "no-cache, no-store, max-age=0, must-revalidate"
+	}
+}
+
+// incompressibleExtensions are the file extensions of assets that are already compressed
+var incompressibleExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".avif": true, ".ico": true,
+	".woff": true, ".woff2": true, ".mp3": true, ".mp4": true, ".webm": true,
+	".gz": true, ".br": true, ".zst": true, ".zip": true,
+}
+
+// precompressedEncodings are the encodings of the sidecar files that can be created during the build
+// in the order that they are preferred, e.g "bundle.js.br" is served in place of "bundle.js"
+var precompressedEncodings = []struct {
+	encoding  string
+	extension string
+}{
+	{"br", ".br"},
+	{"zstd", ".zst"},
+	{"gzip", ".gz"},
+}
+
+// acceptsEncoding determines if the client accepts the content encoding
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		parts := strings.Split(accepted, ";")
+		if strings.TrimSpace(parts[0]) != encoding {
+			continue
+		}
+
+		// an encoding with a quality of zero is explicitly not accepted
+		for _, param := range parts[1:] {
+			q := strings.TrimSpace(param)
+			if !strings.HasPrefix(q, "q=") {
+				continue
+			}
+
+			if quality, err := strconv.ParseFloat(q[2:], 64); err == nil && quality == 0 {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// openSidecar opens the precompressed sidecar of the file for the first encoding that the client accepts,
+// sidecars that are older than the file are ignored, as they would contain stale content.
+func openSidecar(r *http.Request, filePath string, info os.FileInfo) (string, *os.File, os.FileInfo) {
+	for _, e := range precompressedEncodings {
+		if !acceptsEncoding(r, e.encoding) {
+			continue
+		}
+
+		sidecarInfo, err := os.Stat(filePath + e.extension)
+		if err != nil || sidecarInfo.ModTime().Before(info.ModTime()) {
+			continue
+		}
+
+		f, err := os.Open(filePath + e.extension)
+		if err != nil {
+			continue
+		}
+
+		return e.encoding, f, sidecarInfo
+	}
+
+	return "", nil, nil
 }
 
+// shouldCompress determines if the bundle file should be compressed as it is served
+func (o *bundleServerOpts) shouldCompress(r *http.Request, name string, size int64) bool {
+	return acceptsEncoding(r, "gzip") &&
+		r.Header.Get("Range") == "" &&
+		size >= o.compressionMinSize &&
+		!incompressibleExtensions[strings.ToLower(path.Ext(name))]
+}
+
+// gzipResponseWriter compresses the response only when the full content is written,
+// responses such as "304 Not Modified" are written without compression.
 type gzipResponseWriter struct {
-	io.Writer
 	http.ResponseWriter
+	gz          *gzip.Writer
+	compressing bool
+	wroteHeader bool
 }
 
 func (w *gzipResponseWriter) WriteHeader(status int) {
-	w.Header().Del("Content-Length")
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	if status == http.StatusOK {
+		w.compressing = true
+
+		w.Header().Del("Content-Length")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.gz.Reset(w.ResponseWriter)
+	}
+
 	w.ResponseWriter.WriteHeader(status)
 }
 
 func (w *gzipResponseWriter) Write(b []byte) (int, error) {
-	return w.Writer.Write(b)
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.compressing {
+		return w.gz.Write(b)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *gzipResponseWriter) Close() error {
+	if w.compressing {
+		return w.gz.Close()
+	}
+
+	return nil
 }
 
 // setupMuxRequirements creates the required mux handlers for orbit, these include
@@ -311,29 +1890,72 @@ func (s *Serve) setupMuxRequirements() *Serve {
 		},
 	}
 
+	if s.bundleOpts == nil {
+		s.bundleOpts = defaultBundleServerOpts()
+	}
+	opts := s.bundleOpts
+
 	s.mux.HandleFunc("/p/", func(w http.ResponseWriter, r *http.Request) {
-		// TODO(guy): allow these cache policies to be overwritten
-		switch CurrentDevMode {
-		case DevBundleMode:
-			w.Header().Set("Cache-Control", "no-cache, no-store, max-age=0, must-revalidate")
-		case ProdBundleMode:
-			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		name := strings.TrimPrefix(path.Clean("/"+strings.TrimPrefix(r.URL.Path, "/p/")), "/")
+
+		w.Header().Set("Cache-Control", opts.cacheControl(name))
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		filePath := fmt.Sprintf("%s%c%s", http.Dir(bundleDir), os.PathSeparator, name)
+
+		info, err := os.Stat(filePath)
+		if err != nil || info.IsDir() {
+			http.NotFound(w, r)
+			return
+		}
+
+		// each representation of the file requires its own etag, so the encoding is included in the tag
+		setETag := func(encoding string) {
+			if !opts.etags {
+				return
+			}
+
+			etag := fmt.Sprintf("%x-%x", info.Size(), info.ModTime().UnixNano())
+			if encoding != "" {
+				etag = fmt.Sprintf("%s-%s", etag, encoding)
+			}
+
+			w.Header().Set("ETag", fmt.Sprintf(`W/"%s"`, etag))
 		}
 
-		if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
-			w.Header().Set("Content-Encoding", "gzip")
+		if encoding, sidecar, sidecarInfo := openSidecar(r, filePath, info); sidecar != nil {
+			defer sidecar.Close()
+
+			setETag(encoding)
+
+			w.Header().Set("Content-Encoding", encoding)
+			http.ServeContent(w, r, name, sidecarInfo.ModTime(), sidecar)
+			return
+		}
 
-			gz := pool.Get().(*gzip.Writer)
-			defer pool.Put(gz)
+		f, err := os.Open(filePath)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer f.Close()
 
-			gz.Reset(w)
-			defer gz.Close()
+		if !opts.shouldCompress(r, name, info.Size()) {
+			setETag("")
 
-			http.StripPrefix("/p/", http.FileServer(http.Dir(bundleDir))).ServeHTTP(&gzipResponseWriter{ResponseWriter: w, Writer: gz}, r)
+			http.ServeContent(w, r, name, info.ModTime(), f)
 			return
 		}
 
-		http.StripPrefix("/p/", http.FileServer(http.Dir(bundleDir))).ServeHTTP(w, r)
+		setETag("gzip")
+
+		gz := pool.Get().(*gzip.Writer)
+		defer pool.Put(gz)
+
+		gw := &gzipResponseWriter{ResponseWriter: w, gz: gz}
+		defer gw.Close()
+
+		http.ServeContent(gw, r, name, info.ModTime(), f)
 	})
 
 	return s
@@ -341,22 +1963,28 @@ func (s *Serve) setupMuxRequirements() *Serve {
 
 // Serve returns the mux server
 func (s *Serve) Serve() MuxHandler {
-	// rebind data from the route table
-	for k, v := range routeTable {
-		s.HandleFunc(v, func(c *Request) {
-			props := make(map[string]interface{})
-			if s.pagePropHandlers[k] != nil {
-				props = s.pagePropHandlers[k]()
-			}
+	routePages := make([]PageRender, 0, len(routeTable))
+	for k := range routeTable {
+		routePages = append(routePages, k)
+	}
+
+	// the route table is bound in a consistent order, so that routes of equal precedence are always resolved the same
+	sort.Slice(routePages, func(i, j int) bool { return routePages[i] < routePages[j] })
 
-			c.RenderPage(k, props)
+	// rebind data from the route table
+	for _, k := range routePages {
+		page := k
+		s.HandleFunc(routeTable[page], func(c *Request) {
+			s.loadPage(c, page)
 		})
 	}
 
+	s.bindRouter()
+
 	return s.mux
 }
 
-func setupDoc() *htmlDoc {
+func setupDoc() *HTMLDocument {
 	html := ""
 
 	_, err := os.Stat(publicDir)
@@ -369,14 +1997,21 @@ func setupDoc() *htmlDoc {
 	return defaultHTMLDoc(html)
 }
 
-func New() (*Serve, error) {
+func New(opts ...Option) (*Serve, error) {
 	for _, sfn := range serverStartupTasks {
 		sfn()
 	}
 
-	return (&Serve{
-		mux:              http.NewServeMux(),
-		doc:              setupDoc(),
-		pagePropHandlers: map[PageRender]func() map[string]interface{}{},
-	}).setupMuxRequirements(), nil
+	s := &Serve{
+		mux:         http.NewServeMux(),
+		doc:         setupDoc(),
+		pageLoaders: map[PageRender]PageLoader{},
+		bundleOpts:  defaultBundleServerOpts(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s.setupMuxRequirements(), nil
 }
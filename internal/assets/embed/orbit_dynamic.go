@@ -3,13 +3,14 @@ package orbit
 import (
 	"context"
 	"fmt"
+
+	"github.com/GuyARoss/orbit/pkg/htmlparse"
 )
 
 var bundleDir string = ".orbit/dist"
 
 func deleteMeThing(bundleKey string, data []byte, doc htmlDoc) htmlDoc {
-	doc.Head = append(doc.Head, fmt.Sprintf(`<script id="orbit_manifest" type="application/json">%s</script>`, data))
-	doc.Body = append(doc.Body, fmt.Sprintf(`<script class="orbit_bk" src="/p/%s.js">`, bundleKey))
+	doc.Body = append(doc.Body, fmt.Sprintf(`<script class="orbit_bk" src="/p/%s">`, bundleFile(bundleKey)))
 
 	return doc
 }
@@ -36,10 +37,37 @@ var hotReloadPort = 1000
 var serverStartupTasks = []func(){}
 
 type DocumentRenderer struct {
-	fn      func(context.Context, string, []byte, *htmlDoc) (*htmlDoc, context.Context)
+	fn      func(context.Context, string, []byte, *htmlDoc) (*htmlDoc, error)
 	version string
 }
 
 var wrapDocRender = map[PageRender]*DocumentRenderer{}
 
 var routeTable = map[PageRender]string{}
+
+var bundleManifest = map[string]string{}
+
+var staticHeads = map[PageRender][]staticHeadTag{}
+
+// the html document model is written to the autogenerated http file from "pkg/htmlparse"
+type HTMLDocument = htmlparse.HTMLDocument
+
+type HTMLToken = htmlparse.HTMLToken
+
+var HTMLTokenize = htmlparse.HTMLTokenize
+
+const (
+	HTMLStartTagToken       = htmlparse.HTMLStartTagToken
+	HTMLSelfClosingTagToken = htmlparse.HTMLSelfClosingTagToken
+)
+
+const (
+	HeadStart = htmlparse.HeadStart
+	HeadEnd   = htmlparse.HeadEnd
+	BodyStart = htmlparse.BodyStart
+	BodyEnd   = htmlparse.BodyEnd
+)
+
+var ParseHTML = htmlparse.ParseHTML
+
+var NewHTMLDocument = htmlparse.NewHTMLDocument
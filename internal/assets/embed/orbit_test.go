@@ -2,75 +2,194 @@ package orbit
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
-func TestInnerHTML(t *testing.T) {
-	var tt = []struct {
-		f string
-		s string
-		t string
-		e string
+func TestParseRoutePattern(t *testing.T) {
+	tt := []struct {
+		pattern string
+		method  string
+		kinds   []routeSegmentKind
+		subtree bool
+		isErr   bool
 	}{
-		{"<thing>DATA</blah>", "<thing>", "</blah>", "DATA"},
+		{"/", "", []routeSegmentKind{}, true, false},
+		{"/thing", "", []routeSegmentKind{staticSegment}, false, false},
+		{"/thing/", "", []routeSegmentKind{staticSegment}, true, false},
+		{"GET /thing/{toast}", "GET", []routeSegmentKind{staticSegment, slugSegment}, false, false},
+		{"post /thing/{id:[0-9]+}", "POST", []routeSegmentKind{staticSegment, regexSegment}, false, false},
+		{"/thing/{cat?}/{dog?}", "", []routeSegmentKind{staticSegment, optionalSegment, optionalSegment}, false, false},
+		{"/thing/{rest...}", "", []routeSegmentKind{staticSegment, catchAllSegment}, false, false},
+		{"/thing/{id:[0-9]?}", "", []routeSegmentKind{staticSegment, regexSegment}, false, false},
+		{"/thing/{slug:\\w+?}", "", []routeSegmentKind{staticSegment, regexSegment}, false, false},
+		{"/thing/{id?:[0-9]+}", "", []routeSegmentKind{staticSegment, optionalSegment}, false, false},
+		{"thing", "", nil, false, true},
+		{"/thing/{}", "", nil, false, true},
+		{"/thing/{id:[}", "", nil, false, true},
+		{"/thing/{rest...}/other", "", nil, false, true},
+		{"/thing/{cat?}/{dog}", "", nil, false, true},
+		{"/thing/{rest...:[a-z]+}", "", nil, false, true},
 	}
 
-	for _, d := range tt {
-		c := innerHTML(d.f, d.s, d.t)
+	for i, d := range tt {
+		r, err := parseRoutePattern(d.pattern)
+
+		if d.isErr {
+			if err == nil {
+				t.Errorf("(%d) expected error for pattern '%s'", i, d.pattern)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("(%d) unexpected error %s", i, err)
+			continue
+		}
+
+		if r.method != d.method {
+			t.Errorf("(%d) expected method '%s' got '%s'", i, d.method, r.method)
+		}
+
+		if r.subtree != d.subtree {
+			t.Errorf("(%d) expected subtree %t got %t", i, d.subtree, r.subtree)
+		}
+
+		if len(r.segments) != len(d.kinds) {
+			t.Errorf("(%d) expected %d segments got %d", i, len(d.kinds), len(r.segments))
+			continue
+		}
 
-		if !strings.Contains(c, d.e) {
-			t.Errorf("expected %s got %s", d.e, c)
+		for j, k := range d.kinds {
+			if r.segments[j].kind != k {
+				t.Errorf("(%d) expected segment %d to be kind %d got %d", i, j, k, r.segments[j].kind)
+			}
 		}
 	}
 }
 
-func TestParseSlug(t *testing.T) {
-	var tt = []struct {
-		path   string
-		k      map[int]string
-		s      string
-		expect string
+func TestRouter(t *testing.T) {
+	rr := &router{}
+
+	var matched string
+	var slugs map[string]string
+	register := func(pattern string) {
+		if err := rr.handle(pattern, func(rw http.ResponseWriter, r *http.Request) {
+			matched = pattern
+			slugs, _ = r.Context().Value(routeSlugsKey).(map[string]string)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// registered from least to most specific to ensure registration order does not decide the match
+	register("/")
+	register("/users/{rest...}")
+	register("/users/{name}")
+	register("/users/{id:[0-9]+}")
+	register("/users/me")
+	register("GET /docs/{section?}")
+	register("/static/")
+	register("/pages/{page:[0-9]?}")
+	register("/posts/{id?:[0-9]+}")
+
+	tt := []struct {
+		method  string
+		path    string
+		pattern string
+		slugs   map[string]string
 	}{
-		{"/thing/test", map[int]string{1: "slug"}, "slug", "thing"},
+		{"GET", "/users/me", "/users/me", map[string]string{}},
+		{"GET", "/users/42", "/users/{id:[0-9]+}", map[string]string{"id": "42"}},
+		{"GET", "/users/bob", "/users/{name}", map[string]string{"name": "bob"}},
+		{"GET", "/users/bob/posts/1", "/users/{rest...}", map[string]string{"rest": "bob/posts/1"}},
+		{"GET", "/docs", "GET /docs/{section?}", map[string]string{}},
+		{"HEAD", "/docs/intro", "GET /docs/{section?}", map[string]string{"section": "intro"}},
+		{"GET", "/static/js/app.js", "/static/", map[string]string{}},
+		{"GET", "/anything/else", "/", map[string]string{}},
+		{"GET", "/pages/7", "/pages/{page:[0-9]?}", map[string]string{"page": "7"}},
+		{"GET", "/posts", "/posts/{id?:[0-9]+}", map[string]string{}},
+		{"GET", "/posts/12", "/posts/{id?:[0-9]+}", map[string]string{"id": "12"}},
+		{"GET", "/posts/abc", "/", map[string]string{}},
 	}
 
 	for _, d := range tt {
-		s := parseSlug(d.k, d.path)
+		matched = ""
+
+		r := httptest.NewRequest(d.method, d.path, nil)
+		rr.ServeHTTP(httptest.NewRecorder(), r)
 
-		got := s[d.s]
-		if got != d.expect {
-			t.Errorf("expected %s got %s", d.expect, got)
+		if matched != d.pattern {
+			t.Errorf("%s %s expected to match '%s' got '%s'", d.method, d.path, d.pattern, matched)
+			continue
 		}
-	}
-}
 
-func TestParsePathSlugs(t *testing.T) {
-	tt := []struct {
-		path  string
-		epath string
-		i     int
-		e     string
-	}{
-		{"/thing/{toast}", "/thing/", 2, "toast"},
-		{"/thing", "/thing", 0, ""},
-		{"/thing/{toast}/{cat}", "/thing/", 2, "toast"},
+		for k, v := range d.slugs {
+			if slugs[k] != v {
+				t.Errorf("%s %s expected slug '%s' to be '%s' got '%s'", d.method, d.path, k, v, slugs[k])
+			}
+		}
 	}
 
-	for i, d := range tt {
-		slugs, path := parsePathSlugs(d.path)
+	t.Run("duplicate patterns are rejected", func(t *testing.T) {
+		err := rr.handle("/users/me", func(rw http.ResponseWriter, r *http.Request) {})
+		if !errors.Is(err, ErrDuplicateRoute) {
+			t.Errorf("expected duplicate route error got %v", err)
+		}
+	})
+
+	t.Run("method mismatch", func(t *testing.T) {
+		rr := &router{}
+		rr.handle("GET /thing", func(rw http.ResponseWriter, r *http.Request) {})
+		rr.handle("GET /{name}", func(rw http.ResponseWriter, r *http.Request) {})
+		rr.handle("PUT /thing", func(rw http.ResponseWriter, r *http.Request) {})
+
+		w := httptest.NewRecorder()
+		rr.ServeHTTP(w, httptest.NewRequest("POST", "/thing", nil))
 
-		if slugs[d.i] != d.e {
-			t.Errorf("(%d) expected %s got %s", i, d.e, slugs[d.i])
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("expected status 405 got %d", w.Code)
 		}
 
-		if path != d.epath {
-			t.Errorf("(%d) expected %s got %s", i, d.epath, path)
+		if w.Header().Get("Allow") != "GET, PUT" {
+			t.Errorf("expected allow header 'GET, PUT' got '%s'", w.Header().Get("Allow"))
 		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		rr := &router{}
+		rr.handle("/thing", func(rw http.ResponseWriter, r *http.Request) {})
+
+		w := httptest.NewRecorder()
+		rr.ServeHTTP(w, httptest.NewRequest("GET", "/thing/other", nil))
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404 got %d", w.Code)
+		}
+	})
+}
+
+func TestSetNotFoundPage(t *testing.T) {
+	s := &Serve{doc: NewHTMLDocument()}
+	s.SetNotFoundPage("")
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest("GET", "/missing", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 got %d", w.Code)
+	}
+
+	if !strings.Contains(w.Body.String(), "</html>") {
+		t.Error("expected the not found page to be rendered")
 	}
 }
 
@@ -83,17 +202,8 @@ func TestDefaultHTMLDoc_NoPath(t *testing.T) {
 
 	doc := defaultHTMLDoc(fmt.Sprintf("<head>%s</head><body>%s</body>", headContent, bodyContent))
 
-	hasMeta := false
-	hasHeadContent := false
-	for _, h := range doc.Head {
-		if strings.Contains(h, "meta") {
-			hasMeta = true
-		}
-
-		if strings.Contains(h, headContent) {
-			hasHeadContent = true
-		}
-	}
+	hasMeta := strings.Contains(doc.HeadHTML(), "meta")
+	hasHeadContent := strings.Contains(doc.HeadHTML(), headContent)
 
 	if !hasMeta {
 		t.Error("default doc does not contain meta tag")
@@ -102,17 +212,8 @@ func TestDefaultHTMLDoc_NoPath(t *testing.T) {
 		t.Error("expected override head to exist in final body")
 	}
 
-	hasNewBody := false
-	hasDebugClass := false
-	for _, h := range doc.Body {
-		if strings.Contains(h, bodyContent) {
-			hasNewBody = true
-		}
-
-		if strings.Contains(h, `class="debug"`) {
-			hasDebugClass = true
-		}
-	}
+	hasNewBody := strings.Contains(doc.BodyHTML(), bodyContent)
+	hasDebugClass := strings.Contains(doc.BodyHTML(), `class="debug"`)
 
 	if !hasNewBody {
 		t.Error("expected override body to exist in final body")
@@ -122,6 +223,10 @@ func TestDefaultHTMLDoc_NoPath(t *testing.T) {
 		t.Error("expected debug class to be present during debug mode")
 	}
 
+	if !strings.Contains(doc.BodyHTML(), `"orbit_manifest_" + page`) || !strings.Contains(doc.BodyHTML(), `[id^='orbit_manifest_']`) {
+		t.Error("expected the debug manifest function to read the manifests of the pages")
+	}
+
 	CurrentDevMode = currentMode
 }
 
@@ -183,7 +288,7 @@ func TestSetupMuxRequirements(t *testing.T) {
 				}
 			},
 		},
-		doc: &htmlDoc{[]string{}, []string{}},
+		doc: NewHTMLDocument(),
 	}
 
 	newServe := s.setupMuxRequirements()
@@ -215,7 +320,7 @@ func TestHandlePage(t *testing.T) {
 			},
 			checkPath: func(s string) {},
 		},
-		doc: &htmlDoc{[]string{}, []string{}},
+		doc: NewHTMLDocument(),
 	}
 
 	reg := false
@@ -289,12 +394,12 @@ func TestHandleFunc(t *testing.T) {
 			"/test", "/test",
 		},
 
-		// status bad request slug count is invalid for this request.
+		// status not found when the request does not match the slugs of the route.
 		{
 			func(code int) {
 				reg = true
-				if code != http.StatusBadRequest {
-					t.Errorf("expected status 400 upon incorrectly formatted slugs got %d", code)
+				if code != http.StatusNotFound {
+					t.Errorf("expected status 404 upon unmatched slugs got %d", code)
 				}
 			},
 			func(c *Request) {
@@ -316,7 +421,7 @@ func TestHandleFunc(t *testing.T) {
 				writer:        writer,
 				checkPath:     func(s string) {},
 			},
-			doc: &htmlDoc{[]string{}, []string{}},
+			doc: NewHTMLDocument(),
 		}
 
 		s.HandleFunc(d.path, func(c *Request) {
@@ -346,7 +451,7 @@ func TestSetupMuxRequirements_BundleModes(t *testing.T) {
 			writer:        w,
 			checkPath:     func(s string) {},
 		},
-		doc: &htmlDoc{[]string{}, []string{}},
+		doc: NewHTMLDocument(),
 	}
 
 	bundleModes := []struct {
@@ -432,12 +537,12 @@ func TestHandleFuncs(t *testing.T) {
 			"/test", "/test",
 		},
 
-		// status bad request slug count is invalid for this request.
+		// status not found when the request does not match the slugs of the route.
 		{
 			func(code int) {
 				reg = true
-				if code != http.StatusBadRequest {
-					t.Errorf("expected status 400 upon incorrectly formatted slugs got %d", code)
+				if code != http.StatusNotFound {
+					t.Errorf("expected status 404 upon unmatched slugs got %d", code)
 				}
 			},
 			func(c *Request) {
@@ -472,7 +577,7 @@ func TestHandleFuncs(t *testing.T) {
 				writer:        writer,
 				checkPath:     func(s string) {},
 			},
-			doc: &htmlDoc{[]string{}, []string{}},
+			doc: NewHTMLDocument(),
 		}
 
 		s.HandleFunc(d.path, func(c *Request) {
@@ -491,10 +596,14 @@ func TestServe(t *testing.T) {
 		mux: &mockHandle{
 			requestPath:   "/test",
 			requestMethod: "get",
-			writer:        nil,
-			checkPath:     func(s string) {},
+			writer: &mockResponseWriter{
+				mockWriteHeader: func(statusCode int) {},
+				mockWrite:       func(b []byte) (int, error) { return 0, nil },
+				mockHeader:      func() http.Header { return make(http.Header) },
+			},
+			checkPath: func(s string) {},
 		},
-		doc: &htmlDoc{[]string{}, []string{}},
+		doc: NewHTMLDocument(),
 	}
 	f := s.Serve()
 
@@ -521,7 +630,7 @@ func TestBuildHTMLPages(t *testing.T) {
 
 		ioutil.WriteFile(dir, []byte("<body> thing </body> <head> thint2 </head>"), 0666)
 
-		o := buildHTMLPages([]byte(""), p)
+		o, _ := buildHTMLPages(context.Background(), renderFragment, sharedProps([]byte("")), p)
 
 		if len(o.Head) != 1 && len(o.Body) != 1 {
 			t.Errorf("body and head len do not match")
@@ -532,9 +641,9 @@ func TestBuildHTMLPages(t *testing.T) {
 		p := PageRender("wrapme")
 
 		wrapDocRender[p] = &DocumentRenderer{
-			fn: func(ctx context.Context, s string, b []byte, hd *htmlDoc) (*htmlDoc, context.Context) {
+			fn: func(ctx context.Context, s string, b []byte, hd *htmlDoc) (*htmlDoc, error) {
 				hd.Body = append(hd.Body, "thing thing")
-				return hd, ctx
+				return hd, nil
 			},
 			version: "some_version",
 		}
@@ -543,11 +652,394 @@ func TestBuildHTMLPages(t *testing.T) {
 			wrapDocRender[p] = nil
 		})
 
-		o := buildHTMLPages([]byte(""), p)
+		o, _ := buildHTMLPages(context.Background(), renderFragment, sharedProps([]byte("")), p)
 		if len(o.Body) != 1 {
 			t.Errorf("did not apply wrap doc correctly")
 		}
 	})
+
+	t.Run("shared chunks are loaded once", func(t *testing.T) {
+		csr := PageRender("shared_csr")
+		hydrate := PageRender("shared_hydrate")
+		chunk := `<script src="/p/orbit_shared_1a2b.js"></script>`
+
+		for page, version := range map[PageRender]string{csr: "reactCSR", hydrate: "reactHydrate"} {
+			wrapDocRender[page] = &DocumentRenderer{
+				fn: func(ctx context.Context, s string, b []byte, hd *htmlDoc) (*htmlDoc, error) {
+					hd.Body = append(hd.Body, fmt.Sprintf(`<script src="/p/%s.js"></script>`, s))
+					return hd, nil
+				},
+				version: version,
+			}
+		}
+		pageDependencies[csr] = []string{"<script>csr dependency</script>", chunk}
+		pageDependencies[hydrate] = []string{"<script>hydrate dependency</script>", chunk}
+
+		t.Cleanup(func() {
+			for _, page := range []PageRender{csr, hydrate} {
+				delete(wrapDocRender, page)
+				delete(pageDependencies, page)
+			}
+		})
+
+		o, err := buildHTMLPages(context.Background(), renderFragment, sharedProps([]byte("{}")), csr, hydrate)
+		if err != nil {
+			t.Errorf("did not expect error '%s'", err)
+			return
+		}
+
+		out := o.insertInto(ParseHTML("<head></head><body></body>")).Render()
+		if c := strings.Count(out, chunk); c != 1 {
+			t.Errorf("expected the shared chunk to be loaded once got %d in '%s'", c, out)
+		}
+
+		for _, expected := range []string{"csr dependency", "hydrate dependency", "/p/shared_csr.js", "/p/shared_hydrate.js"} {
+			if !strings.Contains(out, expected) {
+				t.Errorf("expected the document to contain '%s' got '%s'", expected, out)
+			}
+		}
+	})
+
+	t.Run("shared chunks of pages with the same web wrapper are loaded", func(t *testing.T) {
+		react := PageRender("shared_react")
+		vue := PageRender("shared_vue")
+		reactChunk := `<script src="/p/orbit_shared_react.js"></script>`
+		vueChunk := `<script src="/p/orbit_shared_vue.js"></script>`
+
+		for _, page := range []PageRender{react, vue} {
+			wrapDocRender[page] = &DocumentRenderer{
+				fn: func(ctx context.Context, s string, b []byte, hd *htmlDoc) (*htmlDoc, error) {
+					return hd, nil
+				},
+				version: "reactCSR",
+			}
+		}
+		pageDependencies[react] = []string{"<script>csr dependency</script>", reactChunk}
+		pageDependencies[vue] = []string{"<script>csr dependency</script>", vueChunk}
+
+		t.Cleanup(func() {
+			for _, page := range []PageRender{react, vue} {
+				delete(wrapDocRender, page)
+				delete(pageDependencies, page)
+			}
+		})
+
+		o, err := buildHTMLPages(context.Background(), renderFragment, sharedProps([]byte("{}")), react, vue)
+		if err != nil {
+			t.Errorf("did not expect error '%s'", err)
+			return
+		}
+
+		out := o.insertInto(ParseHTML("<head></head><body></body>")).Render()
+		for _, expected := range []string{"csr dependency", reactChunk, vueChunk} {
+			if c := strings.Count(out, expected); c != 1 {
+				t.Errorf("expected '%s' to be loaded once got %d in '%s'", expected, c, out)
+			}
+		}
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	newServe := func() *Serve {
+		return &Serve{
+			mux: &mockHandle{
+				requestPath:   "/test",
+				requestMethod: "get",
+				writer: &mockResponseWriter{
+					mockWriteHeader: func(statusCode int) {},
+					mockWrite:       func(b []byte) (int, error) { return 0, nil },
+					mockHeader:      func() http.Header { return make(http.Header) },
+				},
+				checkPath: func(s string) {},
+			},
+			doc: NewHTMLDocument(),
+		}
+	}
+
+	recordMiddleware := func(order *[]string, name string) Middleware {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(c *Request) {
+				*order = append(*order, name)
+				next(c)
+			}
+		}
+	}
+
+	t.Run("global before route middleware", func(t *testing.T) {
+		order := make([]string, 0)
+
+		s := newServe()
+		s.Use(recordMiddleware(&order, "global_1"), recordMiddleware(&order, "global_2"))
+		s.HandleFunc("/test", func(c *Request) {
+			order = append(order, "handler")
+		}, recordMiddleware(&order, "route"))
+
+		expected := []string{"global_1", "global_2", "route", "handler"}
+		if strings.Join(order, ",") != strings.Join(expected, ",") {
+			t.Errorf("expected order %v got %v", expected, order)
+		}
+	})
+
+	t.Run("render hooks see pages and props", func(t *testing.T) {
+		var seenPages []PageRender
+		var seenData interface{}
+
+		s := newServe()
+		s.Use(func(next HandlerFunc) HandlerFunc {
+			return func(c *Request) {
+				c.BeforeRender(func(c *Request, pages []PageRender, data interface{}) bool {
+					seenPages = pages
+					seenData = data
+					return true
+				})
+				next(c)
+			}
+		})
+
+		s.HandleFunc("/test", func(c *Request) {
+			c.RenderPages("props", "page_1", "page_2")
+		})
+
+		if len(seenPages) != 2 || seenPages[1] != "page_2" {
+			t.Errorf("expected render hook to see pages got %v", seenPages)
+		}
+
+		if seenData != "props" {
+			t.Errorf("expected render hook to see props got %v", seenData)
+		}
+	})
+
+	t.Run("render hooks can cancel a render", func(t *testing.T) {
+		rendered := false
+
+		s := newServe()
+		s.mux.(*mockHandle).writer = &mockResponseWriter{
+			mockWriteHeader: func(statusCode int) {
+				if statusCode != http.StatusUnauthorized {
+					rendered = true
+				}
+			},
+			mockWrite:  func(b []byte) (int, error) { return 0, nil },
+			mockHeader: func() http.Header { return make(http.Header) },
+		}
+
+		s.HandleFunc("/test", func(c *Request) {
+			c.RenderPage("", nil)
+		}, func(next HandlerFunc) HandlerFunc {
+			return func(c *Request) {
+				c.BeforeRender(func(c *Request, pages []PageRender, data interface{}) bool {
+					c.Response.WriteHeader(http.StatusUnauthorized)
+					return false
+				})
+				next(c)
+			}
+		})
+
+		if rendered {
+			t.Errorf("expected render to be canceled by render hook")
+		}
+	})
+}
+
+type flushRecorder struct {
+	header  http.Header
+	status  int
+	writes  chan string
+	flushes int
+	out     strings.Builder
+}
+
+func (f *flushRecorder) Header() http.Header    { return f.header }
+func (f *flushRecorder) WriteHeader(status int) { f.status = status }
+func (f *flushRecorder) Write(b []byte) (int, error) {
+	f.out.Write(b)
+	return len(b), nil
+}
+func (f *flushRecorder) Flush() {
+	f.flushes++
+	f.writes <- f.out.String()
+}
+
+func newFlushRecorder() *flushRecorder {
+	return &flushRecorder{header: make(http.Header), writes: make(chan string, 10)}
+}
+
+func TestStreamHTMLPages(t *testing.T) {
+	slow := PageRender("stream_slow")
+	fast := PageRender("stream_fast")
+	release := make(chan bool)
+
+	wrapDocRender[slow] = &DocumentRenderer{
+		fn: func(ctx context.Context, s string, b []byte, hd *htmlDoc) (*htmlDoc, error) {
+			<-release
+			hd.Body = append(hd.Body, "<div>slow</div>")
+			return hd, nil
+		},
+		version: "stub_version",
+	}
+	wrapDocRender[fast] = &DocumentRenderer{
+		fn: func(ctx context.Context, s string, b []byte, hd *htmlDoc) (*htmlDoc, error) {
+			hd.Body = append(hd.Body, "<div>fast</div>")
+			return hd, nil
+		},
+		version: "stub_version",
+	}
+	pageDependencies[slow] = []string{"<script>dependency</script>"}
+
+	t.Cleanup(func() {
+		delete(wrapDocRender, slow)
+		delete(wrapDocRender, fast)
+		delete(pageDependencies, slow)
+	})
+
+	w := newFlushRecorder()
+	base := ParseHTML("<head><title>base</title></head><body><div>shell</div></body>")
+
+	done := make(chan bool)
+	go func() {
+		streamHTMLPages(context.Background(), w, w, http.StatusOK, base, renderFragment, sharedProps([]byte(`{"a": 1}`)), nil, slow, fast)
+		done <- true
+	}()
+
+	shell := <-w.writes
+	for _, expected := range []string{"<title>base</title>", "<script>dependency</script>", `{"a": 1}`, "<div>shell</div>"} {
+		if !strings.Contains(shell, expected) {
+			t.Errorf("expected shell to contain '%s' before the pages are rendered", expected)
+		}
+	}
+
+	if strings.Contains(shell, "fast") {
+		t.Errorf("page fragment should not be written before the previous page fragment")
+	}
+
+	release <- true
+	<-done
+
+	out := w.out.String()
+	if strings.Index(out, "slow") > strings.Index(out, "fast") {
+		t.Errorf("expected fragments to be written in page order")
+	}
+
+	if !strings.HasSuffix(out, "</body></html>") {
+		t.Errorf("expected the document to be closed")
+	}
+
+	if w.status != http.StatusOK {
+		t.Errorf("expected status 200 got %d", w.status)
+	}
+}
+
+func TestStreamHTMLPages_Chunks(t *testing.T) {
+	page := PageRender("stream_chunks")
+	release := make(chan bool)
+
+	wrapDocRender[page] = &DocumentRenderer{
+		fn: func(ctx context.Context, s string, b []byte, hd *htmlDoc) (*htmlDoc, error) {
+			w := renderStream(ctx)
+			if w == nil {
+				return nil, errors.New("expected the page to be rendered with a stream")
+			}
+
+			io.WriteString(w, "<div>shell")
+			<-release
+			io.WriteString(w, "<div>suspended</div></div>")
+
+			hd.Body = append(hd.Body, "<script>bundle</script>")
+			return hd, nil
+		},
+		version: "stub_version",
+	}
+
+	t.Cleanup(func() {
+		delete(wrapDocRender, page)
+	})
+
+	w := newFlushRecorder()
+	base := ParseHTML("<head></head><body></body>")
+
+	done := make(chan error)
+	go func() {
+		done <- streamHTMLPages(context.Background(), w, w, http.StatusOK, base, renderFragment, sharedProps([]byte("{}")), nil, page)
+	}()
+
+	<-w.writes
+	if chunk := <-w.writes; !strings.HasSuffix(chunk, "<div>shell") {
+		t.Errorf("expected the chunk to be flushed before the page has rendered got '%s'", chunk)
+	}
+
+	release <- true
+	if err := <-done; err != nil {
+		t.Errorf("did not expect error '%s'", err)
+	}
+
+	if !strings.Contains(w.out.String(), "<div>shell<div>suspended</div></div><script>bundle</script>") {
+		t.Errorf("expected the chunks to be written before the fragment got '%s'", w.out.String())
+	}
+}
+
+func TestStreamHTMLPages_Status(t *testing.T) {
+	page := PageRender("stream_status")
+
+	wrapDocRender[page] = &DocumentRenderer{
+		fn: func(ctx context.Context, s string, b []byte, hd *htmlDoc) (*htmlDoc, error) {
+			hd.Body = append(hd.Body, "<div>moved</div>")
+			hd.Redirect = "/moved"
+			return hd, nil
+		},
+		version: "stub_version",
+	}
+
+	t.Cleanup(func() {
+		delete(wrapDocRender, page)
+	})
+
+	w := newFlushRecorder()
+	go func() {
+		for range w.writes {
+		}
+	}()
+
+	err := streamHTMLPages(context.Background(), w, w, http.StatusOK, ParseHTML("<head></head><body></body>"), renderFragment, sharedProps([]byte("{}")), nil, page)
+	close(w.writes)
+
+	if !errors.Is(err, ErrStreamedStatus) {
+		t.Errorf("expected the redirect of a streamed page to fail the render got '%v'", err)
+	}
+
+	if w.status != http.StatusOK || !strings.Contains(w.out.String(), "<div>moved</div>") {
+		t.Errorf("expected the fragment to be written with the status of the response")
+	}
+}
+
+func TestWriteHTMLPages_Streaming(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/", nil)
+
+	t.Run("streams when enabled", func(t *testing.T) {
+		s := &Serve{doc: NewHTMLDocument()}
+		s.SetStreaming(true)
+
+		w := newFlushRecorder()
+		s.writeHTMLPages(w, r, http.StatusOK, nil, sharedProps([]byte("{}")), "")
+
+		if w.flushes == 0 {
+			t.Errorf("expected response to be flushed during streaming")
+		}
+	})
+
+	t.Run("buffers when disabled", func(t *testing.T) {
+		s := &Serve{doc: NewHTMLDocument()}
+
+		w := newFlushRecorder()
+		s.writeHTMLPages(w, r, http.StatusOK, nil, sharedProps([]byte("{}")), "")
+
+		if w.flushes != 0 {
+			t.Errorf("expected response to not be flushed when streaming is disabled")
+		}
+
+		if !strings.Contains(w.out.String(), "</html>") {
+			t.Errorf("expected buffered document to be written")
+		}
+	})
 }
 
 func TestParseStaticDocument(t *testing.T) {
@@ -598,15 +1090,7 @@ func TestNew_ValidPublicDir(t *testing.T) {
 		t.Error("orbit handler should not be nil")
 	}
 
-	containsBody := false
-
-	for _, b := range s.doc.Body {
-		if strings.Contains(b, body) {
-			containsBody = true
-		}
-	}
-
-	if !containsBody {
+	if !strings.Contains(s.doc.BodyHTML(), body) {
 		t.Error("body conent not applied correctly")
 	}
 
@@ -628,3 +1112,913 @@ func TestNew_InvalidPublicDir(t *testing.T) {
 
 	publicDir = cpublicDir
 }
+
+func TestErrorPages(t *testing.T) {
+	failing := PageRender("error_failing")
+	errorPage := PageRender("error_page")
+	errRender := errors.New("render failed")
+
+	var errorPageProps string
+	wrapDocRender[failing] = &DocumentRenderer{
+		fn: func(ctx context.Context, s string, b []byte, hd *htmlDoc) (*htmlDoc, error) {
+			return nil, errRender
+		},
+		version: "stub_version",
+	}
+	wrapDocRender[errorPage] = &DocumentRenderer{
+		fn: func(ctx context.Context, s string, b []byte, hd *htmlDoc) (*htmlDoc, error) {
+			errorPageProps = string(b)
+			hd.Body = append(hd.Body, "<div>error page</div>")
+			return hd, nil
+		},
+		version: "stub_version",
+	}
+
+	reported := make([]error, 0)
+	currentReporter := reportDevError
+	reportDevError = func(r *http.Request, err error) { reported = append(reported, err) }
+
+	t.Cleanup(func() {
+		delete(wrapDocRender, failing)
+		delete(wrapDocRender, errorPage)
+		reportDevError = currentReporter
+	})
+
+	r := httptest.NewRequest("GET", "/broken", nil)
+
+	t.Run("renders the error page", func(t *testing.T) {
+		s := &Serve{doc: NewHTMLDocument()}
+		s.SetErrorPage(http.StatusInternalServerError, errorPage)
+
+		w := httptest.NewRecorder()
+		err := s.writeHTMLPages(w, r, http.StatusOK, nil, sharedProps([]byte("{}")), failing)
+
+		var renderErr *RenderError
+		if !errors.As(err, &renderErr) || renderErr.Page != failing || !errors.Is(err, errRender) {
+			t.Errorf("expected render error for page '%s' got %v", failing, err)
+		}
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("expected status 500 got %d", w.Code)
+		}
+
+		if !strings.Contains(w.Body.String(), "<div>error page</div>") {
+			t.Error("expected the error page to be rendered")
+		}
+
+		for _, expected := range []string{`"status":500`, `"path":"/broken"`, "render failed"} {
+			if !strings.Contains(errorPageProps, expected) {
+				t.Errorf("expected error page props to contain '%s' got '%s'", expected, errorPageProps)
+			}
+		}
+	})
+
+	t.Run("writes the status without an error page", func(t *testing.T) {
+		s := &Serve{doc: NewHTMLDocument()}
+
+		w := httptest.NewRecorder()
+		s.writeHTMLPages(w, r, http.StatusOK, nil, sharedProps([]byte("{}")), failing)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("expected status 500 got %d", w.Code)
+		}
+
+		if w.Body.Len() != 0 {
+			t.Errorf("expected an empty body got '%s'", w.Body.String())
+		}
+	})
+
+	t.Run("writes the status of a bare error without the error page", func(t *testing.T) {
+		s := &Serve{doc: NewHTMLDocument()}
+		s.SetErrorPage(http.StatusInternalServerError, errorPage)
+
+		w := httptest.NewRecorder()
+		s.writeError(w, r, http.StatusInternalServerError, &RenderError{Page: failing, Err: &bareError{err: errRender}})
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("expected status 500 got %d", w.Code)
+		}
+
+		if w.Body.Len() != 0 {
+			t.Errorf("expected an empty body got '%s'", w.Body.String())
+		}
+	})
+
+	t.Run("error boundary when streaming", func(t *testing.T) {
+		s := &Serve{doc: NewHTMLDocument()}
+		s.SetStreaming(true)
+
+		w := newFlushRecorder()
+		err := s.writeHTMLPages(w, r, http.StatusOK, nil, sharedProps([]byte("{}")), failing, "")
+
+		if !errors.Is(err, errRender) {
+			t.Errorf("expected render error got %v", err)
+		}
+
+		out := w.out.String()
+		if !strings.Contains(out, `<div class="orbit_error_boundary" data-page="error_failing">`) {
+			t.Errorf("expected error boundary in place of the failed page got '%s'", out)
+		}
+
+		if !strings.HasSuffix(out, "</body></html>") {
+			t.Errorf("expected the document to be closed")
+		}
+	})
+
+	if len(reported) != 4 {
+		t.Errorf("expected each error to be reported got %d", len(reported))
+	}
+}
+
+func TestPageLoader(t *testing.T) {
+	page := PageRender("loader_page")
+	errLoad := errors.New("load failed")
+
+	var renderedProps string
+	wrapDocRender[page] = &DocumentRenderer{
+		fn: func(ctx context.Context, s string, b []byte, hd *htmlDoc) (*htmlDoc, error) {
+			renderedProps = string(b)
+			return hd, nil
+		},
+		version: "stub_version",
+	}
+	routeTable[page] = "/users/{id}"
+
+	currentReporter := reportDevError
+	reportDevError = func(r *http.Request, err error) {}
+
+	t.Cleanup(func() {
+		delete(wrapDocRender, page)
+		delete(routeTable, page)
+		reportDevError = currentReporter
+	})
+
+	newServe := func(loader PageLoader) http.Handler {
+		s := &Serve{mux: http.NewServeMux(), doc: NewHTMLDocument()}
+		if err := s.SetPageLoader(page, loader); err != nil {
+			t.Fatal(err)
+		}
+
+		return s.Serve()
+	}
+
+	t.Run("loads props from the request", func(t *testing.T) {
+		mux := newServe(func(c *Request) (interface{}, error) {
+			return map[string]string{"id": c.Slugs["id"], "tab": c.Request.URL.Query().Get("tab")}, nil
+		})
+
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/users/42?tab=posts", nil))
+
+		if w.Code != http.StatusOK {
+			t.Errorf("expected status 200 got %d", w.Code)
+		}
+
+		if renderedProps != `{"id":"42","tab":"posts"}` {
+			t.Errorf("expected loaded props got '%s'", renderedProps)
+		}
+	})
+
+	tt := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"redirect", Redirect("/login", http.StatusFound), http.StatusFound},
+		{"not found", ErrPageNotFound, http.StatusNotFound},
+		{"wrapped not found", fmt.Errorf("no user: %w", ErrPageNotFound), http.StatusNotFound},
+		{"error", errLoad, http.StatusInternalServerError},
+	}
+
+	for _, d := range tt {
+		t.Run(d.name, func(t *testing.T) {
+			mux := newServe(func(c *Request) (interface{}, error) {
+				return nil, d.err
+			})
+
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("GET", "/users/42", nil))
+
+			if w.Code != d.status {
+				t.Errorf("expected status %d got %d", d.status, w.Code)
+			}
+		})
+	}
+
+	t.Run("redirect location", func(t *testing.T) {
+		mux := newServe(func(c *Request) (interface{}, error) {
+			return nil, Redirect("/login", http.StatusFound)
+		})
+
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/users/42", nil))
+
+		if w.Header().Get("Location") != "/login" {
+			t.Errorf("expected redirect to '/login' got '%s'", w.Header().Get("Location"))
+		}
+	})
+
+	t.Run("page not in route table", func(t *testing.T) {
+		s := &Serve{}
+		err := s.SetPageLoader("not_routed", func(c *Request) (interface{}, error) { return nil, nil })
+
+		if !errors.Is(err, ErrPageNotInRouteTable) {
+			t.Errorf("expected route table error got %v", err)
+		}
+	})
+}
+
+func TestMemoryCache(t *testing.T) {
+	c := NewMemoryCache(2)
+
+	c.Set("a", &CacheEntry{Body: []string{"a"}})
+	c.Set("b", &CacheEntry{Body: []string{"b"}})
+
+	// reading "a" makes "b" the least recently used entry
+	c.Get("a")
+	c.Set("c", &CacheEntry{Body: []string{"c"}})
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected least recently used entry to be evicted")
+	}
+
+	for _, k := range []string{"a", "c"} {
+		if _, ok := c.Get(k); !ok {
+			t.Errorf("expected entry '%s' to exist", k)
+		}
+	}
+
+	c.Delete("a")
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected deleted entry to not exist")
+	}
+}
+
+func TestRenderCachedFragment(t *testing.T) {
+	page := PageRender("cached_page")
+
+	// renderCall is the context of a render, along with the error of the context at the time of the render
+	type renderCall struct {
+		ctx context.Context
+		err error
+	}
+
+	renders := make(chan renderCall, 10)
+	var renderErr error
+	// fallback renders the page as though its server side render failed & it is rendered on the client
+	var fallback bool
+	wrapDocRender[page] = &DocumentRenderer{
+		fn: func(ctx context.Context, s string, b []byte, hd *htmlDoc) (*htmlDoc, error) {
+			renders <- renderCall{ctx, ctx.Err()}
+			if renderErr != nil {
+				return nil, renderErr
+			}
+
+			hd.Body = append(hd.Body, string(b))
+			hd.fallback = fallback
+			return hd, nil
+		},
+		version: "stub_version",
+	}
+
+	t.Cleanup(func() {
+		delete(wrapDocRender, page)
+	})
+
+	renderCount := func() int {
+		count := 0
+		for {
+			select {
+			case <-renders:
+				count++
+			default:
+				return count
+			}
+		}
+	}
+
+	ctx := context.Background()
+
+	t.Run("pages without a policy are not cached", func(t *testing.T) {
+		s := &Serve{}
+		s.renderCachedFragment(ctx, page, []byte("{}"))
+		s.renderCachedFragment(ctx, page, []byte("{}"))
+
+		if c := renderCount(); c != 2 {
+			t.Errorf("expected 2 renders got %d", c)
+		}
+	})
+
+	t.Run("fresh entries are reused", func(t *testing.T) {
+		s := &Serve{}
+		s.SetCachePolicy(page, CachePolicy{TTL: time.Minute})
+
+		s.renderCachedFragment(ctx, page, []byte(`{"a":1}`))
+		fragment, _ := s.renderCachedFragment(ctx, page, []byte(`{"a":1}`))
+		s.renderCachedFragment(ctx, page, []byte(`{"a":2}`))
+
+		if c := renderCount(); c != 2 {
+			t.Errorf("expected a render for each distinct set of props got %d", c)
+		}
+
+		if fragment.Body[0] != `{"a":1}` {
+			t.Errorf("expected cached fragment got '%s'", fragment.Body)
+		}
+	})
+
+	t.Run("stale entries are served while revalidating", func(t *testing.T) {
+		cache := NewMemoryCache(10)
+
+		s := &Serve{}
+		s.SetRenderCache(cache)
+		s.SetCachePolicy(page, CachePolicy{TTL: time.Minute, StaleWhileRevalidate: time.Hour})
+
+		// the request has already been served (& its context cancelled) by the time that the page is revalidated
+		r := httptest.NewRequest("GET", "/cached", nil)
+		requestCtx, cancel := context.WithCancel(context.WithValue(ctx, renderRequestKey, r))
+		cancel()
+
+		key := renderCacheKey(requestCtx, page, []byte("{}"))
+		cache.Set(key, &CacheEntry{Body: []string{"stale"}, RenderedAt: time.Now().Add(-2 * time.Minute)})
+
+		fragment, _ := s.renderCachedFragment(requestCtx, page, []byte("{}"))
+		if fragment.Body[0] != "stale" {
+			t.Errorf("expected stale fragment got '%s'", fragment.Body)
+		}
+
+		select {
+		case call := <-renders:
+			if renderRequest(call.ctx) != r {
+				t.Error("expected the revalidation to be rendered with the request of the stale render")
+			}
+
+			if _, ok := call.ctx.Deadline(); !ok || call.err != nil {
+				t.Errorf("expected the revalidation to have its own deadline got '%v'", call.err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected the stale entry to be revalidated")
+		}
+
+		// wait for the revalidation to be written to the cache
+		for i := 0; i < 100; i++ {
+			if entry, _ := cache.Get(key); entry.Body[0] != "stale" {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		if entry, _ := cache.Get(key); entry.Body[0] != "{}" {
+			t.Errorf("expected revalidated entry got '%s'", entry.Body)
+		}
+	})
+
+	t.Run("expired entries are rendered again", func(t *testing.T) {
+		cache := NewMemoryCache(10)
+
+		s := &Serve{}
+		s.SetRenderCache(cache)
+		s.SetCachePolicy(page, CachePolicy{TTL: time.Minute})
+
+		cache.Set(renderCacheKey(ctx, page, []byte("{}")), &CacheEntry{Body: []string{"expired"}, RenderedAt: time.Now().Add(-2 * time.Minute)})
+
+		fragment, _ := s.renderCachedFragment(ctx, page, []byte("{}"))
+		if fragment.Body[0] != "{}" || renderCount() != 1 {
+			t.Errorf("expected expired entry to be rendered got '%s'", fragment.Body)
+		}
+	})
+
+	t.Run("entries vary by the request that is provided to the renderers", func(t *testing.T) {
+		s := &Serve{}
+		s.SetCachePolicy(page, CachePolicy{TTL: time.Minute})
+
+		request := func(path string, headers map[string]string) context.Context {
+			r := httptest.NewRequest("GET", path, nil)
+			for k, v := range headers {
+				r.Header.Set(k, v)
+			}
+
+			return context.WithValue(ctx, renderRequestKey, r)
+		}
+
+		s.renderCachedFragment(request("/vary", map[string]string{"Accept-Language": "en-US", "Cookie": "session=a"}), page, []byte("{}"))
+		s.renderCachedFragment(request("/vary", map[string]string{"Accept-Language": "en-US", "Cookie": "session=b", "Authorization": "b"}), page, []byte("{}"))
+
+		if c := renderCount(); c != 1 {
+			t.Errorf("expected the headers that are not forwarded to not vary the entry got %d renders", c)
+		}
+
+		s.renderCachedFragment(request("/vary", map[string]string{"Accept-Language": "de-DE"}), page, []byte("{}"))
+		s.renderCachedFragment(request("/vary?page=2", map[string]string{"Accept-Language": "en-US"}), page, []byte("{}"))
+
+		if c := renderCount(); c != 2 {
+			t.Errorf("expected the forwarded headers & url to vary the entry got %d renders", c)
+		}
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		renderErr = errors.New("render failed")
+		t.Cleanup(func() { renderErr = nil })
+
+		s := &Serve{}
+		s.SetCachePolicy(page, CachePolicy{TTL: time.Minute})
+
+		s.renderCachedFragment(ctx, page, []byte("{}"))
+		_, err := s.renderCachedFragment(ctx, page, []byte("{}"))
+
+		if err == nil || renderCount() != 2 {
+			t.Error("expected failed renders to not be cached")
+		}
+	})
+
+	t.Run("fallback renders are not cached", func(t *testing.T) {
+		fallback = true
+		t.Cleanup(func() { fallback = false })
+
+		cache := NewMemoryCache(10)
+
+		s := &Serve{}
+		s.SetRenderCache(cache)
+		s.SetCachePolicy(page, CachePolicy{TTL: time.Minute})
+
+		s.renderCachedFragment(ctx, page, []byte("{}"))
+		s.renderCachedFragment(ctx, page, []byte("{}"))
+
+		if _, ok := cache.Get(renderCacheKey(ctx, page, []byte("{}"))); ok || renderCount() != 2 {
+			t.Error("expected fallback renders to not be cached")
+		}
+	})
+
+	t.Run("fallback revalidations keep the stale entry", func(t *testing.T) {
+		fallback = true
+		t.Cleanup(func() { fallback = false })
+
+		cache := NewMemoryCache(10)
+
+		s := &Serve{}
+		s.SetRenderCache(cache)
+		s.SetCachePolicy(page, CachePolicy{TTL: time.Minute, StaleWhileRevalidate: time.Hour})
+
+		key := renderCacheKey(ctx, page, []byte("{}"))
+		cache.Set(key, &CacheEntry{Body: []string{"stale"}, RenderedAt: time.Now().Add(-2 * time.Minute)})
+
+		s.renderCachedFragment(ctx, page, []byte("{}"))
+
+		select {
+		case <-renders:
+		case <-time.After(time.Second):
+			t.Fatal("expected the stale entry to be revalidated")
+		}
+
+		// wait for the revalidation to complete
+		for i := 0; i < 100; i++ {
+			s.cacheMu.Lock()
+			revalidating := s.revalidating[key]
+			s.cacheMu.Unlock()
+
+			if !revalidating {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		if entry, _ := cache.Get(key); entry.Body[0] != "stale" {
+			t.Errorf("expected the fallback render to not replace the stale entry got '%s'", entry.Body)
+		}
+	})
+}
+
+func TestBundleServer(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	currentBundleDir := bundleDir
+	bundleDir = tmpDir
+
+	t.Cleanup(func() {
+		bundleDir = currentBundleDir
+	})
+
+	largeJS := strings.Repeat("console.log('orbit');", 100)
+	ioutil.WriteFile(fmt.Sprintf("%s/large.js", tmpDir), []byte(largeJS), 0666)
+	ioutil.WriteFile(fmt.Sprintf("%s/small.js", tmpDir), []byte("a()"), 0666)
+	ioutil.WriteFile(fmt.Sprintf("%s/image.png", tmpDir), []byte(largeJS), 0666)
+	ioutil.WriteFile(fmt.Sprintf("%s/sidecar.js", tmpDir), []byte(largeJS), 0666)
+	ioutil.WriteFile(fmt.Sprintf("%s/sidecar.js.br", tmpDir), []byte("brotli"), 0666)
+
+	s := (&Serve{
+		mux:        http.NewServeMux(),
+		doc:        NewHTMLDocument(),
+		bundleOpts: defaultBundleServerOpts(),
+	})
+	WithBundleCachePolicy("*.png", "public, max-age=60")(s)
+	s.setupMuxRequirements()
+
+	request := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+
+		w := httptest.NewRecorder()
+		s.mux.ServeHTTP(w, r)
+
+		return w
+	}
+
+	t.Run("compression", func(t *testing.T) {
+		tt := []struct {
+			path     string
+			headers  map[string]string
+			encoding string
+		}{
+			{"/p/large.js", map[string]string{"Accept-Encoding": "gzip"}, "gzip"},
+			{"/p/large.js", map[string]string{"Accept-Encoding": "gzip;q=0"}, ""},
+			{"/p/large.js", map[string]string{}, ""},
+			{"/p/large.js", map[string]string{"Accept-Encoding": "gzip", "Range": "bytes=0-10"}, ""},
+			{"/p/small.js", map[string]string{"Accept-Encoding": "gzip"}, ""},
+			{"/p/image.png", map[string]string{"Accept-Encoding": "gzip"}, ""},
+			{"/p/sidecar.js", map[string]string{"Accept-Encoding": "gzip, br"}, "br"},
+		}
+
+		for i, d := range tt {
+			w := request(d.path, d.headers)
+
+			if got := w.Header().Get("Content-Encoding"); got != d.encoding {
+				t.Errorf("(%d) expected encoding '%s' got '%s'", i, d.encoding, got)
+			}
+		}
+	})
+
+	t.Run("sidecar content", func(t *testing.T) {
+		w := request("/p/sidecar.js", map[string]string{"Accept-Encoding": "br"})
+
+		if w.Body.String() != "brotli" {
+			t.Errorf("expected sidecar to be served got '%s'", w.Body.String())
+		}
+
+		if !strings.Contains(w.Header().Get("Content-Type"), "javascript") {
+			t.Errorf("expected content type of the original file got '%s'", w.Header().Get("Content-Type"))
+		}
+	})
+
+	t.Run("cache policy", func(t *testing.T) {
+		if got := request("/p/image.png", nil).Header().Get("Cache-Control"); got != "public, max-age=60" {
+			t.Errorf("expected cache policy of matching rule got '%s'", got)
+		}
+
+		if got := request("/p/large.js", nil).Header().Get("Cache-Control"); got == "public, max-age=60" {
+			t.Errorf("expected default cache policy got '%s'", got)
+		}
+	})
+
+	t.Run("etag", func(t *testing.T) {
+		etag := request("/p/large.js", map[string]string{"Accept-Encoding": "gzip"}).Header().Get("ETag")
+		if etag == "" {
+			t.Fatal("expected etag to be set")
+		}
+
+		w := request("/p/large.js", map[string]string{"Accept-Encoding": "gzip", "If-None-Match": etag})
+		if w.Code != http.StatusNotModified {
+			t.Errorf("expected status 304 got %d", w.Code)
+		}
+
+		if w.Body.Len() != 0 || w.Header().Get("Content-Encoding") != "" {
+			t.Error("expected not modified response to not be compressed")
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		if w := request("/p/missing.js", nil); w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404 got %d", w.Code)
+		}
+	})
+}
+
+func TestHeadApply(t *testing.T) {
+	doc := ParseHTML(`<head><title>Base</title><meta name="description" content="base"><link rel="canonical" href="/base"><meta name="viewport" content="width=device-width"></head><body><svg><title>Icon</title></svg></body>`)
+
+	head := (&Head{}).
+		Title("First").
+		Title("Page <Title>").
+		Meta("description", "page").
+		Property("og:title", "Page").
+		Canonical("/page")
+
+	if err := head.JSONLD(map[string]string{"@type": "WebPage", "name": "</script>"}); err != nil {
+		t.Errorf("did not expect error '%s'", err)
+	}
+
+	head.apply(doc)
+	out := doc.HeadHTML()
+
+	for _, expected := range []string{
+		"<title>Page &lt;Title&gt;</title>",
+		`<meta name="description" content="page" />`,
+		`<meta property="og:title" content="Page" />`,
+		`<link rel="canonical" href="/page" />`,
+		`<meta name="viewport" content="width=device-width">`,
+		`<script type="application/ld+json">`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected head to contain '%s' got '%s'", expected, out)
+		}
+	}
+
+	for _, unexpected := range []string{"Base", `content="base"`, `href="/base"`, "First", "</script>\""} {
+		if strings.Contains(out, unexpected) {
+			t.Errorf("expected head to not contain '%s' got '%s'", unexpected, out)
+		}
+	}
+
+	if strings.Count(out, "<title>") != 1 {
+		t.Errorf("expected a single title got '%s'", out)
+	}
+
+	if !strings.Contains(doc.BodyHTML(), "<title>Icon</title>") {
+		t.Errorf("expected the body to not be modified got '%s'", doc.BodyHTML())
+	}
+}
+
+func TestRequestHead(t *testing.T) {
+	currentHeads := staticHeads
+	staticHeads = map[PageRender][]staticHeadTag{
+		"page": {
+			{token: "orbit:title", value: "Static"},
+			{token: "orbit:meta", name: "description", value: "static description"},
+			{token: "orbit:property", name: "og:type", value: "website"},
+			{token: "orbit:meta", name: "twitter:card", value: "summary"},
+		},
+	}
+
+	t.Cleanup(func() {
+		staticHeads = currentHeads
+	})
+
+	s := &Serve{
+		mux: http.NewServeMux(),
+		doc: ParseHTML("<title>Base</title>"),
+	}
+
+	s.HandleFunc("/", func(c *Request) {
+		c.Head().Title("Request")
+		c.RenderPage("page", nil)
+	})
+
+	w := httptest.NewRecorder()
+	s.mux.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	out := w.Body.String()
+	for _, expected := range []string{
+		"<title>Request</title>",
+		`<meta name="description" content="static description" />`,
+		`<meta property="og:type" content="website" />`,
+		`<meta name="twitter:card" content="summary" />`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected document to contain '%s' got '%s'", expected, out)
+		}
+	}
+
+	if strings.Contains(out, "Static") || strings.Contains(out, "Base") {
+		t.Errorf("expected request title to replace the static & base titles got '%s'", out)
+	}
+
+	if s.doc.HeadHTML() != "<title>Base</title>" {
+		t.Errorf("expected base document to not be modified")
+	}
+}
+
+func TestWriteHTMLPages_RenderedResponse(t *testing.T) {
+	page := PageRender("rendered_response_page")
+	t.Cleanup(func() {
+		delete(wrapDocRender, page)
+	})
+
+	tt := []struct {
+		name     string
+		fragment htmlDoc
+		status   int
+		location string
+	}{
+		{"head", htmlDoc{Head: []string{"<title>Rendered</title>", `<meta name="description" content="rendered">`}}, http.StatusOK, ""},
+		{"status", htmlDoc{Status: http.StatusNotFound}, http.StatusNotFound, ""},
+		{"redirect", htmlDoc{Redirect: "/login"}, http.StatusFound, "/login"},
+		{"permanent redirect", htmlDoc{Redirect: "/moved", Status: http.StatusMovedPermanently}, http.StatusMovedPermanently, "/moved"},
+	}
+
+	for _, d := range tt {
+		t.Run(d.name, func(t *testing.T) {
+			var rendered *http.Request
+			fragment := d.fragment
+
+			wrapDocRender[page] = &DocumentRenderer{
+				fn: func(ctx context.Context, s string, b []byte, hd *htmlDoc) (*htmlDoc, error) {
+					rendered = renderRequest(ctx)
+					return &fragment, nil
+				},
+			}
+
+			s := &Serve{doc: ParseHTML(`<title>Base</title><meta name="description" content="base">`)}
+			r := httptest.NewRequest("GET", "/page", nil)
+			w := httptest.NewRecorder()
+
+			s.writeHTMLPages(w, r, http.StatusOK, nil, sharedProps([]byte("{}")), page)
+
+			if rendered != r {
+				t.Errorf("expected the request to be provided to the renderer")
+			}
+
+			if w.Code != d.status {
+				t.Errorf("expected status %d got %d", d.status, w.Code)
+			}
+
+			if location := w.Header().Get("Location"); location != d.location {
+				t.Errorf("expected location '%s' got '%s'", d.location, location)
+			}
+
+			if len(d.fragment.Head) == 0 {
+				return
+			}
+
+			out := w.Body.String()
+			if strings.Contains(out, "Base") || strings.Contains(out, `content="base"`) {
+				t.Errorf("expected rendered head elements to replace the base elements got '%s'", out)
+			}
+
+			if !strings.Contains(out, "<title>Rendered</title>") || !strings.Contains(out, `content="rendered"`) {
+				t.Errorf("expected rendered head elements got '%s'", out)
+			}
+		})
+	}
+}
+
+func TestHTMLDocMerge(t *testing.T) {
+	doc := &htmlDoc{}
+
+	doc.merge(&htmlDoc{Body: []string{"a"}})
+	doc.merge(&htmlDoc{Body: []string{"b"}, Status: http.StatusNotFound, Redirect: "/first"})
+	doc.merge(&htmlDoc{Body: []string{"c"}, Status: http.StatusGone, Redirect: "/second"})
+
+	if doc.fragment() != "abc" {
+		t.Errorf("expected fragments to be merged in order got '%s'", doc.fragment())
+	}
+
+	if doc.Status != http.StatusNotFound || doc.Redirect != "/first" {
+		t.Errorf("expected the first status & redirect got %d '%s'", doc.Status, doc.Redirect)
+	}
+}
+
+func TestBatchFragments(t *testing.T) {
+	pages := []PageRender{"batch_first", "batch_single", "batch_second", "batch_cached"}
+	versions := []string{"batched", "single", "batched", "batched"}
+
+	for i, p := range pages {
+		wrapDocRender[p] = &DocumentRenderer{
+			fn: func(ctx context.Context, s string, b []byte, hd *htmlDoc) (*htmlDoc, error) {
+				hd.Body = append(hd.Body, "single:"+s)
+				return hd, nil
+			},
+			version: versions[i],
+		}
+	}
+
+	calls := 0
+	batchRenderers["batched"] = func(ctx context.Context, pages []string, data [][]byte) ([]*htmlDoc, error) {
+		calls++
+
+		docs := make([]*htmlDoc, len(pages))
+		for i, p := range pages {
+			docs[i] = &htmlDoc{Body: []string{"batch:" + p}}
+		}
+
+		return docs, nil
+	}
+
+	t.Cleanup(func() {
+		for _, p := range pages {
+			delete(wrapDocRender, p)
+		}
+
+		delete(batchRenderers, "batched")
+	})
+
+	exclude := func(p PageRender) bool { return p == "batch_cached" }
+	render := batchFragments(context.Background(), renderFragment, exclude, sharedProps([]byte("{}")), pages...)
+
+	doc, err := buildHTMLPages(context.Background(), render, sharedProps([]byte("{}")), pages...)
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	expected := "batch:batch_firstsingle:batch_singlebatch:batch_secondsingle:batch_cached"
+	if got := strings.Join(doc.Body, ""); got != expected {
+		t.Errorf("expected '%s' got '%s'", expected, got)
+	}
+
+	if calls != 1 {
+		t.Errorf("expected the batched pages to be rendered with a single call got %d", calls)
+	}
+}
+
+func TestBatchFragments_Error(t *testing.T) {
+	pages := []PageRender{"batch_error_first", "batch_error_second"}
+	for _, p := range pages {
+		wrapDocRender[p] = &DocumentRenderer{version: "failing_batch"}
+	}
+
+	batchRenderers["failing_batch"] = func(ctx context.Context, pages []string, data [][]byte) ([]*htmlDoc, error) {
+		return nil, errors.New("batch failed")
+	}
+
+	t.Cleanup(func() {
+		for _, p := range pages {
+			delete(wrapDocRender, p)
+		}
+
+		delete(batchRenderers, "failing_batch")
+	})
+
+	render := batchFragments(context.Background(), renderFragment, func(PageRender) bool { return false }, sharedProps([]byte("{}")), pages...)
+
+	_, err := buildHTMLPages(context.Background(), render, sharedProps([]byte("{}")), pages...)
+
+	var renderErr *RenderError
+	if !errors.As(err, &renderErr) || renderErr.Page != "batch_error_first" {
+		t.Errorf("expected render error of the first page got '%v'", err)
+	}
+}
+
+func TestBuildHTMLPages_PageProps(t *testing.T) {
+	pages := []PageRender{"props_first", "props_second"}
+	for _, p := range pages {
+		wrapDocRender[p] = &DocumentRenderer{
+			fn: func(ctx context.Context, s string, b []byte, hd *htmlDoc) (*htmlDoc, error) {
+				hd.Body = append(hd.Body, fmt.Sprintf("%s:%s", s, b))
+				return hd, nil
+			},
+			version: "props_version",
+		}
+	}
+
+	t.Cleanup(func() {
+		for _, p := range pages {
+			delete(wrapDocRender, p)
+		}
+	})
+
+	props, err := newRenderProps(PageProps{"props_first": map[string]string{"name": "first"}}, pages...)
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	doc, err := buildHTMLPages(context.Background(), renderFragment, props, pages...)
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	expectedBody := `props_first:{"name":"first"}props_second:{}`
+	if got := strings.Join(doc.Body, ""); got != expectedBody {
+		t.Errorf("expected body '%s' got '%s'", expectedBody, got)
+	}
+
+	head := strings.Join(doc.Head, "")
+	for _, expected := range []string{
+		`<script id="orbit_manifest_props_first" type="application/json">{"name":"first"}</script>`,
+		`<script id="orbit_manifest_props_second" type="application/json">{}</script>`,
+	} {
+		if !strings.Contains(head, expected) {
+			t.Errorf("expected head to contain '%s' got '%s'", expected, head)
+		}
+	}
+
+	if strings.Contains(head, `id="orbit_manifest"`) {
+		t.Errorf("did not expect shared manifest got '%s'", head)
+	}
+}
+
+func TestRenderProps(t *testing.T) {
+	tt := []struct {
+		data     interface{}
+		page     PageRender
+		expected string
+	}{
+		{map[string]int{"a": 1}, "page", `{"a":1}`},
+		{PageProps{"page": map[string]int{"a": 1}}, "page", `{"a":1}`},
+		{PageProps{"page": map[string]int{"a": 1}}, "other", `{}`},
+		{nil, "page", `null`},
+	}
+
+	for _, d := range tt {
+		props, err := newRenderProps(d.data, "page", "other")
+		if err != nil {
+			t.Errorf("did not expect error '%s'", err)
+			continue
+		}
+
+		if got := string(props.of(d.page)); got != d.expected {
+			t.Errorf("expected '%s' got '%s'", d.expected, got)
+		}
+	}
+}
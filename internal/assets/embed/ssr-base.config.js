@@ -0,0 +1,84 @@
+const dirName = () => {
+    const correct = []
+
+    let splitDir = ""
+    if (process.platform === "win32") {
+        splitDir = __dirname.split('\\')
+    } else {
+        splitDir = __dirname.split('/')
+    }
+
+    for (let i = 0; i < splitDir.length - 1; i++) {
+        correct.push(splitDir[i])
+    }
+
+    if (process.platform === "win32") {
+        return correct.join("\\")
+    } else {
+        return correct.join("/")
+    }
+}
+
+// base config of the server side render bundles, these bundles are not served to the browser
+// so they are output to a separate directory from the client bundles.
+module.exports = {
+    target: 'node',
+    output: {
+        path: dirName() + "/ssr"
+    },
+    module: {
+        rules: [
+            {
+                test: /\.css$/i,
+                exclude: /node_modules/,
+                use: [
+                    {
+                        loader: 'css-loader',
+                        options: {
+                            modules: {
+                                exportOnlyLocals: true,
+                            },
+                        },
+                    },
+                ],
+            },
+            {
+                test: /\.(js|jsx|ts|tsx)$/,
+                exclude: /node_modules/,
+                use: {
+                    loader: "babel-loader",
+                    options: {
+                        "presets": [
+                            [
+                                "@babel/preset-env",
+                                {
+                                    "targets": {
+                                        "node": "current"
+                                    }
+                                }
+                            ],
+                            "@babel/preset-react",
+                            "@babel/preset-typescript"
+                        ],
+                        "plugins": [
+                            "@babel/plugin-proposal-class-properties",
+                            "@babel/plugin-proposal-export-default-from"
+                        ]
+                    }
+                }
+            },
+            {
+                test: /\.html$/,
+                use: [
+                    {
+                        loader: "html-loader",
+                    }
+                ]
+            }
+        ]
+    },
+    resolve: {
+        extensions: ['.js', '.jsx', '.ts', '.tsx'],
+        modules: ['node_modules']
+    },
+};
@@ -0,0 +1,108 @@
+const dirName = () => {
+    const correct = []
+
+    let splitDir = ""
+    if (process.platform === "win32") {
+        splitDir = __dirname.split('\\')
+    } else {
+        splitDir = __dirname.split('/')
+    }
+
+    for (let i = 0; i < splitDir.length - 1; i++) {
+        correct.push(splitDir[i])
+    }
+
+    if (process.platform === "win32") {
+        return correct.join("\\")
+    } else {
+        return correct.join("/")
+    }
+}
+
+// swcLoader creates the swc-loader of the server side render bundles for the files that are parsed by the provided parser,
+// these bundles are run by node so they target a recent version of ecmascript rather than es5.
+const swcLoader = (parser) => ({
+    loader: 'swc-loader',
+    options: {
+        jsc: {
+            target: "es2019",
+            parser,
+            transform: {
+                react: {
+                    pragma: "React.createElement",
+                    pragmaFrag: "React.Fragment",
+                    throwIfNamespace: true,
+                    development: false,
+                    useBuiltins: false
+                }
+            }
+        },
+        module: {
+            type: "es6"
+        },
+        minify: false
+    }
+})
+
+// base config of the server side render bundles when swc is preferred over babel, these bundles are not served to the browser
+// so they are output to a separate directory from the client bundles.
+module.exports = {
+    target: 'node',
+    output: {
+        path: dirName() + "/ssr"
+    },
+    module: {
+        rules: [
+            {
+                test: /\.css$/i,
+                exclude: /node_modules/,
+                use: [
+                    {
+                        loader: 'css-loader',
+                        options: {
+                            modules: {
+                                exportOnlyLocals: true,
+                            },
+                        },
+                    },
+                ],
+            },
+            {
+                test: /\.(js|jsx)$/,
+                exclude: /node_modules/,
+                use: swcLoader({
+                    syntax: "ecmascript",
+                    jsx: true
+                })
+            },
+            {
+                test: /\.ts$/,
+                exclude: /node_modules/,
+                use: swcLoader({
+                    syntax: "typescript",
+                    tsx: false
+                })
+            },
+            {
+                test: /\.tsx$/,
+                exclude: /node_modules/,
+                use: swcLoader({
+                    syntax: "typescript",
+                    tsx: true
+                })
+            },
+            {
+                test: /\.html$/,
+                use: [
+                    {
+                        loader: "html-loader",
+                    }
+                ]
+            }
+        ]
+    },
+    resolve: {
+        extensions: ['.js', '.jsx', '.ts', '.tsx'],
+        modules: ['node_modules']
+    },
+};
@@ -21,6 +21,37 @@ const dirName = () => {
     }
 }
 
+// swcLoader creates the swc-loader of the files that are parsed by the provided parser
+const swcLoader = (parser) => ({
+    loader: 'swc-loader',
+    options: {
+        jsc: {
+            target: "es5",
+            parser,
+            transform: {
+                react: {
+                    pragma: "React.createElement",
+                    pragmaFrag: "React.Fragment",
+                    throwIfNamespace: true,
+                    development: true,
+                    useBuiltins: false
+                },
+                optimizer: {
+                    globals: {
+                        vars: {
+                            __DEBUG__: "true"
+                        }
+                    }
+                }
+            }
+        },
+        module: {
+            type: "es6"
+        },
+        minify: false
+    }
+})
+
 module.exports = {
     entry: './index.js',
     output: {
@@ -31,45 +62,35 @@ module.exports = {
             {
                 test: /\.(js|jsx)$/,
                 exclude: /(node_modules|bower_components)/,
-                use: {
-                    loader: 'swc-loader',
-                    options: {
-                        jsc: {
-                            target: "es5",
-                            parser: {
-                                syntax: "ecmascript",
-                                jsx: true,
-                                numericSeparator: false,
-                                classPrivateProperty: false,
-                                privateMethod: false,
-                                classProperty: false,
-                                functionBind: false,
-                                decorators: false,
-                                decoratorsBeforeExport: false
-                            },
-                            transform: {
-                                react: {
-                                    pragma: "React.createElement",
-                                    pragmaFrag: "React.Fragment",
-                                    throwIfNamespace: true,
-                                    development: true,
-                                    useBuiltins: false
-                                },
-                                optimizer: {
-                                    globals: {
-                                        vars: {
-                                            __DEBUG__: "true"
-                                        }
-                                    }
-                                }
-                            }
-                        },
-                        module: {
-                            type: "es6"
-                        },
-                        minify: false
-                    }
-                }
+                use: swcLoader({
+                    syntax: "ecmascript",
+                    jsx: true,
+                    numericSeparator: false,
+                    classPrivateProperty: false,
+                    privateMethod: false,
+                    classProperty: false,
+                    functionBind: false,
+                    decorators: false,
+                    decoratorsBeforeExport: false
+                })
+            },
+            {
+                test: /\.ts$/,
+                exclude: /(node_modules|bower_components)/,
+                use: swcLoader({
+                    syntax: "typescript",
+                    tsx: false,
+                    decorators: false
+                })
+            },
+            {
+                test: /\.tsx$/,
+                exclude: /(node_modules|bower_components)/,
+                use: swcLoader({
+                    syntax: "typescript",
+                    tsx: true,
+                    decorators: false
+                })
             },
             {
                 test: /\.css$/i,
@@ -96,7 +117,7 @@ module.exports = {
         ],
     },
     resolve: {
-        extensions: ['.js', '.jsx'],
+        extensions: ['.js', '.jsx', '.ts', '.tsx'],
         modules: ['node_modules', path.resolve(__dirname, './')]
     },
 };
\ No newline at end of file
@@ -8,6 +8,7 @@ import (
 	"context"
 	"fmt"
 	"io/fs"
+	"time"
 
 	"github.com/GuyARoss/orbit/internal/assets"
 	"github.com/GuyARoss/orbit/internal/libout"
@@ -41,6 +42,23 @@ type BuildOpts struct {
 	// PriorityEntry is a page entry that will skip all other entries during the build process
 	// this option is for SPAs, and will skip building all other pages.
 	PriorityEntry string
+	// Precompress creates precompressed sidecars of the bundle files, so that they do not need to be compressed per request.
+	Precompress bool
+	// HashBundles creates content-hashed copies of the bundle files, which are referenced by the autogenerated packages
+	// in place of the bundle key, so that long lived cache policies do not serve stale bundles after a redeploy.
+	HashBundles bool
+	// BundleGraceWindow is the duration that content-hashed bundle files of previous builds are kept.
+	BundleGraceWindow time.Duration
+	// ReactVersion is the major version of react that is targeted by the react wrappers,
+	// when left blank the version is detected from the installed react package.
+	ReactVersion int
+	// JSXFramework is the framework that renders the jsx pages which do not declare their own framework.
+	JSXFramework string
+	// SSRBackend is the renderer used by the autogenerated packages to server side render pages,
+	// either "node" or "embedded" which renders with goja when the package is built with the "goja" tag.
+	SSRBackend string
+	// MultiEntry bundles the client bundles of the pages with a single multi-entry webpack config rather than per page.
+	MultiEntry bool
 }
 
 func (opts *BuildOpts) FindAllPages() []string {
@@ -54,14 +72,21 @@ func (opts *BuildOpts) FindAllPages() []string {
 
 func NewBuildOptsFromViper() *BuildOpts {
 	return &BuildOpts{
-		PackageName:    viper.GetString("package_name"),
-		OutDir:         viper.GetString("out_dir"),
-		Mode:           viper.GetString("mode"),
-		NodeModulePath: viper.GetString("node_modules_dir"),
-		PublicPath:     viper.GetString("public_path"),
-		NoWrite:        len(viper.GetString("spa_entry_path")) > 0,
-		PriorityEntry:  viper.GetString("spa_entry_path"),
-		ApplicationDir: viper.GetString("app_dir"),
+		PackageName:       viper.GetString("package_name"),
+		OutDir:            viper.GetString("out_dir"),
+		Mode:              viper.GetString("mode"),
+		NodeModulePath:    viper.GetString("node_modules_dir"),
+		PublicPath:        viper.GetString("public_path"),
+		NoWrite:           len(viper.GetString("spa_entry_path")) > 0,
+		PriorityEntry:     viper.GetString("spa_entry_path"),
+		ApplicationDir:    viper.GetString("app_dir"),
+		Precompress:       viper.GetBool("precompress"),
+		HashBundles:       viper.GetBool("hash_bundles"),
+		BundleGraceWindow: viper.GetDuration("bundle_grace_window"),
+		ReactVersion:      viper.GetInt("react_version"),
+		JSXFramework:      viper.GetString("jsx_framework"),
+		SSRBackend:        viper.GetString("ssr_backend"),
+		MultiEntry:        viper.GetBool("multi_entry"),
 	}
 }
 
@@ -77,8 +102,11 @@ func Build(opts *BuildOpts) (srcpack.PackedComponentList, error) {
 		Assets: []fs.DirEntry{
 			ats.AssetEntry(assets.WebPackConfig),
 			ats.AssetEntry(assets.SSRProtoFile),
+			ats.AssetEntry(assets.SSRHealthProto),
 			ats.AssetEntry(assets.JsWebPackConfig),
 			ats.AssetEntry(assets.WebPackSWCConfig),
+			ats.AssetEntry(assets.SSRWebPackConfig),
+			ats.AssetEntry(assets.SSRWebPackSWCConfig),
 		},
 		Mkdirs: opts.RequiredDirs,
 	}
@@ -100,6 +128,9 @@ func Build(opts *BuildOpts) (srcpack.PackedComponentList, error) {
 		BundlerMode:      opts.Mode,
 		NodeModuleDir:    opts.NodeModulePath,
 		CachedBundleKeys: c,
+		ReactVersion:     opts.ReactVersion,
+		JSXFramework:     opts.JSXFramework,
+		MultiEntry:       opts.MultiEntry,
 	})
 
 	components, err := packer.PackMany(pages)
@@ -107,11 +138,27 @@ func Build(opts *BuildOpts) (srcpack.PackedComponentList, error) {
 		return nil, err
 	}
 
+	manifest := AssetManifest{}
+	if opts.HashBundles && !opts.NoWrite {
+		manifest, err = HashBundleDir(".orbit/dist", &HashBundleOpts{GraceWindow: opts.BundleGraceWindow})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Precompress && !opts.NoWrite {
+		if err = PrecompressDir(".orbit/dist", &PrecompressOpts{MinSize: 1024}); err != nil {
+			return nil, err
+		}
+	}
+
 	bg := libout.New(&libout.BundleGroupOpts{
 		PackageName:   opts.PackageName,
 		BaseBundleOut: ".orbit/dist",
 		BundleMode:    opts.Mode,
 		PublicDir:     opts.PublicPath,
+		AssetManifest: manifest,
+		SSRBackend:    opts.SSRBackend,
 	})
 
 	if !opts.NoWrite {
@@ -131,9 +178,11 @@ func Build(opts *BuildOpts) (srcpack.PackedComponentList, error) {
 		)
 
 		if err = bg.WriteLibout(liboutFiles, &libout.FilePathOpts{
-			TestFile: fmt.Sprintf("%s/%s/orb_test.go", opts.OutDir, opts.PackageName),
-			EnvFile:  fmt.Sprintf("%s/%s/orb_env.go", opts.OutDir, opts.PackageName),
-			HTTPFile: fmt.Sprintf("%s/%s/orb_http.go", opts.OutDir, opts.PackageName),
+			TestFile:    fmt.Sprintf("%s/%s/orb_test.go", opts.OutDir, opts.PackageName),
+			EnvFile:     fmt.Sprintf("%s/%s/orb_env.go", opts.OutDir, opts.PackageName),
+			HTTPFile:    fmt.Sprintf("%s/%s/orb_http.go", opts.OutDir, opts.PackageName),
+			JSVMFile:    fmt.Sprintf("%s/%s/orb_jsvm.go", opts.OutDir, opts.PackageName),
+			PlatformDir: fmt.Sprintf("%s/%s", opts.OutDir, opts.PackageName),
 		}); err != nil {
 			return nil, err
 		}
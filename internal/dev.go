@@ -221,9 +221,11 @@ func (s *devSession) NewPageFileChangeRequest(ctx context.Context, file string)
 		ats.AssetKey(assets.Tests),
 		ats.AssetKey(assets.PrimaryPackage),
 	), &libout.FilePathOpts{
-		TestFile: fmt.Sprintf("%s/%s/orb_test.go", s.OutDir, s.PackageName),
-		EnvFile:  fmt.Sprintf("%s/%s/orb_env.go", s.OutDir, s.PackageName),
-		HTTPFile: fmt.Sprintf("%s/%s/orb_http.go", s.OutDir, s.PackageName),
+		TestFile:    fmt.Sprintf("%s/%s/orb_test.go", s.OutDir, s.PackageName),
+		EnvFile:     fmt.Sprintf("%s/%s/orb_env.go", s.OutDir, s.PackageName),
+		HTTPFile:    fmt.Sprintf("%s/%s/orb_http.go", s.OutDir, s.PackageName),
+		JSVMFile:    fmt.Sprintf("%s/%s/orb_jsvm.go", s.OutDir, s.PackageName),
+		PlatformDir: fmt.Sprintf("%s/%s", s.OutDir, s.PackageName),
 	}); err != nil {
 		return err
 	}
@@ -259,8 +261,11 @@ func NewDevSession(ctx context.Context, opts *SessionOpts) (*devSession, error)
 		Assets: []fs.DirEntry{
 			ats.AssetEntry(assets.WebPackConfig),
 			ats.AssetEntry(assets.SSRProtoFile),
+			ats.AssetEntry(assets.SSRHealthProto),
 			ats.AssetEntry(assets.JsWebPackConfig),
 			ats.AssetEntry(assets.WebPackSWCConfig),
+			ats.AssetEntry(assets.SSRWebPackConfig),
+			ats.AssetEntry(assets.SSRWebPackSWCConfig),
 		},
 		Dist: []fs.DirEntry{ats.AssetEntry(assets.HotReload)},
 	}).Make()
@@ -280,6 +285,8 @@ func NewDevSession(ctx context.Context, opts *SessionOpts) (*devSession, error)
 		NodeModuleDir:       opts.NodeModulePath,
 		CachedBundleKeys:    c,
 		SkipFirstPassBundle: true,
+		ReactVersion:        opts.ReactVersion,
+		JSXFramework:        opts.JSXFramework,
 	})
 
 	// @@todo(guy) magic string : "pages" allow support for this keyword from a flag
@@ -295,6 +302,7 @@ func NewDevSession(ctx context.Context, opts *SessionOpts) (*devSession, error)
 		BundleMode:    opts.Mode,
 		PublicDir:     opts.PublicPath,
 		HotReloadPort: opts.HotReloadPort,
+		SSRBackend:    opts.SSRBackend,
 	})
 
 	ctx = context.WithValue(ctx, webwrap.BundlerID, opts.Mode)
@@ -310,9 +318,11 @@ func NewDevSession(ctx context.Context, opts *SessionOpts) (*devSession, error)
 		ats.AssetKey(assets.Tests),
 		ats.AssetKey(assets.PrimaryPackage),
 	), &libout.FilePathOpts{
-		TestFile: fmt.Sprintf("%s/%s/orb_test.go", opts.OutDir, opts.PackageName),
-		EnvFile:  fmt.Sprintf("%s/%s/orb_env.go", opts.OutDir, opts.PackageName),
-		HTTPFile: fmt.Sprintf("%s/%s/orb_http.go", opts.OutDir, opts.PackageName),
+		TestFile:    fmt.Sprintf("%s/%s/orb_test.go", opts.OutDir, opts.PackageName),
+		EnvFile:     fmt.Sprintf("%s/%s/orb_env.go", opts.OutDir, opts.PackageName),
+		HTTPFile:    fmt.Sprintf("%s/%s/orb_http.go", opts.OutDir, opts.PackageName),
+		JSVMFile:    fmt.Sprintf("%s/%s/orb_jsvm.go", opts.OutDir, opts.PackageName),
+		PlatformDir: fmt.Sprintf("%s/%s", opts.OutDir, opts.PackageName),
 	})
 	if err != nil {
 		return nil, err
@@ -0,0 +1,18 @@
+// Copyright (c) 2021 Guy A. Ross
+// This source code is licensed under the GNU GPLv3 found in the
+// license file in the root directory of this source tree.
+
+//go:build goja
+
+package internal
+
+import (
+	"github.com/GuyARoss/orbit/pkg/webwrap/gojavm"
+
+	ewrap "github.com/GuyARoss/orbit/pkg/webwrap/embed"
+)
+
+// the static build renders with goja when the embedded ssr backend is used
+func init() {
+	ewrap.NewJSVM = func() ewrap.JSVM { return gojavm.New() }
+}
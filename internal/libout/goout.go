@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"github.com/GuyARoss/orbit/pkg/embedutils"
+	"github.com/GuyARoss/orbit/pkg/htmlparse"
 )
 
 // GOLibFile is an implementation of the libout.LiboutFile
@@ -19,12 +20,17 @@ import (
 type GOLibFile struct {
 	PackageName string
 	Body        string
+	// BuildTag is the build constraint of the file e.g "goja", the file is always built when left blank
+	BuildTag string
 }
 
 // Write writes the current golibfile to the provided path
 // this function will also create the file, if it does not exist.
 func (l *GOLibFile) Write(path string) error {
 	out := strings.Builder{}
+	if l.BuildTag != "" {
+		out.WriteString(fmt.Sprintf("//go:build %s\n\n", l.BuildTag))
+	}
 	out.WriteString(fmt.Sprintf("package %s\n\n", l.PackageName))
 	out.WriteString(l.Body)
 
@@ -161,6 +167,22 @@ func (p *goParser) parseLine(line string) string {
 	return line
 }
 
+// embedFileName finds the name of the embedded file, an empty name is returned when the file cannot be read
+func embedFileName(entry embedutils.FileReader) string {
+	file, err := entry.Read()
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return ""
+	}
+
+	return stat.Name()
+}
+
 func parseFile(entry embedutils.FileReader) (*parsedGoFile, error) {
 	file, err := entry.Read()
 
@@ -213,9 +235,69 @@ func (l *GOLibout) HTTPFile(packageName string) (LiboutFile, error) {
 		return nil, err
 	}
 
+	// the html document model is shared with the build, so that both parse documents the same way
+	doc, err := parseFile(htmlparse.DocumentFile)
+	if err != nil {
+		return nil, err
+	}
+
+	body.MergeImports(doc.Imports)
+	body.MergeBody(doc.Body)
+
+	return &GOLibFile{
+		PackageName: packageName,
+		Body:        body.Serialize(),
+	}, nil
+}
+
+// ssrVMFileName is the name of the embedded file of the ssr renderer that defines the SSRBackend & the JSVM of the package
+const ssrVMFileName = "ssr_vm.go"
+
+// jsvmBody provides the goja vm to the embedded ssr renderer, the vm exchanges json with the renderer
+// so it satisfies the JSVM of the package without depending on the types of the package.
+const jsvmBody = `import "github.com/GuyARoss/orbit/pkg/webwrap/gojavm"
+
+// the embedded ssr renderer renders with goja when the package is built with the "goja" tag
+func init() {
+	NewJSVM = func() JSVM { return gojavm.New() }
+}
+`
+
+func (l *GOLibout) JSVMFile(packageName string) (LiboutFile, error) {
+	return &GOLibFile{
+		PackageName: packageName,
+		Body:        jsvmBody,
+		BuildTag:    "goja",
+	}, nil
+}
+
+// isPlatformFile is true when the embedded file is constrained to a platform (e.g ssr_proc_windows.go), these
+// files cannot be merged with the env file & are instead written to a file of their own.
+func isPlatformFile(name string) bool {
+	return strings.HasSuffix(name, "_unix.go") || strings.HasSuffix(name, "_windows.go")
+}
+
+func (l *GOLibout) PlatformFile(packageName string, entry embedutils.FileReader) (LiboutFile, error) {
+	body, err := parseFile(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	// the build constraint of the embedded file is applied to the written file, as it must come before the package name
+	tag := ""
+	lines := strings.SplitAfter(body.Body, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "//go:build ") {
+			tag = strings.TrimSpace(strings.TrimPrefix(line, "//go:build "))
+			body.Body = strings.Join(append(lines[:i:i], lines[i+1:]...), "")
+			break
+		}
+	}
+
 	return &GOLibFile{
 		PackageName: packageName,
 		Body:        body.Serialize(),
+		BuildTag:    tag,
 	}, nil
 }
 
@@ -237,8 +319,18 @@ func (l *GOLibout) EnvFile(bg *BundleGroup) (LiboutFile, error) {
 		p.name = fmt.Sprintf("%s%s", strings.ToUpper(string(p.name[0])), p.name[1:])
 	}
 
+	// web wrappers can share embedded files (e.g the ssr renderer), each of these files is only merged once
+	merged := make(map[string]bool)
 	for _, v := range bg.wrapDocRender {
 		for _, f := range v {
+			if name := embedFileName(f); name != "" {
+				if merged[name] || isPlatformFile(name) {
+					continue
+				}
+
+				merged[name] = true
+			}
+
 			str, err := parseFile(f)
 			if err != nil {
 				return nil, err
@@ -249,6 +341,11 @@ func (l *GOLibout) EnvFile(bg *BundleGroup) (LiboutFile, error) {
 	}
 
 	out.WriteString(b.Serialize())
+
+	if bg.SSRBackend != "" && merged[ssrVMFileName] {
+		out.WriteString(fmt.Sprintf("func init() {\n\tSSRBackend = %q\n}\n\n", bg.SSRBackend))
+	}
+
 	out.WriteString("var staticResourceMap = map[PageRender]bool{\n")
 
 	for _, p := range bg.pages {
@@ -264,7 +361,7 @@ func (l *GOLibout) EnvFile(bg *BundleGroup) (LiboutFile, error) {
 	out.WriteString("}\n")
 
 	out.WriteString("var serverStartupTasks = []func(){}\n")
-	out.WriteString("type RenderFunction func(context.Context, string, []byte, *htmlDoc) (*htmlDoc, context.Context)\n")
+	out.WriteString("type RenderFunction func(context.Context, string, []byte, *htmlDoc) (*htmlDoc, error)\n")
 
 	out.WriteString("var wrapDocRender = map[PageRender]*DocumentRenderer{\n")
 	for _, p := range bg.pages {
@@ -320,7 +417,11 @@ type DocumentRenderer struct {
 	for _, p := range bg.pages {
 		out.WriteString(fmt.Sprintf(`	%s: {`, p.name))
 		for _, s := range bg.componentBodyMap[p.wrapVersion] {
-			out.WriteString(fmt.Sprintf("`%s`,", s))
+			out.WriteString(fmt.Sprintf("`%s`,", bg.hashedReferences(s)))
+			out.WriteString("\n")
+		}
+		for _, c := range p.sharedChunks {
+			out.WriteString(fmt.Sprintf("`%s`,", bg.hashedReferences(fmt.Sprintf(`<script src="/p/%s"></script>`, c))))
 			out.WriteString("\n")
 		}
 		out.WriteString("},")
@@ -330,15 +431,6 @@ type DocumentRenderer struct {
 	out.WriteString("}")
 	out.WriteString("\n")
 
-	out.WriteString(`
-	
-type HydrationCtxKey string
-
-const (
-	OrbitManifest HydrationCtxKey = "orbitManifest"
-)
-`)
-
 	out.WriteString(`
 type BundleMode int32
 
@@ -355,13 +447,36 @@ const (
 		out.WriteString("var CurrentDevMode BundleMode = DevBundleMode")
 	}
 
-	if len(bg.routeTable) > 0 {
-		out.WriteString("\nvar routeTable = map[PageRender]string{\n")
-		for k, v := range bg.routeTable {
-			out.WriteString(fmt.Sprintf(`%s: "%s",`, k, v))
+	// the route table is always defined as the runtime binds it during serve
+	out.WriteString("\nvar routeTable = map[PageRender]string{\n")
+	for k, v := range bg.routeTable {
+		out.WriteString(fmt.Sprintf(`%s: %q,`, k, v))
+	}
+	out.WriteString("}")
+
+	// static head tags are always defined as the runtime applies them to each render of the page
+	out.WriteString("\nvar staticHeads = map[PageRender][]staticHeadTag{\n")
+	headPages := make([]string, 0, len(bg.headTable))
+	for k := range bg.headTable {
+		headPages = append(headPages, k)
+	}
+	sort.Strings(headPages)
+
+	for _, k := range headPages {
+		out.WriteString(fmt.Sprintf("	%s: {", k))
+		for _, tag := range bg.headTable[k] {
+			out.WriteString(fmt.Sprintf("{token: %q, name: %q, value: %q},", tag.Token, tag.Name, tag.Value))
 		}
-		out.WriteString("}")
+		out.WriteString("},\n")
 	}
+	out.WriteString("}")
+
+	// the bundle manifest is always defined as the runtime uses it to find the bundle files
+	out.WriteString("\nvar bundleManifest = map[string]string{\n")
+	for _, k := range bg.manifestKeys() {
+		out.WriteString(fmt.Sprintf("	%q: %q,\n", k, bg.AssetManifest[k]))
+	}
+	out.WriteString("}")
 
 	return &GOLibFile{
 		PackageName: bg.PackageName,
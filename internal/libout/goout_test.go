@@ -5,9 +5,14 @@
 package libout
 
 import (
+	"io/fs"
+	"os"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/GuyARoss/orbit/pkg/embedutils"
+	"github.com/GuyARoss/orbit/pkg/jsparse"
 )
 
 func TestMergeImports(t *testing.T) {
@@ -143,8 +148,316 @@ func TestEnvFile(t *testing.T) {
 	}
 
 	got := len(loboutFile.(*GOLibFile).Body)
-	if got != 992 {
-		t.Errorf("got '%d', expected '%d'", got, 992)
+	if got != 1027 {
+		t.Errorf("got '%d', expected '%d'", got, 1027)
 		return
 	}
 }
+
+func TestEnvFile_AssetManifest(t *testing.T) {
+	f := &GOLibout{}
+	loboutFile, err := f.EnvFile(&BundleGroup{
+		pages: []*page{
+			{
+				name:        "SomePage",
+				bundleKey:   "abc",
+				wrapVersion: "reactCSR",
+			},
+		},
+		componentBodyMap: map[string][]string{
+			"reactCSR": {`<script src="/p/dep.js"></script>`},
+		},
+		wrapDocRender: make(map[string][]embedutils.FileReader),
+		BundleGroupOpts: &BundleGroupOpts{
+			PackageName: "TestPackage",
+			AssetManifest: map[string]string{
+				"abc.js": "abc.1a2b.js",
+				"dep.js": "dep.3c4d.js",
+			},
+		},
+	})
+	if err != nil {
+		t.Error("did not expect error", err)
+		return
+	}
+
+	body := loboutFile.(*GOLibFile).Body
+
+	for _, expected := range []string{`"abc.js": "abc.1a2b.js",`, `<script src="/p/dep.3c4d.js"></script>`} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected env file to contain '%s'", expected)
+		}
+	}
+
+	if strings.Contains(body, `"/p/dep.js"`) {
+		t.Error("expected page dependencies to reference the hashed bundle file")
+	}
+}
+
+func TestEnvFile_StaticHeads(t *testing.T) {
+	f := &GOLibout{}
+	loboutFile, err := f.EnvFile(&BundleGroup{
+		pages: []*page{
+			{
+				name: "SomePage",
+			},
+		},
+		headTable: map[string][]jsparse.OrbitHeadTag{
+			"SomePage": {{Token: jsparse.OrbitTitleToken, Value: `Some "Title"`}},
+		},
+		wrapDocRender:   make(map[string][]embedutils.FileReader),
+		BundleGroupOpts: &BundleGroupOpts{PackageName: "TestPackage"},
+	})
+	if err != nil {
+		t.Error("did not expect error", err)
+		return
+	}
+
+	expected := `SomePage: {{token: "orbit:title", name: "", value: "Some \"Title\""},},`
+	if !strings.Contains(loboutFile.(*GOLibFile).Body, expected) {
+		t.Errorf("expected env file to contain '%s'", expected)
+	}
+}
+
+type mapFileReader struct {
+	fs   fstest.MapFS
+	name string
+}
+
+func (r *mapFileReader) Read() (fs.File, error) { return r.fs.Open(r.name) }
+
+func TestHTTPFile(t *testing.T) {
+	f := &GOLibout{
+		httpFile: &mapFileReader{
+			fs:   fstest.MapFS{"orbit.go": {Data: []byte("package orbit\n\nimport (\n\t\"fmt\"\n\t\"net/http\"\n)\n\nvar handler http.Handler\n")}},
+			name: "orbit.go",
+		},
+	}
+
+	loboutFile, err := f.HTTPFile("TestPackage")
+	if err != nil {
+		t.Error("did not expect error", err)
+		return
+	}
+
+	body := loboutFile.(*GOLibFile).Body
+
+	for _, expected := range []string{"var handler http.Handler", "type HTMLDocument struct", "func ParseHTML("} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected http file to contain '%s'", expected)
+		}
+	}
+
+	if strings.Count(body, `"fmt"`) != 1 {
+		t.Error("expected the imports of the document model to be merged")
+	}
+}
+
+func TestEnvFile_SharedEmbedFiles(t *testing.T) {
+	files := fstest.MapFS{
+		"shared.go":  {Data: []byte("package webwrap\n\nfunc sharedRender() {}\n")},
+		"react.go":   {Data: []byte("package webwrap\n\nfunc reactRender() {}\n")},
+		"vue_ssr.go": {Data: []byte("package webwrap\n\nfunc vueRender() {}\n")},
+	}
+
+	f := &GOLibout{}
+	loboutFile, err := f.EnvFile(&BundleGroup{
+		wrapDocRender: map[string][]embedutils.FileReader{
+			"react": {&mapFileReader{fs: files, name: "shared.go"}, &mapFileReader{fs: files, name: "react.go"}},
+			"vue":   {&mapFileReader{fs: files, name: "shared.go"}, &mapFileReader{fs: files, name: "vue_ssr.go"}},
+		},
+		BundleGroupOpts: &BundleGroupOpts{PackageName: "TestPackage"},
+	})
+	if err != nil {
+		t.Error("did not expect error", err)
+		return
+	}
+
+	body := loboutFile.(*GOLibFile).Body
+
+	if strings.Count(body, "func sharedRender()") != 1 {
+		t.Error("expected the shared embed file to be merged once")
+	}
+
+	for _, expected := range []string{"func reactRender()", "func vueRender()"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected env file to contain '%s'", expected)
+		}
+	}
+}
+
+func TestEnvFile_SharedChunks(t *testing.T) {
+	f := &GOLibout{}
+	loboutFile, err := f.EnvFile(&BundleGroup{
+		pages: []*page{
+			{
+				name:         "SomePage",
+				bundleKey:    "abc",
+				wrapVersion:  "reactCSR",
+				sharedChunks: []string{"orbit_shared_1a2b.js"},
+			},
+		},
+		wrapDocRender: make(map[string][]embedutils.FileReader),
+		BundleGroupOpts: &BundleGroupOpts{
+			PackageName: "TestPackage",
+			AssetManifest: map[string]string{
+				"orbit_shared_1a2b.js": "orbit_shared_1a2b.3c4d.js",
+			},
+		},
+	})
+	if err != nil {
+		t.Error("did not expect error", err)
+		return
+	}
+
+	body := loboutFile.(*GOLibFile).Body
+
+	if !strings.Contains(body, "`<script src=\"/p/orbit_shared_1a2b.3c4d.js\"></script>`,") {
+		t.Errorf("expected page dependencies to load the shared chunk of the page got '%s'", body)
+	}
+}
+
+func TestEnvFile_SSRBackend(t *testing.T) {
+	files := fstest.MapFS{
+		"ssr_vm.go": {Data: []byte("package webwrap\n\nvar SSRBackend = \"node\"\n")},
+	}
+
+	tt := []struct {
+		wrapDocRender map[string][]embedutils.FileReader
+		ssrBackend    string
+		expected      bool
+	}{
+		{map[string][]embedutils.FileReader{"reactSSR": {&mapFileReader{fs: files, name: "ssr_vm.go"}}}, "embedded", true},
+		{map[string][]embedutils.FileReader{"reactSSR": {&mapFileReader{fs: files, name: "ssr_vm.go"}}}, "", false},
+		{map[string][]embedutils.FileReader{}, "embedded", false},
+	}
+
+	for i, d := range tt {
+		f := &GOLibout{}
+		loboutFile, err := f.EnvFile(&BundleGroup{
+			wrapDocRender:   d.wrapDocRender,
+			BundleGroupOpts: &BundleGroupOpts{PackageName: "TestPackage", SSRBackend: d.ssrBackend},
+		})
+		if err != nil {
+			t.Error("did not expect error", err)
+			return
+		}
+
+		got := strings.Contains(loboutFile.(*GOLibFile).Body, "SSRBackend = \"embedded\"\n}")
+		if got != d.expected {
+			t.Errorf("(%d) expected ssr backend to be set '%t' got '%t'", i, d.expected, got)
+		}
+	}
+}
+
+func TestWriteLibout_JSVMFile(t *testing.T) {
+	files := fstest.MapFS{
+		"ssr_vm.go": {Data: []byte("package webwrap\n\nvar SSRBackend = \"node\"\n")},
+	}
+
+	dir := t.TempDir()
+	fOpts := &FilePathOpts{
+		TestFile: dir + "/orb_test.go",
+		HTTPFile: dir + "/orb_http.go",
+		EnvFile:  dir + "/orb_env.go",
+		JSVMFile: dir + "/orb_jsvm.go",
+	}
+
+	goFile := &mapFileReader{
+		fs:   fstest.MapFS{"orbit.go": {Data: []byte("package orbit\n")}},
+		name: "orbit.go",
+	}
+
+	bg := New(&BundleGroupOpts{PackageName: "TestPackage"})
+	bg.wrapDocRender["reactSSR"] = []embedutils.FileReader{&mapFileReader{fs: files, name: "ssr_vm.go"}}
+
+	if err := bg.WriteLibout(NewGOLibout(goFile, goFile), fOpts); err != nil {
+		t.Error("did not expect error", err)
+		return
+	}
+
+	data, err := os.ReadFile(fOpts.JSVMFile)
+	if err != nil {
+		t.Error("expected the jsvm file to be written for server side rendered pages")
+		return
+	}
+
+	if !strings.HasPrefix(string(data), "//go:build goja\n\npackage TestPackage\n") {
+		t.Errorf("expected the jsvm file to be constrained to the goja build tag got '%s'", data)
+	}
+
+	bg = New(&BundleGroupOpts{PackageName: "TestPackage"})
+	if err := bg.WriteLibout(NewGOLibout(goFile, goFile), fOpts); err != nil {
+		t.Error("did not expect error", err)
+		return
+	}
+
+	if _, err := os.Stat(fOpts.JSVMFile); !os.IsNotExist(err) {
+		t.Error("expected the jsvm file to be removed when no pages are server side rendered")
+	}
+}
+
+func TestWriteLibout_PlatformFile(t *testing.T) {
+	files := fstest.MapFS{
+		"ssr_pool.go":         {Data: []byte("package webwrap\n\nvar pool = defaultSSRAddress\n")},
+		"ssr_proc_windows.go": {Data: []byte("//go:build windows\n\npackage webwrap\n\nimport \"os\"\n\nconst defaultSSRAddress = \"127.0.0.1:3024\"\n\nvar _ = os.Kill\n")},
+	}
+
+	dir := t.TempDir()
+	fOpts := &FilePathOpts{
+		TestFile:    dir + "/orb_test.go",
+		HTTPFile:    dir + "/orb_http.go",
+		EnvFile:     dir + "/orb_env.go",
+		PlatformDir: dir,
+	}
+
+	goFile := &mapFileReader{
+		fs:   fstest.MapFS{"orbit.go": {Data: []byte("package orbit\n")}},
+		name: "orbit.go",
+	}
+
+	bg := New(&BundleGroupOpts{PackageName: "TestPackage"})
+	bg.wrapDocRender["reactHydrate"] = []embedutils.FileReader{
+		&mapFileReader{fs: files, name: "ssr_pool.go"},
+		&mapFileReader{fs: files, name: "ssr_proc_windows.go"},
+	}
+
+	if err := bg.WriteLibout(NewGOLibout(goFile, goFile), fOpts); err != nil {
+		t.Error("did not expect error", err)
+		return
+	}
+
+	data, err := os.ReadFile(dir + "/orb_ssr_proc_windows.go")
+	if err != nil {
+		t.Error("expected the platform file to be written")
+		return
+	}
+
+	if !strings.HasPrefix(string(data), "//go:build windows\n\npackage TestPackage\n\nimport (\n\t\"os\"\n)\n") {
+		t.Errorf("expected the platform file to be constrained to its build tag got '%s'", data)
+	}
+
+	if strings.Contains(string(data), "//go:build windows\n\nconst") {
+		t.Error("expected the build constraint to be removed from the body of the platform file")
+	}
+
+	env, err := os.ReadFile(fOpts.EnvFile)
+	if err != nil {
+		t.Error("did not expect error", err)
+		return
+	}
+
+	if strings.Contains(string(env), "127.0.0.1:3024") {
+		t.Error("expected the platform file to not be merged with the env file")
+	}
+
+	bg = New(&BundleGroupOpts{PackageName: "TestPackage"})
+	if err := bg.WriteLibout(NewGOLibout(goFile, goFile), fOpts); err != nil {
+		t.Error("did not expect error", err)
+		return
+	}
+
+	if _, err := os.Stat(dir + "/orb_ssr_proc_windows.go"); !os.IsNotExist(err) {
+		t.Error("expected the platform file to be removed when it is no longer used")
+	}
+}
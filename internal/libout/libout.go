@@ -7,10 +7,14 @@ package libout
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/GuyARoss/orbit/internal/srcpack"
 	"github.com/GuyARoss/orbit/pkg/embedutils"
+	"github.com/GuyARoss/orbit/pkg/jsparse"
 	"github.com/GuyARoss/orbit/pkg/webwrap"
 )
 
@@ -20,6 +24,11 @@ type BundleGroupOpts struct {
 	BundleMode    string
 	PublicDir     string
 	HotReloadPort int
+	// AssetManifest maps the name of a bundle file to its content-hashed name
+	AssetManifest map[string]string
+	// SSRBackend is the renderer used by the package to server side render pages e.g "embedded",
+	// when left blank the default renderer of the package is used.
+	SSRBackend string
 }
 
 type page struct {
@@ -28,6 +37,8 @@ type page struct {
 	wrapVersion      string
 	filePath         string
 	isStaticResource bool
+	// sharedChunks are the file names of the chunks that are shared between the bundle of the page & the other pages
+	sharedChunks []string
 }
 
 type pageList []*page
@@ -53,12 +64,21 @@ type Libout interface {
 	TestFile(packageName string) (LiboutFile, error)
 	HTTPFile(packageName string) (LiboutFile, error)
 	EnvFile(*BundleGroup) (LiboutFile, error)
+	// JSVMFile provides the javascript vm of the embedded ssr renderer to the package when it is built with the "goja" tag
+	JSVMFile(packageName string) (LiboutFile, error)
+	// PlatformFile provides an embedded file that is constrained to a platform, e.g the process group of the ssr renderer
+	PlatformFile(packageName string, entry embedutils.FileReader) (LiboutFile, error)
 }
 
 type FilePathOpts struct {
 	TestFile string
 	HTTPFile string
 	EnvFile  string
+	// JSVMFile is only written when the package server side renders pages, it is otherwise removed
+	JSVMFile string
+	// PlatformDir is the directory that the platform constrained files of the wrappers are written to
+	// e.g "orb_ssr_proc_windows.go", the files that are no longer used by the package are removed
+	PlatformDir string
 }
 
 type BundleWriter interface {
@@ -75,6 +95,7 @@ type BundleGroup struct {
 	componentBodyMap map[string][]string
 	wrapDocRender    map[string][]embedutils.FileReader
 	routeTable       map[string]string
+	headTable        map[string][]jsparse.OrbitHeadTag
 }
 
 func (opts *BundleGroup) WriteLibout(files Libout, fOpts *FilePathOpts) error {
@@ -96,6 +117,30 @@ func (opts *BundleGroup) WriteLibout(files Libout, fOpts *FilePathOpts) error {
 		},
 	}
 
+	if fOpts.JSVMFile != "" {
+		if !opts.serverRendered() {
+			// a previous build may have server side rendered pages, the file would no longer compile without the renderer
+			if err := os.Remove(fOpts.JSVMFile); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		} else {
+			fns = append(fns, func() (LiboutFile, string, error) {
+				f, err := files.JSVMFile(opts.PackageName)
+
+				return f, fOpts.JSVMFile, err
+			})
+		}
+	}
+
+	if fOpts.PlatformDir != "" {
+		platformFns, err := opts.platformFiles(files, fOpts.PlatformDir)
+		if err != nil {
+			return err
+		}
+
+		fns = append(fns, platformFns...)
+	}
+
 	// TODO(guy): concurrent this
 	for _, fn := range fns {
 		fs, path, err := fn()
@@ -112,12 +157,93 @@ func (opts *BundleGroup) WriteLibout(files Libout, fOpts *FilePathOpts) error {
 	return nil
 }
 
+// platformFiles creates the platform constrained files of the wrappers, the platform files of a previous
+// build that are not used by the wrappers of the current pages are removed as they may no longer compile.
+func (opts *BundleGroup) platformFiles(files Libout, dir string) ([]func() (LiboutFile, string, error), error) {
+	fns := []func() (LiboutFile, string, error){}
+	written := make(map[string]bool)
+
+	for _, v := range opts.wrapDocRender {
+		for _, f := range v {
+			name := embedFileName(f)
+			if !isPlatformFile(name) {
+				continue
+			}
+
+			path := filepath.Join(dir, fmt.Sprintf("orb_%s", name))
+			if written[path] {
+				continue
+			}
+			written[path] = true
+
+			entry := f
+			fns = append(fns, func() (LiboutFile, string, error) {
+				lf, err := files.PlatformFile(opts.PackageName, entry)
+
+				return lf, path, err
+			})
+		}
+	}
+
+	for _, pattern := range []string{"orb_*_unix.go", "orb_*_windows.go"} {
+		existing, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range existing {
+			if written[path] {
+				continue
+			}
+
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+		}
+	}
+
+	return fns, nil
+}
+
+// serverRendered is true when the ssr renderer is included in the package by the wrappers of any of the pages
+func (l *BundleGroup) serverRendered() bool {
+	for _, v := range l.wrapDocRender {
+		for _, f := range v {
+			if embedFileName(f) == ssrVMFileName {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 // parseVersionKey parses the version key and converts it to a valid key
 func parseVersionKey(k string) string {
 	f := strings.ReplaceAll(k, ".", "_")
 	return strings.ReplaceAll(f, "-", "")
 }
 
+// manifestKeys returns the sorted names of the bundle files in the asset manifest
+func (l *BundleGroup) manifestKeys() []string {
+	keys := make([]string, 0, len(l.AssetManifest))
+	for k := range l.AssetManifest {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// hashedReferences replaces the references to the bundle files in the dom element with their content-hashed names
+func (l *BundleGroup) hashedReferences(element string) string {
+	for _, k := range l.manifestKeys() {
+		element = strings.ReplaceAll(element, fmt.Sprintf(`"/p/%s"`, k), fmt.Sprintf(`"/p/%s"`, l.AssetManifest[k]))
+	}
+
+	return element
+}
+
 // AcceptComponent collects the required DOM elements and applies it to the component body map
 func (l *BundleGroup) AcceptComponent(ctx context.Context, c srcpack.PackComponent, cacheOpts *webwrap.CacheDOMOpts) error {
 	componentName := c.Name()
@@ -144,7 +270,7 @@ func (l *BundleGroup) AcceptComponent(ctx context.Context, c srcpack.PackCompone
 	}
 
 	if !l.pageMap[componentName] {
-		l.pages = append(l.pages, &page{componentName, c.BundleKey(), wrapper.Version(), c.OriginalFilePath(), c.IsStaticResource()})
+		l.pages = append(l.pages, &page{componentName, c.BundleKey(), wrapper.Version(), c.OriginalFilePath(), c.IsStaticResource(), c.SharedChunks()})
 		l.pageMap[componentName] = true
 	}
 
@@ -153,6 +279,10 @@ func (l *BundleGroup) AcceptComponent(ctx context.Context, c srcpack.PackCompone
 		l.routeTable[componentName] = orbitRoutePath
 	}
 
+	if head := c.JsDocument().OrbitHead(); len(head) > 0 {
+		l.headTable[componentName] = head
+	}
+
 	return nil
 }
 
@@ -176,5 +306,6 @@ func New(opts *BundleGroupOpts) *BundleGroup {
 		componentBodyMap: make(map[string][]string),
 		wrapDocRender:    make(map[string][]embedutils.FileReader),
 		routeTable:       map[string]string{},
+		headTable:        map[string][]jsparse.OrbitHeadTag{},
 	}
 }
@@ -0,0 +1,222 @@
+// Copyright (c) 2021 Guy A. Ross
+// This source code is licensed under the GNU GPLv3 found in the
+// license file in the root directory of this source tree.
+
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// AssetManifestFile is the name of the manifest file written to the bundle directory
+const AssetManifestFile = "orbit-manifest.json"
+
+// hashedExtensions are the extensions of bundle files that are given a content-hashed name
+var hashedExtensions = map[string]bool{
+	".js":  true,
+	".css": true,
+}
+
+// hashedFilePattern matches the names of files that have already been content-hashed
+var hashedFilePattern = regexp.MustCompile(`\.[0-9a-f]{16}\.[a-z]+$`)
+
+// AssetManifest maps the name of a bundle file to the name of its content-hashed copy
+// e.g "bundle.js" => "bundle.1a2b3c4d5e6f7a8b.js"
+type AssetManifest map[string]string
+
+// assetManifestFile is the structure of the manifest file that is written to the bundle directory.
+// retired hashes are kept so that they can be removed after the grace window has passed.
+type assetManifestFile struct {
+	Files   AssetManifest        `json:"files"`
+	Retired map[string]time.Time `json:"retired"`
+}
+
+// HashBundleOpts are the options used to create the content-hashed bundle files
+type HashBundleOpts struct {
+	// GraceWindow is the duration that previously hashed files are kept after they have been replaced,
+	// this allows clients still running a previous deploy to request the files that they reference.
+	GraceWindow time.Duration
+	// Now is the time used to retire previously hashed files, defaults to the current time
+	Now time.Time
+}
+
+// readAssetManifestFile reads the manifest file from the directory, an empty manifest is returned
+// if one has not yet been written.
+func readAssetManifestFile(dir string) (*assetManifestFile, error) {
+	m := &assetManifestFile{
+		Files:   AssetManifest{},
+		Retired: map[string]time.Time{},
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, AssetManifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+
+	if m.Files == nil {
+		m.Files = AssetManifest{}
+	}
+
+	if m.Retired == nil {
+		m.Retired = map[string]time.Time{}
+	}
+
+	return m, nil
+}
+
+// hashedName creates the content-hashed name of the file
+func hashedName(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	name := filepath.Base(path)
+	ext := filepath.Ext(name)
+
+	return fmt.Sprintf("%s.%s%s", strings.TrimSuffix(name, ext), hex.EncodeToString(h.Sum(nil))[:16], ext), nil
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+
+	return err
+}
+
+// removeHashedFile removes the hashed file along with any of its precompressed sidecars
+func removeHashedFile(dir string, name string) error {
+	for _, ext := range []string{"", ".gz", ".br", ".zst"} {
+		err := os.Remove(filepath.Join(dir, name+ext))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HashBundleDir creates a content-hashed copy of each of the bundle files at the root of the directory
+// and writes the manifest of these files to the directory. hashed files from previous builds are kept
+// until the grace window has passed, so that they can still be served to clients of a previous deploy.
+func HashBundleDir(dir string, opts *HashBundleOpts) (AssetManifest, error) {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	previous, err := readAssetManifestFile(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// files that were previously hashed should not be hashed again
+	hashed := make(map[string]bool)
+	for _, name := range previous.Files {
+		hashed[name] = true
+	}
+	for name := range previous.Retired {
+		hashed[name] = true
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := AssetManifest{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || hashed[name] || hashedFilePattern.MatchString(name) || !hashedExtensions[strings.ToLower(filepath.Ext(name))] {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+
+		hashedFile, err := hashedName(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, hashedFile)); errors.Is(err, os.ErrNotExist) {
+			if err = copyFile(path, filepath.Join(dir, hashedFile)); err != nil {
+				return nil, err
+			}
+		}
+
+		manifest[name] = hashedFile
+	}
+
+	current := make(map[string]bool)
+	for _, name := range manifest {
+		current[name] = true
+	}
+
+	retired := make(map[string]time.Time)
+	for _, name := range previous.Files {
+		if !current[name] {
+			retired[name] = now
+		}
+	}
+
+	for name, retiredAt := range previous.Retired {
+		if current[name] {
+			continue
+		}
+
+		if now.Sub(retiredAt) > opts.GraceWindow {
+			if err = removeHashedFile(dir, name); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		retired[name] = retiredAt
+	}
+
+	data, err := json.MarshalIndent(&assetManifestFile{Files: manifest, Retired: retired}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	if err = os.WriteFile(filepath.Join(dir, AssetManifestFile), data, 0644); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2021 Guy A. Ross
+// This source code is licensed under the GNU GPLv3 found in the
+// license file in the root directory of this source tree.
+
+package internal
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHashBundleDir(t *testing.T) {
+	tdir := t.TempDir()
+	now := time.Now()
+
+	ioutil.WriteFile(tdir+"/bundle.js", []byte("console.log('v1')"), 0666)
+	ioutil.WriteFile(tdir+"/bundle", []byte("<html></html>"), 0666)
+
+	first, err := HashBundleDir(tdir, &HashBundleOpts{GraceWindow: time.Hour, Now: now})
+	if err != nil {
+		t.Errorf("should not fail during hashing '%s'", err)
+		return
+	}
+
+	v1 := first["bundle.js"]
+	if v1 == "" || !strings.HasPrefix(v1, "bundle.") || !strings.HasSuffix(v1, ".js") {
+		t.Errorf("expected hashed name for bundle got '%s'", v1)
+		return
+	}
+
+	if _, ok := first["bundle"]; ok {
+		t.Error("expected files without a hashable extension to be skipped")
+	}
+
+	if data, _ := ioutil.ReadFile(tdir + "/" + v1); string(data) != "console.log('v1')" {
+		t.Errorf("expected hashed file to contain the bundle content got '%s'", data)
+	}
+
+	t.Run("unchanged content keeps hash", func(t *testing.T) {
+		m, err := HashBundleDir(tdir, &HashBundleOpts{GraceWindow: time.Hour, Now: now})
+		if err != nil {
+			t.Errorf("should not fail during hashing '%s'", err)
+			return
+		}
+
+		if m["bundle.js"] != v1 || len(m) != 1 {
+			t.Errorf("expected manifest to be unchanged got '%v'", m)
+		}
+	})
+
+	ioutil.WriteFile(tdir+"/bundle.js", []byte("console.log('v2')"), 0666)
+
+	second, err := HashBundleDir(tdir, &HashBundleOpts{GraceWindow: time.Hour, Now: now})
+	if err != nil {
+		t.Errorf("should not fail during hashing '%s'", err)
+		return
+	}
+
+	if second["bundle.js"] == v1 {
+		t.Error("expected changed content to create a new hash")
+	}
+
+	if _, err := os.Stat(tdir + "/" + v1); err != nil {
+		t.Error("expected previous hash to be kept during the grace window")
+	}
+
+	_, err = HashBundleDir(tdir, &HashBundleOpts{GraceWindow: time.Hour, Now: now.Add(2 * time.Hour)})
+	if err != nil {
+		t.Errorf("should not fail during hashing '%s'", err)
+		return
+	}
+
+	if _, err := os.Stat(tdir + "/" + v1); err == nil {
+		t.Error("expected previous hash to be removed after the grace window")
+	}
+
+	if _, err := os.Stat(tdir + "/" + second["bundle.js"]); err != nil {
+		t.Error("expected current hash to be kept")
+	}
+}
@@ -84,8 +84,11 @@ func BenchmarkMakeFileStructure(b *testing.B) {
 		Assets: []fs.DirEntry{
 			ats.AssetEntry(assets.WebPackConfig),
 			ats.AssetEntry(assets.SSRProtoFile),
+			ats.AssetEntry(assets.SSRHealthProto),
 			ats.AssetEntry(assets.JsWebPackConfig),
 			ats.AssetEntry(assets.WebPackSWCConfig),
+			ats.AssetEntry(assets.SSRWebPackConfig),
+			ats.AssetEntry(assets.SSRWebPackSWCConfig),
 		},
 		Mkdirs: []string{},
 	}
@@ -0,0 +1,117 @@
+// Copyright (c) 2021 Guy A. Ross
+// This source code is licensed under the GNU GPLv3 found in the
+// license file in the root directory of this source tree.
+
+package internal
+
+import (
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// precompressExtensions are the extensions of bundle files that benefit from compression
+var precompressExtensions = map[string]bool{
+	".js":   true,
+	".css":  true,
+	".html": true,
+	".json": true,
+	".svg":  true,
+	".map":  true,
+	".txt":  true,
+}
+
+// sidecarEncoding is the encoding of a precompressed sidecar, the bundle server serves the sidecar
+// of the first encoding that the client accepts (in the order of br, zstd then gzip).
+type sidecarEncoding struct {
+	extension string
+	writer    func(w io.Writer) (io.WriteCloser, error)
+}
+
+// sidecarEncodings are the encodings of the sidecars that are created for each compressible file
+var sidecarEncodings = []sidecarEncoding{
+	{".gz", func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriterLevel(w, gzip.BestCompression)
+	}},
+	{".br", func(w io.Writer) (io.WriteCloser, error) {
+		return brotli.NewWriterLevel(w, brotli.BestCompression), nil
+	}},
+	{".zst", func(w io.Writer) (io.WriteCloser, error) {
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+	}},
+}
+
+// PrecompressOpts are the options used to create the precompressed sidecars of bundle files
+type PrecompressOpts struct {
+	// MinSize is the size (in bytes) that a file needs to be before a sidecar is created
+	MinSize int64
+}
+
+// PrecompressDir creates the gzip, brotli & zstd sidecars e.g "bundle.js.gz", "bundle.js.br" & "bundle.js.zst" for each
+// of the compressible files in the directory. the bundle server prefers these sidecars over compressing the file for each
+// request, sidecars that are already newer than their file are skipped.
+func PrecompressDir(dir string, opts *PrecompressOpts) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || !precompressExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		if info.Size() < opts.MinSize {
+			return nil
+		}
+
+		for _, e := range sidecarEncodings {
+			sidecar, err := os.Stat(path + e.extension)
+			if err == nil && !sidecar.ModTime().Before(info.ModTime()) {
+				continue
+			}
+
+			if err := compressFile(path, path+e.extension, e.writer); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// compressFile writes the content of the src file to the dst file through the compressed writer
+func compressFile(src string, dst string, writer func(w io.Writer) (io.WriteCloser, error)) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	w, err := writer(out)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(w, in); err != nil {
+		return err
+	}
+
+	return w.Close()
+}
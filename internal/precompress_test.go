@@ -0,0 +1,72 @@
+// Copyright (c) 2021 Guy A. Ross
+// This source code is licensed under the GNU GPLv3 found in the
+// license file in the root directory of this source tree.
+
+package internal
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestPrecompressDir(t *testing.T) {
+	tdir := t.TempDir()
+	content := strings.Repeat("console.log('orbit');", 100)
+
+	os.Mkdir(tdir+"/nested", 0777)
+	ioutil.WriteFile(tdir+"/large.js", []byte(content), 0666)
+	ioutil.WriteFile(tdir+"/nested/large.css", []byte(content), 0666)
+	ioutil.WriteFile(tdir+"/small.js", []byte("a()"), 0666)
+	ioutil.WriteFile(tdir+"/image.png", []byte(content), 0666)
+
+	err := PrecompressDir(tdir, &PrecompressOpts{MinSize: 1024})
+	if err != nil {
+		t.Errorf("should not fail during precompression '%s'", err)
+		return
+	}
+
+	readers := map[string]func(r io.Reader) (io.Reader, error){
+		".gz": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		".br": func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+		".zst": func(r io.Reader) (io.Reader, error) {
+			return zstd.NewReader(r)
+		},
+	}
+
+	for extension, reader := range readers {
+		for _, path := range []string{"/large.js" + extension, "/nested/large.css" + extension} {
+			f, err := os.Open(tdir + path)
+			if err != nil {
+				t.Errorf("expected sidecar '%s' to exist", path)
+				continue
+			}
+
+			r, err := reader(f)
+			if err != nil {
+				t.Errorf("expected sidecar '%s' to be compressed", path)
+				f.Close()
+				continue
+			}
+
+			data, _ := ioutil.ReadAll(r)
+			if string(data) != content {
+				t.Errorf("expected sidecar '%s' to contain the original content", path)
+			}
+
+			f.Close()
+		}
+
+		for _, path := range []string{"/small.js" + extension, "/image.png" + extension} {
+			if _, err := os.Stat(tdir + path); err == nil {
+				t.Errorf("expected sidecar '%s' to not be created", path)
+			}
+		}
+	}
+}
@@ -40,7 +40,7 @@ func BuildSPA(component srcpack.PackComponent, opts *SPABuildOpts) error {
 	}
 
 	// parse the html page (if exists) and add the javascript to it.
-	htmlDoc := htmlparse.NewEmptyDoc()
+	htmlDoc := htmlparse.NewHTMLDocument()
 
 	if viper.GetString("public_path") != "" {
 		// if a public html path is found, we use the contents as a base
@@ -57,10 +57,10 @@ func BuildSPA(component srcpack.PackComponent, opts *SPABuildOpts) error {
 		CacheDir:  "",
 	})
 	// note: altering the order of the appends will break functionality
-	htmlDoc.Body = append(htmlDoc.Body, wr.DocumentTag(component.BundleKey()))
+	htmlDoc.Insert(htmlparse.BodyEnd, wr.DocumentTag(component.BundleKey()))
 
-	htmlDoc.Body = append(htmlDoc.Body, body...)
-	htmlDoc.Body = append(htmlDoc.Body, fmt.Sprintf(`<script src="./%s.js"></script>`, component.BundleKey()))
+	htmlDoc.Insert(htmlparse.BodyEnd, body...)
+	htmlDoc.Insert(htmlparse.BodyEnd, fmt.Sprintf(`<script src="./%s.js"></script>`, component.BundleKey()))
 
 	htmlDoc.SaveToFile(fmt.Sprintf("%s/index.html", outDir))
 
@@ -16,7 +16,7 @@ import (
 
 type PackComponent interface {
 	Repack() error
-	RepackForWaitGroup(wg *sync.WaitGroup) error
+	RepackForWaitGroup(wg *sync.WaitGroup, deferred *DeferredBundle) error
 	OriginalFilePath() string
 	Dependencies() []*jsparse.ImportDependency
 	BundleKey() string
@@ -24,6 +24,7 @@ type PackComponent interface {
 	WebWrapper() webwrap.JSWebWrapper
 	IsStaticResource() bool
 	JsDocument() jsparse.JSDocument
+	SharedChunks() []string
 }
 
 // component that has been successfully ran, and output from a packing method.
@@ -39,6 +40,8 @@ type Component struct {
 	m                *sync.Mutex
 	isStaticResource bool
 	document         jsparse.JSDocument
+	sharedChunks     []string
+	deferred         *DeferredBundle
 }
 
 // NewComponentOpts options for creating a new component
@@ -49,6 +52,10 @@ type NewComponentOpts struct {
 	JSParser            jsparse.JSParser
 	JSWebWrappers       webwrap.JSWebWrapperList
 	SkipFirstPassBundle bool
+	// Deferred defers the bundling of the shared configurators of the component (or each of its configurators when
+	// multi-entry is enabled) until all of the pages of the build have been setup, when it is left nil the component
+	// is bundled on its own.
+	Deferred *DeferredBundle
 }
 
 var ErrInvalidComponentType = errors.New("invalid component type")
@@ -115,12 +122,30 @@ func NewComponent(ctx context.Context, opts *NewComponentOpts) (PackComponent, e
 		}
 	}
 
+	sharedChunks := make([]string, 0)
 	for _, r := range resource.Configurators {
+		deferred := false
+		if opts.Deferred != nil {
+			var chunks []string
+			deferred, chunks = opts.Deferred.Add(wrapMethod, r, opts.FilePath)
+			sharedChunks = append(sharedChunks, chunks...)
+		}
+
+		// shared configurators are written by the deferred bundle, the config of the page is still
+		// written when it is deferred so that the page can be rebundled on its own.
+		if deferred && r.Shared {
+			continue
+		}
+
 		configErr := r.Page.WriteFile(r.FilePath)
 		if configErr != nil {
 			return nil, configErr
 		}
 
+		if deferred {
+			continue
+		}
+
 		bundleErr := wrapMethod.Bundle(r.FilePath, opts.FilePath)
 		if bundleErr != nil {
 			return nil, bundleErr
@@ -136,8 +161,10 @@ func NewComponent(ctx context.Context, opts *NewComponentOpts) (PackComponent, e
 		webWrapper:       wrapMethod,
 		JsParser:         opts.JSParser,
 		WebDir:           opts.WebDir,
-		isStaticResource: len(initPage.DefaultExport().Args) == 0,
+		isStaticResource: len(initPage.DefaultExport().Args) == 0 && !webwrap.HasIslands(wrapMethod, initPage),
 		document:         initPage,
+		sharedChunks:     sharedChunks,
+		deferred:         opts.Deferred,
 	}, nil
 }
 
@@ -145,11 +172,27 @@ func (s *Component) JsDocument() jsparse.JSDocument { return s.document }
 
 func (s *Component) IsStaticResource() bool { return s.isStaticResource }
 
+// SharedChunks returns the file names of the chunks that are shared with the other pages of the multi-entry bundle,
+// these chunks are required to be loaded along with the bundle of the component. only the chunks that were emitted
+// by the bundle are returned, so the chunks are not known until the deferred bundle has been bundled.
+func (s *Component) SharedChunks() []string {
+	if s.deferred == nil {
+		return nil
+	}
+
+	return s.deferred.EmittedChunks(s.sharedChunks)
+}
+
 // Repack repacks a component following the following processes
 //   - parses the provided filepath with the the components jsparser
 //   - reapplies the component web wrapper
 //   - bundles the component
 func (s *Component) Repack() error {
+	return s.repack(nil)
+}
+
+// repack repacks the component, the shared configurators of the component are bundled by the deferred bundle when it is provided.
+func (s *Component) repack(deferred *DeferredBundle) error {
 	// parse the original javascript page, provided our javascript parser.
 	// we later mutate this page to apply the rest of the required web wrapper
 	page, err := s.JsParser.Parse(s.originalFilePath, s.WebDir)
@@ -182,6 +225,8 @@ func (s *Component) Repack() error {
 	}
 
 	s.m.Lock()
+	defer s.m.Unlock()
+
 	for opt, filePath := range resource.BundleOpFileDescriptor {
 		err = pages[opt].WriteFile(filePath)
 		if err != nil {
@@ -190,6 +235,11 @@ func (s *Component) Repack() error {
 	}
 
 	for _, b := range resource.Configurators {
+		if deferred != nil && b.Shared {
+			deferred.Add(s.webWrapper, b, s.originalFilePath)
+			continue
+		}
+
 		err = b.Page.WriteFile(b.FilePath)
 		if err != nil {
 			return err
@@ -201,14 +251,13 @@ func (s *Component) Repack() error {
 		}
 	}
 
-	s.m.Unlock()
-
 	return nil
 }
 
-// RepackForWaitGroup given a wait group, repacks the component using the underlying "Repack" method.
-func (s *Component) RepackForWaitGroup(wg *sync.WaitGroup) error {
-	err := s.Repack()
+// RepackForWaitGroup given a wait group, repacks the component using the underlying "repack" method.
+// the shared configurators of the component are bundled by the deferred bundle once each of the components has been repacked.
+func (s *Component) RepackForWaitGroup(wg *sync.WaitGroup, deferred *DeferredBundle) error {
+	err := s.repack(deferred)
 
 	if err != nil {
 		return err
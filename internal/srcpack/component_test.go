@@ -6,6 +6,10 @@ package srcpack
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/GuyARoss/orbit/pkg/jsparse"
@@ -130,3 +134,140 @@ func TestRepack(t *testing.T) {
 		return
 	}
 }
+
+func TestNewComponent_MultiEntry(t *testing.T) {
+	deferred := NewDeferredBundle(true)
+
+	// the bundler would fail, so the component should not be bundled until the deferred bundle is bundled
+	_, err := NewComponent(context.TODO(), &NewComponentOpts{
+		FilePath:   "something.test",
+		WebDir:     "./webDir",
+		DefaultKey: "thing",
+		JSParser: &mock.MockJSParser{
+			Err:           nil,
+			ParseDocument: mock.NewMockJSDocument("test", "jsx", "test"),
+		},
+		JSWebWrappers: []webwrap.JSWebWrapper{&webwrapmock.MockWrapper{Satisfy: true, FailBundle: true}},
+		Deferred:      deferred,
+	})
+	if err != nil {
+		t.Errorf("error should not be thrown '%s'", err)
+		return
+	}
+
+	if len(deferred.configurators) != 1 {
+		t.Errorf("expected the configurator to be deferred got '%d'", len(deferred.configurators))
+	}
+}
+
+func TestNewComponent_Deferred(t *testing.T) {
+	deferred := NewDeferredBundle(false)
+
+	// configurators that are not shared are bundled by the page when multi-entry is not enabled
+	_, err := NewComponent(context.TODO(), &NewComponentOpts{
+		FilePath:   "something.test",
+		WebDir:     "./webDir",
+		DefaultKey: "thing",
+		JSParser: &mock.MockJSParser{
+			Err:           nil,
+			ParseDocument: mock.NewMockJSDocument("test", "jsx", "test"),
+		},
+		JSWebWrappers: []webwrap.JSWebWrapper{&webwrapmock.MockWrapper{Satisfy: true, FailBundle: true}},
+		Deferred:      deferred,
+	})
+	if err == nil {
+		t.Error("expected the page to be bundled on its own")
+	}
+
+	if len(deferred.configurators) != 0 {
+		t.Errorf("expected the configurator to not be deferred got '%d'", len(deferred.configurators))
+	}
+}
+
+func TestDeferredBundle_Add(t *testing.T) {
+	deferred := NewDeferredBundle(false)
+	wrapper := &webwrapmock.MockWrapper{}
+
+	// configurators that are shared between pages should only be bundled once
+	for _, page := range []string{"pages/a.jsx", "pages/b.jsx"} {
+		ok, _ := deferred.Add(wrapper, webwrap.BundleConfigurator{FilePath: ".orbit/base/pages/react_ssr.config.js", Shared: true}, page)
+		if !ok {
+			t.Error("expected the shared configurator to be deferred")
+		}
+	}
+
+	if len(deferred.configurators) != 1 {
+		t.Errorf("expected shared configurators to be added once got '%d'", len(deferred.configurators))
+	}
+
+	entry := &webwrap.WebpackEntry{BundleKey: "abc", FilePath: ".orbit/base/pages/abc.js"}
+	if ok, _ := deferred.Add(wrapper, webwrap.BundleConfigurator{FilePath: ".orbit/base/pages/abc.config.js", Entry: entry}, "pages/c.jsx"); ok {
+		t.Error("expected the entry to be bundled by the page when multi-entry is not enabled")
+	}
+
+	deferred = NewDeferredBundle(true)
+	ok, chunks := deferred.Add(wrapper, webwrap.BundleConfigurator{FilePath: ".orbit/base/pages/abc.config.js", Entry: entry}, "pages/c.jsx")
+
+	if !ok || len(chunks) != 1 || chunks[0] != fmt.Sprintf("%s.js", entry.SharedChunk()) {
+		t.Errorf("expected the shared chunk of the entry got '%v'", chunks)
+	}
+
+	if len(deferred.entries) != 1 || len(deferred.configurators) != 0 {
+		t.Errorf("expected the entry to be bundled by the multi-entry config")
+	}
+}
+
+func TestDeferredBundle_Bundle(t *testing.T) {
+	deferred := NewDeferredBundle(false)
+	path := filepath.Join(t.TempDir(), "react_ssr.map.js")
+
+	doc := jsparse.NewEmptyDocument()
+	doc.AddOther("export const a = 1")
+	deferred.Add(&webwrapmock.MockWrapper{}, webwrap.BundleConfigurator{FilePath: path, Page: doc, Shared: true}, "pages/a.jsx")
+
+	// the pages that are setup after the configurator has been added should still be included in the shared configurator
+	doc.AddOther("export const b = 2")
+
+	if err := deferred.Bundle(nil); err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("expected the shared configurator to be written")
+		return
+	}
+
+	if !strings.Contains(string(data), "export const a = 1") || !strings.Contains(string(data), "export const b = 2") {
+		t.Errorf("expected the shared configurator to include each of the pages got '%s'", data)
+	}
+}
+
+func TestDeferredBundle_EmittedChunks(t *testing.T) {
+	deferred := NewDeferredBundle(true)
+	wrapper := &webwrapmock.MockWrapper{}
+
+	shared := &webwrap.WebpackEntry{BundleKey: "abc", FilePath: ".orbit/base/pages/abc.js"}
+	alone := &webwrap.WebpackEntry{BundleKey: "def", FilePath: ".orbit/base/pages/def.js", Config: "vueConfig"}
+
+	_, sharedChunks := deferred.Add(wrapper, webwrap.BundleConfigurator{FilePath: ".orbit/base/pages/abc.config.js", Entry: shared}, "pages/abc.jsx")
+	_, aloneChunks := deferred.Add(wrapper, webwrap.BundleConfigurator{FilePath: ".orbit/base/pages/def.config.js", Entry: alone}, "pages/def.vue")
+
+	// webpack only emits the chunk of the entries that share node modules
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, sharedChunks[0]), []byte(""), 0644)
+
+	if err := deferred.recordEmittedChunks(dir); err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	if chunks := deferred.EmittedChunks(sharedChunks); len(chunks) != 1 || chunks[0] != sharedChunks[0] {
+		t.Errorf("expected the emitted chunk got '%v'", chunks)
+	}
+
+	if chunks := deferred.EmittedChunks(aloneChunks); len(chunks) != 0 {
+		t.Errorf("expected the chunk that was not emitted to be dropped got '%v'", chunks)
+	}
+}
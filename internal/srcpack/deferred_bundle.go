@@ -0,0 +1,146 @@
+// Copyright (c) 2021 Guy A. Ross
+// This source code is licensed under the GNU GPLv3 found in the
+// license file in the root directory of this source tree.
+
+package srcpack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/GuyARoss/orbit/pkg/webwrap"
+)
+
+// deferredConfigurator is a configurator that is bundled once all of the pages have been setup
+type deferredConfigurator struct {
+	wrapper          webwrap.JSWebWrapper
+	configurator     webwrap.BundleConfigurator
+	originalFilePath string
+}
+
+// DeferredBundle collects the configurators of the pages of a build, so that they are bundled once all of the pages
+// have been setup rather than once per page. the configurators that are shared by many pages (e.g the server bundle)
+// are always deferred, so that they are written & bundled once with the content of each of the pages.
+//
+// when multi-entry is enabled, each of the configurators is deferred. the webpack entries of the pages are bundled by a
+// multi-entry config per webpack config & the other configurators are bundled once per config file.
+type DeferredBundle struct {
+	m sync.Mutex
+
+	multiEntry    bool
+	entries       []*webwrap.WebpackEntry
+	configurators []*deferredConfigurator
+	configPaths   map[string]bool
+	// emitted are the file names of the shared chunks that were emitted by the multi-entry bundles
+	emitted map[string]bool
+}
+
+// Add adds the configurator of the page to the bundle when it is deferred, the file names of the shared chunks
+// that the page can depend on are returned. configurators that are not deferred should be bundled by the page.
+// webpack only emits a shared chunk when the pages share node modules, see EmittedChunks.
+func (b *DeferredBundle) Add(wrapper webwrap.JSWebWrapper, configurator webwrap.BundleConfigurator, originalFilePath string) (bool, []string) {
+	if !b.multiEntry && !configurator.Shared {
+		return false, nil
+	}
+
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	if b.multiEntry && configurator.Entry != nil {
+		b.entries = append(b.entries, configurator.Entry)
+		return true, []string{fmt.Sprintf("%s.js", configurator.Entry.SharedChunk())}
+	}
+
+	if !b.configPaths[configurator.FilePath] {
+		b.configurators = append(b.configurators, &deferredConfigurator{wrapper, configurator, originalFilePath})
+		b.configPaths[configurator.FilePath] = true
+	}
+
+	return true, nil
+}
+
+// Bundle bundles each of the collected configurators, the multi-entry configs are created by the provided bundler.
+func (b *DeferredBundle) Bundle(bundler *webwrap.BaseBundler) error {
+	for _, c := range b.configurators {
+		// shared configurators are only written once each of the pages has added its content to them
+		if c.configurator.Shared {
+			if err := c.configurator.Page.WriteFile(c.configurator.FilePath); err != nil {
+				return err
+			}
+		}
+
+		if err := c.wrapper.Bundle(c.configurator.FilePath, c.originalFilePath); err != nil {
+			return err
+		}
+	}
+
+	if len(b.entries) == 0 {
+		return nil
+	}
+
+	for _, c := range bundler.MultiEntryConfigurators(b.entries) {
+		if err := c.Page.WriteFile(c.FilePath); err != nil {
+			return err
+		}
+
+		if err := bundler.BundleMultiEntry(c.FilePath); err != nil {
+			return err
+		}
+	}
+
+	return b.recordEmittedChunks(bundler.BundleOutputDir())
+}
+
+// recordEmittedChunks records which of the shared chunks of the entries were emitted to the output directory
+func (b *DeferredBundle) recordEmittedChunks(dir string) error {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	for _, e := range b.entries {
+		name := fmt.Sprintf("%s.js", e.SharedChunk())
+		if b.emitted[name] {
+			continue
+		}
+
+		_, err := os.Stat(filepath.Join(dir, name))
+		if err == nil {
+			b.emitted[name] = true
+			continue
+		}
+
+		if !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// EmittedChunks filters the shared chunks to the chunks that were emitted once the bundle has been bundled,
+// a page does not load a chunk that was not emitted as its bundle includes the modules of the chunk itself.
+func (b *DeferredBundle) EmittedChunks(chunks []string) []string {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	emitted := make([]string, 0, len(chunks))
+	for _, c := range chunks {
+		if b.emitted[c] {
+			emitted = append(emitted, c)
+		}
+	}
+
+	return emitted
+}
+
+// NewDeferredBundle creates a deferred bundle, the webpack entries of the pages are only bundled together when multi-entry is enabled.
+func NewDeferredBundle(multiEntry bool) *DeferredBundle {
+	return &DeferredBundle{
+		multiEntry:    multiEntry,
+		entries:       make([]*webwrap.WebpackEntry, 0),
+		configurators: make([]*deferredConfigurator, 0),
+		configPaths:   make(map[string]bool),
+		emitted:       make(map[string]bool),
+	}
+}
@@ -7,6 +7,7 @@ package mock
 import (
 	"sync"
 
+	"github.com/GuyARoss/orbit/internal/srcpack"
 	"github.com/GuyARoss/orbit/pkg/jsparse"
 	"github.com/GuyARoss/orbit/pkg/webwrap"
 	"github.com/GuyARoss/orbit/pkg/webwrap/mock"
@@ -26,7 +27,8 @@ func (m *MockPackedComponent) Repack() error {
 }
 
 func (m *MockPackedComponent) IsStaticResource() bool { return false }
-func (m *MockPackedComponent) RepackForWaitGroup(wg *sync.WaitGroup) error {
+func (m *MockPackedComponent) SharedChunks() []string { return nil }
+func (m *MockPackedComponent) RepackForWaitGroup(wg *sync.WaitGroup, deferred *srcpack.DeferredBundle) error {
 	return nil
 }
 func (m *MockPackedComponent) OriginalFilePath() string                  { return m.FilePath }
@@ -10,6 +10,7 @@ import (
 	"os"
 	"sync"
 
+	"github.com/GuyARoss/orbit/pkg/experiments"
 	"github.com/GuyARoss/orbit/pkg/jsparse"
 	"github.com/GuyARoss/orbit/pkg/log"
 	"github.com/GuyARoss/orbit/pkg/webwrap"
@@ -34,7 +35,12 @@ type JSPacker struct {
 	SkipFirstPassBundle bool
 	AssetDir            string
 	WebDir              string
-	cachedBundleKeys    CachedEnvKeys
+	// MultiEntry bundles the pages of "PackMany" once all of them have been setup, with a multi-entry config per
+	// webpack config rather than a webpack process per page. the multi-entry configs are created by the "Bundler".
+	// the configurators that are shared between pages (e.g the server bundle) are always bundled once all of the pages have been setup.
+	MultiEntry       bool
+	Bundler          *webwrap.BaseBundler
+	cachedBundleKeys CachedEnvKeys
 }
 
 // concpack is a packing mechanism embedding the packer to pack a set of files concurrently.
@@ -45,6 +51,7 @@ type concPack struct {
 	packedPages      []PackComponent
 	packMap          map[string]bool
 	cachedBundleKeys CachedEnvKeys
+	deferred         *DeferredBundle
 }
 
 // PackMany packs the provided file paths into the orbit root directory
@@ -54,6 +61,7 @@ func (s *JSPacker) PackMany(pages []string) (PackedComponentList, error) {
 		packedPages:      make([]PackComponent, 0),
 		packMap:          make(map[string]bool),
 		cachedBundleKeys: s.cachedBundleKeys,
+		deferred:         NewDeferredBundle(s.MultiEntry),
 	}
 
 	wg := &sync.WaitGroup{}
@@ -83,6 +91,11 @@ func (s *JSPacker) PackMany(pages []string) (PackedComponentList, error) {
 
 	wg.Wait()
 
+	// the deferred configurators are only bundled once each of the pages has been setup, so that they include each of the pages
+	if packErr == nil {
+		packErr = cp.deferred.Bundle(s.Bundler)
+	}
+
 	return cp.packedPages, packErr
 }
 
@@ -108,6 +121,15 @@ type DefaultPackerOpts struct {
 	NodeModuleDir       string
 	CachedBundleKeys    CachedEnvKeys
 	SkipFirstPassBundle bool
+	// ReactVersion is the major version of react that is targeted by the react wrappers
+	ReactVersion int
+	// JSXFramework is the framework that renders the jsx pages which do not declare their own framework
+	JSXFramework string
+	// MultiEntry bundles the client bundles of the pages with a single multi-entry config, rather than per page
+	MultiEntry bool
+	// BundlerBackend is the bundler of the client bundles of the pages, when left blank the pages are
+	// bundled by esbuild when the esbuild experiment is enabled & are otherwise bundled by webpack.
+	BundlerBackend webwrap.BundlerBackend
 }
 
 // pack single packs a single file path into a usable web component
@@ -130,6 +152,7 @@ func (p *concPack) PackSingle(wg *sync.WaitGroup, path string) (PackComponent, e
 		JSWebWrappers:       p.ValidWebWrappers,
 		JSParser:            p.JsParser,
 		SkipFirstPassBundle: p.SkipFirstPassBundle,
+		Deferred:            p.deferred,
 	})
 
 	if err != nil {
@@ -157,13 +180,16 @@ func (l *PackedComponentList) RepackMany(logger log.Logger) error {
 
 	sh := NewSyncHook(logger)
 
+	// the shared configurators of the components are bundled once, after each of the components has been repacked
+	deferred := NewDeferredBundle(false)
+
 	for _, comp := range *l {
 		// we copy dir here to avoid the pointer of dir being passed to our wrap func.
 		t := comp
 		// go routine to pack every page found in the pages directory
 		// we wrap this routine with the sync hook to measure & log time deltas.
 		go sh.WrapFunc(t.OriginalFilePath(), func() *webwrap.WrapStats {
-			err := comp.RepackForWaitGroup(wg)
+			err := comp.RepackForWaitGroup(wg, deferred)
 			if err != nil {
 				errOnce.Do(func() {
 					packErr = err
@@ -176,7 +202,11 @@ func (l *PackedComponentList) RepackMany(logger log.Logger) error {
 
 	wg.Wait()
 
-	return packErr
+	if packErr != nil {
+		return packErr
+	}
+
+	return deferred.Bundle(nil)
 }
 
 // Write creates an audit file of all the current components to the specified file
@@ -199,17 +229,33 @@ func (l *PackedComponentList) Write(path string) error {
 }
 
 func NewDefaultPacker(logger log.Logger, opts *DefaultPackerOpts) Packer {
+	backend := opts.BundlerBackend
+	if backend == "" {
+		backend = webwrap.WebpackBackend
+
+		if experiments.GlobalExperimentalFeatures.PreferESBuild {
+			backend = webwrap.ESBuildBackend
+		}
+	}
+
+	bundler := &webwrap.BaseBundler{
+		Mode:           webwrap.BundlerMode(opts.BundlerMode),
+		PageOutputDir:  ".orbit/base/pages",
+		NodeModulesDir: opts.NodeModuleDir,
+		Logger:         logger,
+		ReactVersion:   opts.ReactVersion,
+		JSXFramework:   opts.JSXFramework,
+		Backend:        backend,
+	}
+
 	packer := &JSPacker{
-		JsParser: &jsparse.JSFileParser{},
-		ValidWebWrappers: webwrap.NewActiveMap(&webwrap.BaseBundler{
-			Mode:           webwrap.BundlerMode(opts.BundlerMode),
-			PageOutputDir:  ".orbit/base/pages",
-			NodeModulesDir: opts.NodeModuleDir,
-			Logger:         logger,
-		}),
+		JsParser:            &jsparse.JSFileParser{},
+		ValidWebWrappers:    webwrap.NewActiveMap(bundler),
 		Logger:              logger,
 		cachedBundleKeys:    opts.CachedBundleKeys,
 		SkipFirstPassBundle: opts.SkipFirstPassBundle,
+		MultiEntry:          opts.MultiEntry,
+		Bundler:             bundler,
 	}
 
 	return packer
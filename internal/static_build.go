@@ -21,6 +21,8 @@ type StaticBuild struct {
 	buildOpts         *BuildOpts
 	staticBuildOut    string
 	SkipResourceCheck bool
+	// SSRBackend is the renderer used to build the static content, either ewrap.NodeSSRBackend or ewrap.EmbeddedSSRBackend
+	SSRBackend string
 }
 
 type ComponentStaticContext struct {
@@ -35,18 +37,24 @@ func (opts *StaticBuild) createStaticContext(components srcpack.PackedComponentL
 	bundlePaths := make(map[ewrap.PageRender]string)
 
 	if opts.SkipResourceCheck {
+		bundler := &webwrap.BaseBundler{
+			Mode:           webwrap.DevelopmentBundle,
+			WebDir:         opts.buildOpts.ApplicationDir,
+			PageOutputDir:  ".orbit/base/pages",
+			NodeModulesDir: opts.buildOpts.NodeModulePath,
+			Logger:         nil,
+			ReactVersion:   opts.buildOpts.ReactVersion,
+			JSXFramework:   opts.buildOpts.JSXFramework,
+		}
+
 		ssrWrapMethod := webwrap.NewReactSSRPartial(&webwrap.NewReactSSROpts{
-			Bundler: &webwrap.BaseBundler{
-				Mode:           webwrap.DevelopmentBundle,
-				WebDir:         opts.buildOpts.ApplicationDir,
-				PageOutputDir:  ".orbit/base/pages",
-				NodeModulesDir: opts.buildOpts.NodeModulePath,
-				Logger:         nil,
-			},
+			Bundler:      bundler,
 			SourceMapDoc: jsparse.NewEmptyDocument(),
 			InitDoc:      jsparse.NewEmptyDocument(),
+			Streaming:    bundler.ReactMajorVersion() >= 18,
 		})
 
+		lastConfigurator := ""
 		for _, c := range components {
 			page, err := ssrWrapMethod.Apply(c.JsDocument())
 			if err != nil {
@@ -76,6 +84,8 @@ func (opts *StaticBuild) createStaticContext(components srcpack.PackedComponentL
 					fmt.Println(configErr)
 					break
 				}
+
+				lastConfigurator = r.FilePath
 			}
 
 			pages[ewrap.PageRender(c.BundleKey())] = ewrap.NewEmptyDocumentRenderer(c.WebWrapper().Stats().Bundler)
@@ -86,6 +96,13 @@ func (opts *StaticBuild) createStaticContext(components srcpack.PackedComponentL
 				staticMap[ewrap.PageRender(c.BundleKey())] = true
 			}
 		}
+
+		// the server bundle includes each of the components, so it is only bundled once all of them have been written
+		if lastConfigurator != "" {
+			if err := ssrWrapMethod.Bundle(lastConfigurator, ""); err != nil {
+				fmt.Println(err)
+			}
+		}
 	}
 
 	for _, c := range components {
@@ -115,8 +132,12 @@ func (opts *StaticBuild) Build(components srcpack.PackedComponentList) error {
 
 	doc := ewrap.DocFromFile(opts.buildOpts.PublicPath)
 
+	if opts.SSRBackend != "" {
+		ewrap.SSRBackend = opts.SSRBackend
+	}
+
 	defer ewrap.Close()
-	ewrap.StartupTaskReactSSR(opts.staticBuildOut, staticCtx.Pages, staticCtx.StaticMap, staticCtx.BundlePaths, *doc)()
+	ewrap.StartupTaskReactSSR(opts.staticBuildOut, staticCtx.Pages, staticCtx.StaticMap, staticCtx.BundlePaths, doc)()
 
 	return nil
 }
@@ -9,6 +9,8 @@ import "github.com/GuyARoss/orbit/pkg/log"
 type Features struct {
 	PreferSSR         bool
 	PreferSWCCompiler bool
+	PreferIslands     bool
+	PreferESBuild     bool
 }
 
 var GlobalExperimentalFeatures *Features = &Features{}
@@ -22,6 +24,12 @@ func Load(logger log.Logger, features []string) error {
 		case "swc":
 			GlobalExperimentalFeatures.PreferSWCCompiler = true
 			logger.Warn("experimental feature 'prefer swc compiler' enabled\n")
+		case "islands":
+			GlobalExperimentalFeatures.PreferIslands = true
+			logger.Warn("experimental feature 'prefer islands' enabled\n")
+		case "esbuild":
+			GlobalExperimentalFeatures.PreferESBuild = true
+			logger.Warn("experimental feature 'prefer esbuild' enabled\n")
 		}
 	}
 
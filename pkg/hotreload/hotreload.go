@@ -5,6 +5,7 @@
 package hotreload
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
 	"sync"
@@ -60,6 +61,7 @@ type HotReload struct {
 	currentBundleKeys BundleKeyList
 	Redirected        chan RedirectionEvent
 	skipUpgrade       bool
+	pendingLogs       []*SocketRequest
 }
 
 type SocketRequest struct {
@@ -131,6 +133,67 @@ func (s *HotReload) EmitLog(level LogLevel, message string) error {
 	return s.socket.WriteJSON(r)
 }
 
+// LogRequest is a log that has been sent to the hot reload server by the application server
+type LogRequest struct {
+	Level   LogLevel `json:"level"`
+	Message string   `json:"message"`
+	Origin  string   `json:"origin"`
+}
+
+// maxPendingLogs is the maximum number of logs that are held while the browser is not connected
+const maxPendingLogs = 25
+
+// HandleLog accepts logs from the application server & forwards them to the browser. Since these logs are
+// usually created while the browser is loading the page, they are held until the next socket connects.
+func (s *HotReload) HandleLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	logRequest := &LogRequest{}
+	if err := json.NewDecoder(r.Body).Decode(logRequest); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	origin := logRequest.Origin
+	if origin == "" {
+		origin = "server"
+	}
+
+	sockRequest := &SocketRequest{
+		Operation: "logger",
+		Value:     []string{strconv.Itoa(int(logRequest.Level)), logRequest.Message, origin},
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.pendingLogs = append(s.pendingLogs, sockRequest)
+	if len(s.pendingLogs) > maxPendingLogs {
+		s.pendingLogs = s.pendingLogs[len(s.pendingLogs)-maxPendingLogs:]
+	}
+
+	if s.IsActive() {
+		s.flushPendingLogs()
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// flushPendingLogs writes each of the pending logs to the current socket
+func (s *HotReload) flushPendingLogs() {
+	for i, l := range s.pendingLogs {
+		if err := s.socket.WriteJSON(l); err != nil {
+			s.pendingLogs = s.pendingLogs[i:]
+			return
+		}
+	}
+
+	s.pendingLogs = s.pendingLogs[:0]
+}
+
 func (s *HotReload) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	s.m.Lock()
 
@@ -153,6 +216,7 @@ func (s *HotReload) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	}
 
 	s.socket = c
+	s.flushPendingLogs()
 
 	switch sockRequest.Operation {
 	case "pages":
@@ -6,6 +6,8 @@ package hotreload
 
 import (
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -189,3 +191,64 @@ func TestNewSocket(t *testing.T) {
 		t.Errorf("expected updated bundle keys got '%s'", n.currentBundleKeys)
 	}
 }
+
+func TestHandleLog(t *testing.T) {
+	t.Run("held until the socket connects", func(t *testing.T) {
+		hr := New()
+
+		w := httptest.NewRecorder()
+		hr.HandleLog(w, httptest.NewRequest(http.MethodPost, "/log", strings.NewReader(`{"level": 2, "message": "render failed"}`)))
+
+		if w.Code != http.StatusAccepted {
+			t.Errorf("expected status 202 got %d", w.Code)
+		}
+
+		if len(hr.pendingLogs) != 1 || hr.pendingLogs[0].Value[2] != "server" {
+			t.Errorf("expected a pending server log got '%v'", hr.pendingLogs)
+			return
+		}
+
+		go func() { <-hr.Redirected }()
+
+		ms := &mock.MockSocket{ReadData: &SocketRequest{Operation: "pages"}}
+		hr.skipUpgrade = true
+		hr.socket = ms
+		hr.HandleWebSocket(&mock.MockResponseWriter{}, &http.Request{})
+
+		if !ms.DidWrite {
+			t.Error("expected pending log to be written to socket")
+		}
+
+		if len(hr.pendingLogs) != 0 {
+			t.Errorf("expected pending logs to be flushed got %d", len(hr.pendingLogs))
+		}
+	})
+
+	t.Run("written to an active socket", func(t *testing.T) {
+		ms := &mock.MockSocket{}
+		hr := New()
+		hr.socket = ms
+
+		hr.HandleLog(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/log", strings.NewReader(`{"level": 1, "message": "warning"}`)))
+
+		if !ms.DidWrite {
+			t.Error("did not write to socket")
+		}
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		hr := New()
+
+		w := httptest.NewRecorder()
+		hr.HandleLog(w, httptest.NewRequest(http.MethodGet, "/log", nil))
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("expected status 405 got %d", w.Code)
+		}
+
+		w = httptest.NewRecorder()
+		hr.HandleLog(w, httptest.NewRequest(http.MethodPost, "/log", strings.NewReader("{")))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400 got %d", w.Code)
+		}
+	})
+}
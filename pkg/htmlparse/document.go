@@ -0,0 +1,544 @@
+// Copyright (c) 2021 Guy A. Ross
+// This source code is licensed under the GNU GPLv3 found in the
+// license file in the root directory of this source tree.
+
+package htmlparse
+
+// NOTE: this file is also written to the autogenerated http file, so it should
+// only depend on the standard library & its identifiers should not collide with those of the http file.
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HTMLTokenType is the type of a single token of an html document
+type HTMLTokenType int
+
+const (
+	HTMLTextToken HTMLTokenType = iota
+	HTMLStartTagToken
+	HTMLEndTagToken
+	HTMLSelfClosingTagToken
+	HTMLCommentToken
+	HTMLDoctypeToken
+)
+
+// HTMLAttr is a single attribute of an html element
+type HTMLAttr struct {
+	Key string
+	Val string
+}
+
+// HTMLToken is a single token of an html document, the raw source of the
+// token is retained so that the document can be rendered without modification.
+type HTMLToken struct {
+	Type  HTMLTokenType
+	Name  string
+	Attrs []HTMLAttr
+	Raw   string
+}
+
+// Attr returns the value of the attribute with the provided key
+func (t *HTMLToken) Attr(key string) (string, bool) {
+	for _, a := range t.Attrs {
+		if a.Key == strings.ToLower(key) {
+			return a.Val, true
+		}
+	}
+
+	return "", false
+}
+
+// HTMLRawTextElements are elements whose content is not tokenized
+var HTMLRawTextElements = map[string]bool{
+	"script": true, "style": true, "title": true, "textarea": true,
+}
+
+// HTMLVoidElements are elements that never have any content or end tag
+var HTMLVoidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// HTMLHeadElements are elements that belong to the head when the document does not declare one
+var HTMLHeadElements = map[string]bool{
+	"base": true, "link": true, "meta": true, "noscript": true, "script": true, "style": true, "title": true,
+}
+
+// HTMLTokenize splits the html source into its tokens
+func HTMLTokenize(src string) []HTMLToken {
+	tokens := make([]HTMLToken, 0)
+
+	for i := 0; i < len(src); {
+		if src[i] != '<' {
+			end := strings.IndexByte(src[i:], '<')
+			if end == -1 {
+				end = len(src) - i
+			}
+
+			// a "<" that does not open a tag is part of the text
+			if end == 0 {
+				end = 1
+			}
+
+			tokens = appendHTMLText(tokens, src[i:i+end])
+			i += end
+			continue
+		}
+
+		rest := src[i:]
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			end := strings.Index(rest[4:], "-->")
+			if end == -1 {
+				end = len(rest)
+			} else {
+				end += 7
+			}
+
+			tokens = append(tokens, HTMLToken{Type: HTMLCommentToken, Raw: rest[:end]})
+			i += end
+		case strings.HasPrefix(rest, "<!") || strings.HasPrefix(rest, "<?"):
+			end := strings.IndexByte(rest, '>')
+			if end == -1 {
+				end = len(rest) - 1
+			}
+
+			t := HTMLToken{Type: HTMLCommentToken, Raw: rest[:end+1]}
+			if strings.HasPrefix(strings.ToLower(rest), "<!doctype") {
+				t.Type = HTMLDoctypeToken
+			}
+
+			tokens = append(tokens, t)
+			i += end + 1
+		case strings.HasPrefix(rest, "</") && len(rest) > 2 && isHTMLNameStart(rest[2]):
+			end := strings.IndexByte(rest, '>')
+			if end == -1 {
+				end = len(rest) - 1
+			}
+
+			tokens = append(tokens, HTMLToken{Type: HTMLEndTagToken, Name: htmlTagName(rest[2:end]), Raw: rest[:end+1]})
+			i += end + 1
+		case len(rest) > 1 && isHTMLNameStart(rest[1]):
+			t, n := readHTMLStartTag(rest)
+			tokens = append(tokens, t)
+			i += n
+
+			// the content of raw text elements can contain "<", so it is read until the closing tag
+			if t.Type == HTMLStartTagToken && HTMLRawTextElements[t.Name] {
+				lower := strings.ToLower(src[i:])
+				end := strings.Index(lower, "</"+t.Name)
+				if end == -1 {
+					end = len(lower)
+				}
+
+				tokens = appendHTMLText(tokens, src[i:i+end])
+				i += end
+			}
+		default:
+			tokens = appendHTMLText(tokens, "<")
+			i++
+		}
+	}
+
+	return tokens
+}
+
+// appendHTMLText appends the text to the tokens, joining it with the previous text token
+func appendHTMLText(tokens []HTMLToken, text string) []HTMLToken {
+	if text == "" {
+		return tokens
+	}
+
+	if n := len(tokens); n > 0 && tokens[n-1].Type == HTMLTextToken {
+		tokens[n-1].Raw += text
+		return tokens
+	}
+
+	return append(tokens, HTMLToken{Type: HTMLTextToken, Raw: text})
+}
+
+func isHTMLNameStart(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// htmlTagName reads the lowercased tag name from the start of the string
+func htmlTagName(s string) string {
+	end := 0
+	for end < len(s) && !isHTMLSpace(s[end]) && s[end] != '/' && s[end] != '>' {
+		end++
+	}
+
+	return strings.ToLower(s[:end])
+}
+
+// readHTMLStartTag reads the start tag at the beginning of the string, returning the
+// token & the number of bytes that it consumed.
+func readHTMLStartTag(s string) (HTMLToken, int) {
+	t := HTMLToken{Type: HTMLStartTagToken, Name: htmlTagName(s[1:]), Attrs: []HTMLAttr{}}
+
+	i := 1 + len(t.Name)
+	for i < len(s) {
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+
+		if i >= len(s) {
+			break
+		}
+
+		if s[i] == '>' {
+			i++
+			break
+		}
+
+		if s[i] == '/' {
+			if i+1 < len(s) && s[i+1] == '>' {
+				t.Type = HTMLSelfClosingTagToken
+				i += 2
+				break
+			}
+
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '=' && s[i] != '>' && !(s[i] == '/' && i+1 < len(s) && s[i+1] == '>') {
+			i++
+		}
+		attr := HTMLAttr{Key: strings.ToLower(s[start:i])}
+
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+
+		if i < len(s) && s[i] == '=' {
+			i++
+			for i < len(s) && isHTMLSpace(s[i]) {
+				i++
+			}
+
+			if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+				quote := s[i]
+				end := strings.IndexByte(s[i+1:], quote)
+				if end == -1 {
+					end = len(s) - i - 1
+				}
+
+				attr.Val = s[i+1 : i+1+end]
+				i += end + 2
+			} else {
+				start := i
+				for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '>' {
+					i++
+				}
+
+				attr.Val = s[start:i]
+			}
+		}
+
+		if attr.Key != "" {
+			t.Attrs = append(t.Attrs, attr)
+		}
+	}
+
+	if i > len(s) {
+		i = len(s)
+	}
+
+	t.Raw = s[:i]
+	if HTMLVoidElements[t.Name] {
+		t.Type = HTMLSelfClosingTagToken
+	}
+
+	return t, i
+}
+
+// InsertPosition is a position of the document where content can be inserted
+type InsertPosition int
+
+const (
+	HeadStart InsertPosition = iota
+	HeadEnd
+	BodyStart
+	BodyEnd
+)
+
+// HTMLDocument is an html document split into its head & body, the attributes of the
+// html, head & body elements are kept so that they are retained when the document is rendered.
+type HTMLDocument struct {
+	Doctype   string
+	HTMLAttrs []HTMLAttr
+	HeadAttrs []HTMLAttr
+	BodyAttrs []HTMLAttr
+	Head      []HTMLToken
+	Body      []HTMLToken
+}
+
+// NewHTMLDocument creates an empty html document
+func NewHTMLDocument() *HTMLDocument {
+	return &HTMLDocument{
+		Doctype:   "<!doctype html>",
+		HTMLAttrs: []HTMLAttr{{Key: "lang", Val: "en"}},
+		HeadAttrs: []HTMLAttr{},
+		BodyAttrs: []HTMLAttr{},
+		Head:      []HTMLToken{},
+		Body:      []HTMLToken{},
+	}
+}
+
+// ParseHTML parses the html source into a document, content that is outside of an
+// explicit head or body is placed in the section that a browser would place it.
+func ParseHTML(src string) *HTMLDocument {
+	doc := NewHTMLDocument()
+	doc.HTMLAttrs = []HTMLAttr{}
+
+	const (
+		beforeHead = iota
+		inHead
+		afterHead
+		inBody
+	)
+
+	state := beforeHead
+	implicitHead := false
+	openHead := 0
+	hasLang := false
+
+	for _, t := range HTMLTokenize(src) {
+		isTag := t.Type == HTMLStartTagToken || t.Type == HTMLSelfClosingTagToken
+		blank := t.Type == HTMLTextToken && strings.TrimSpace(t.Raw) == ""
+
+		switch {
+		case t.Type == HTMLDoctypeToken:
+			doc.Doctype = t.Raw
+			continue
+		case isTag && t.Name == "html":
+			doc.HTMLAttrs = append([]HTMLAttr{}, t.Attrs...)
+			_, hasLang = t.Attr("lang")
+			continue
+		case isTag && t.Name == "head" && state < inBody:
+			doc.HeadAttrs = append([]HTMLAttr{}, t.Attrs...)
+			state = inHead
+			continue
+		case isTag && t.Name == "body":
+			doc.BodyAttrs = append([]HTMLAttr{}, t.Attrs...)
+			state = inBody
+			continue
+		case t.Type == HTMLEndTagToken && (t.Name == "html" || t.Name == "body"):
+			continue
+		case t.Type == HTMLEndTagToken && t.Name == "head":
+			if state < inBody {
+				state = afterHead
+			}
+			continue
+		}
+
+		switch state {
+		case beforeHead, afterHead:
+			if blank {
+				continue
+			}
+
+			if (isTag && HTMLHeadElements[t.Name]) || t.Type == HTMLCommentToken {
+				if state == beforeHead {
+					state = inHead
+					implicitHead = true
+				}
+
+				doc.Head = append(doc.Head, t)
+				if t.Type == HTMLStartTagToken {
+					openHead++
+				}
+				continue
+			}
+
+			state = inBody
+			doc.Body = append(doc.Body, t)
+		case inHead:
+			// a head that was not declared ends with the first element that does not belong to it
+			if implicitHead && openHead == 0 && !blank && t.Type != HTMLCommentToken && !(isTag && HTMLHeadElements[t.Name]) {
+				state = inBody
+				doc.Body = append(doc.Body, t)
+				continue
+			}
+
+			if implicitHead && t.Type == HTMLStartTagToken {
+				openHead++
+			}
+
+			if implicitHead && t.Type == HTMLEndTagToken && openHead > 0 {
+				openHead--
+			}
+
+			doc.Head = append(doc.Head, t)
+		default:
+			doc.Body = append(doc.Body, t)
+		}
+	}
+
+	if !hasLang {
+		doc.HTMLAttrs = append(doc.HTMLAttrs, HTMLAttr{Key: "lang", Val: "en"})
+	}
+
+	return doc
+}
+
+// Clone creates a copy of the document, so that content can be inserted without modifying the original
+func (d *HTMLDocument) Clone() *HTMLDocument {
+	return &HTMLDocument{
+		Doctype:   d.Doctype,
+		HTMLAttrs: append([]HTMLAttr{}, d.HTMLAttrs...),
+		HeadAttrs: append([]HTMLAttr{}, d.HeadAttrs...),
+		BodyAttrs: append([]HTMLAttr{}, d.BodyAttrs...),
+		Head:      append([]HTMLToken{}, d.Head...),
+		Body:      append([]HTMLToken{}, d.Body...),
+	}
+}
+
+// htmlContentTokens tokenizes the raw html content, so that inserted content can be matched like the rest of the document
+func htmlContentTokens(content []string) []HTMLToken {
+	tokens := make([]HTMLToken, 0, len(content))
+	for _, c := range content {
+		tokens = append(tokens, HTMLTokenize(c)...)
+	}
+
+	return tokens
+}
+
+// Insert inserts the raw html content at the position of the document, in the order that it was provided
+func (d *HTMLDocument) Insert(position InsertPosition, content ...string) {
+	tokens := htmlContentTokens(content)
+
+	switch position {
+	case HeadStart:
+		d.Head = append(tokens, d.Head...)
+	case HeadEnd:
+		d.Head = append(d.Head, tokens...)
+	case BodyStart:
+		d.Body = append(tokens, d.Body...)
+	case BodyEnd:
+		d.Body = append(d.Body, tokens...)
+	}
+}
+
+// InsertBefore inserts the raw html content before the first of the elements with the provided name,
+// an element can also be referred to by its id with "#" e.g "#root". returns false if the element
+// does not exist in the document.
+func (d *HTMLDocument) InsertBefore(element string, content ...string) bool {
+	for _, section := range []*[]HTMLToken{&d.Head, &d.Body} {
+		for i := range *section {
+			t := &(*section)[i]
+			if t.Type != HTMLStartTagToken && t.Type != HTMLSelfClosingTagToken {
+				continue
+			}
+
+			if strings.HasPrefix(element, "#") {
+				if id, ok := t.Attr("id"); !ok || id != element[1:] {
+					continue
+				}
+			} else if t.Name != strings.ToLower(element) {
+				continue
+			}
+
+			*section = append((*section)[:i], append(htmlContentTokens(content), (*section)[i:]...)...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// RemoveHeadElements removes each of the head elements that satisfy the match (including their content)
+func (d *HTMLDocument) RemoveHeadElements(match func(t *HTMLToken) bool) {
+	d.Head = removeHTMLElements(d.Head, match)
+}
+
+func removeHTMLElements(tokens []HTMLToken, match func(t *HTMLToken) bool) []HTMLToken {
+	out := make([]HTMLToken, 0, len(tokens))
+
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		if (t.Type != HTMLStartTagToken && t.Type != HTMLSelfClosingTagToken) || !match(&t) {
+			out = append(out, t)
+			continue
+		}
+
+		if t.Type == HTMLSelfClosingTagToken {
+			continue
+		}
+
+		// skip until the end tag of the element, accounting for nested elements of the same name
+		depth := 1
+		for i+1 < len(tokens) && depth > 0 {
+			i++
+			if tokens[i].Name != t.Name {
+				continue
+			}
+
+			switch tokens[i].Type {
+			case HTMLStartTagToken:
+				depth++
+			case HTMLEndTagToken:
+				depth--
+			}
+		}
+	}
+
+	return out
+}
+
+func renderHTMLAttrs(attrs []HTMLAttr) string {
+	s := strings.Builder{}
+
+	for _, a := range attrs {
+		switch {
+		case a.Val == "":
+			s.WriteString(fmt.Sprintf(" %s", a.Key))
+		case strings.Contains(a.Val, `"`):
+			s.WriteString(fmt.Sprintf(" %s='%s'", a.Key, a.Val))
+		default:
+			s.WriteString(fmt.Sprintf(` %s="%s"`, a.Key, a.Val))
+		}
+	}
+
+	return s.String()
+}
+
+func renderHTMLTokens(tokens []HTMLToken) string {
+	s := strings.Builder{}
+	for _, t := range tokens {
+		s.WriteString(t.Raw)
+	}
+
+	return s.String()
+}
+
+// HeadHTML renders the content of the head
+func (d *HTMLDocument) HeadHTML() string { return renderHTMLTokens(d.Head) }
+
+// BodyHTML renders the content of the body
+func (d *HTMLDocument) BodyHTML() string { return renderHTMLTokens(d.Body) }
+
+// RenderOpen renders the document up to the end of the body, this allows for content
+// to be streamed to the end of the body before the document is closed with RenderClose.
+func (d *HTMLDocument) RenderOpen() string {
+	return fmt.Sprintf("%s<html%s><head%s>%s</head><body%s>%s",
+		d.Doctype, renderHTMLAttrs(d.HTMLAttrs), renderHTMLAttrs(d.HeadAttrs), d.HeadHTML(), renderHTMLAttrs(d.BodyAttrs), d.BodyHTML())
+}
+
+// RenderClose renders the end of the document that was opened by RenderOpen
+func (d *HTMLDocument) RenderClose() string {
+	return "</body></html>"
+}
+
+// Render renders the document out to a single string
+func (d *HTMLDocument) Render() string {
+	return d.RenderOpen() + d.RenderClose()
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2021 Guy A. Ross
+// This source code is licensed under the GNU GPLv3 found in the
+// license file in the root directory of this source tree.
+
+package htmlparse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseHTML(t *testing.T) {
+	tt := []struct {
+		name      string
+		src       string
+		head      string
+		body      string
+		htmlAttrs string
+		bodyAttrs string
+	}{
+		{
+			name:      "attributes",
+			src:       `<!DOCTYPE html><html lang="fr" data-theme='dark'><head><title>T</title></head><body class="app" data-x=1><div id="root"></div></body></html>`,
+			head:      "<title>T</title>",
+			body:      `<div id="root"></div>`,
+			htmlAttrs: ` lang="fr" data-theme="dark"`,
+			bodyAttrs: ` class="app" data-x="1"`,
+		},
+		{
+			name:      "casing",
+			src:       `<HTML><HEAD><Title>T</Title></HEAD><BODY Class="app"><p>x</p></BODY></HTML>`,
+			head:      "<Title>T</Title>",
+			body:      "<p>x</p>",
+			htmlAttrs: ` lang="en"`,
+			bodyAttrs: ` class="app"`,
+		},
+		{
+			name:      "missing tags",
+			src:       `<meta charset="utf-8"><title>T</title><div>content</div>`,
+			head:      `<meta charset="utf-8"><title>T</title>`,
+			body:      "<div>content</div>",
+			htmlAttrs: ` lang="en"`,
+		},
+		{
+			name:      "raw text",
+			src:       `<head><script>if (a < b) { document.write("<body>") }</script></head><body><p>x</p></body>`,
+			head:      `<script>if (a < b) { document.write("<body>") }</script>`,
+			body:      "<p>x</p>",
+			htmlAttrs: ` lang="en"`,
+		},
+	}
+
+	for _, d := range tt {
+		t.Run(d.name, func(t *testing.T) {
+			doc := ParseHTML(d.src)
+
+			if doc.HeadHTML() != d.head {
+				t.Errorf("expected head '%s' got '%s'", d.head, doc.HeadHTML())
+			}
+
+			if doc.BodyHTML() != d.body {
+				t.Errorf("expected body '%s' got '%s'", d.body, doc.BodyHTML())
+			}
+
+			if got := renderHTMLAttrs(doc.HTMLAttrs); got != d.htmlAttrs {
+				t.Errorf("expected html attributes '%s' got '%s'", d.htmlAttrs, got)
+			}
+
+			if got := renderHTMLAttrs(doc.BodyAttrs); got != d.bodyAttrs {
+				t.Errorf("expected body attributes '%s' got '%s'", d.bodyAttrs, got)
+			}
+		})
+	}
+}
+
+func TestHTMLDocumentInsert(t *testing.T) {
+	doc := ParseHTML(`<html lang="de"><head><title>T</title></head><body class="app"><div id="root"></div><script src="/a.js"></script></body></html>`)
+
+	doc.Insert(HeadStart, "<meta charset=\"utf-8\">")
+	doc.Insert(HeadEnd, "<link rel=\"icon\">")
+	doc.Insert(BodyStart, "<header></header>")
+	doc.Insert(BodyEnd, "<footer></footer>")
+
+	if !doc.InsertBefore("script", "<div id=\"modal\"></div>") {
+		t.Error("expected insert before the script element")
+	}
+
+	if !doc.InsertBefore("#root", "<nav></nav>") {
+		t.Error("expected insert before the root element")
+	}
+
+	if doc.InsertBefore("#missing", "<nav></nav>") {
+		t.Error("expected insert before a missing element to fail")
+	}
+
+	expected := `<!doctype html><html lang="de"><head><meta charset="utf-8"><title>T</title><link rel="icon"></head>` +
+		`<body class="app"><header></header><nav></nav><div id="root"></div><div id="modal"></div><script src="/a.js"></script><footer></footer></body></html>`
+
+	if got := doc.Render(); got != expected {
+		t.Errorf("expected '%s' got '%s'", expected, got)
+	}
+
+	if doc.RenderOpen()+doc.RenderClose() != doc.Render() {
+		t.Error("expected the open & closed document to match the rendered document")
+	}
+}
+
+func TestHTMLDocumentRemoveHeadElements(t *testing.T) {
+	doc := ParseHTML(`<head><title>T</title><meta name="description" content="x"><meta name="viewport" content="y"></head><body><svg><title>Icon</title></svg></body>`)
+
+	doc.RemoveHeadElements(func(t *HTMLToken) bool {
+		name, _ := t.Attr("name")
+		return t.Name == "title" || name == "description"
+	})
+
+	if got := doc.HeadHTML(); got != `<meta name="viewport" content="y">` {
+		t.Errorf("expected matching head elements to be removed got '%s'", got)
+	}
+
+	if !strings.Contains(doc.BodyHTML(), "<title>Icon</title>") {
+		t.Errorf("expected body to not be modified got '%s'", doc.BodyHTML())
+	}
+}
+
+func TestHTMLDocumentClone(t *testing.T) {
+	doc := ParseHTML(`<head><title>T</title></head><body><p>x</p></body>`)
+	clone := doc.Clone()
+
+	clone.Insert(HeadEnd, "<meta>")
+	clone.Insert(BodyEnd, "<p>y</p>")
+
+	if doc.HeadHTML() != "<title>T</title>" || doc.BodyHTML() != "<p>x</p>" {
+		t.Error("expected the original document to not be modified")
+	}
+}
@@ -1,35 +1,29 @@
 package htmlparse
 
 import (
-	"fmt"
+	"embed"
+	"io/fs"
 	"io/ioutil"
 	"os"
-	"strings"
+
+	"github.com/GuyARoss/orbit/pkg/embedutils"
 )
 
-// innerHTML is a utility function that assists with the parsing the content of html tags
-// it does this by returning the subset of the two provided strings "subStart" & "subEnd"
-func innerHTML(str string, subStart string, subEnd string) string {
-	return strings.Split(strings.Join(strings.Split(str, subStart)[1:], ""), subEnd)[0]
-}
+//go:embed document.go
+var documentSource embed.FS
 
-// HTMLDoc represents a basic document model that will be rendered upon build request
-type HTMLDoc struct {
-	Head []string
-	Body []string
-}
+type documentFileReader struct{}
 
-// render renders the document out to a single string
-func (s *HTMLDoc) Render() string {
-	return fmt.Sprintf(`
-	<!doctype html>
-	<html lang="en">
-	<head>%s</head>
-	<body>%s</body>
-	</html>`, strings.Join(s.Head, ""), strings.Join(s.Body, ""))
+func (r *documentFileReader) Read() (fs.File, error) {
+	return documentSource.Open("document.go")
 }
 
-func (s *HTMLDoc) SaveToFile(filename string) error {
+// DocumentFile is the source of the html document model, this is written to the autogenerated
+// http file so that the same document model is used by both the build & the orbit server.
+var DocumentFile embedutils.FileReader = &documentFileReader{}
+
+// SaveToFile renders the document to the provided file
+func (s *HTMLDocument) SaveToFile(filename string) error {
 	file, err := os.Create(filename)
 	if err != nil {
 		return err
@@ -45,29 +39,14 @@ func (s *HTMLDoc) SaveToFile(filename string) error {
 	return err
 }
 
-func (s *HTMLDoc) Merge(doc *HTMLDoc) *HTMLDoc {
-	s.Body = append(doc.Body, s.Body...)
-	s.Head = append(doc.Head, s.Head...)
-
-	return s
-}
-
-func DocFromFile(path string) *HTMLDoc {
+// DocFromFile parses the html document of the provided path, an empty document
+// is returned if the file does not exist.
+func DocFromFile(path string) *HTMLDocument {
 	data, err := ioutil.ReadFile(path)
 
 	if len(data) == 0 || err != nil {
-		return &HTMLDoc{}
+		return NewHTMLDocument()
 	}
 
-	return &HTMLDoc{
-		Head: []string{innerHTML(string(data), "<head>", "</head>")},
-		Body: []string{innerHTML(string(data), "<body>", "</body>")},
-	}
-}
-
-func NewEmptyDoc() *HTMLDoc {
-	return &HTMLDoc{
-		Head: []string{},
-		Body: []string{},
-	}
+	return ParseHTML(string(data))
 }
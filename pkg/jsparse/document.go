@@ -31,6 +31,15 @@ type JSDocument interface {
 	Clone() JSDocument
 	// OrbitRoutes are http routes that are found within the source document
 	OrbitRoutePath() string
+	// OrbitHead are the tags of the document head that are declared within the source document
+	OrbitHead() []OrbitHeadTag
+	// OrbitIslands are the names of the imported components that are declared as interactive islands
+	OrbitIslands() []string
+	// OrbitFramework is the framework that is declared to render the document e.g "// orbit:framework preact"
+	OrbitFramework() string
+	// SourceImport is the default import of the source file of the document from the bundled pages,
+	// this allows the source file to be bundled as is e.g single-file components
+	SourceImport() *ImportDependency
 }
 
 // OrbitCommentToken are comment tokens that specifically initialize orbit internals
@@ -38,8 +47,31 @@ type OrbitCommentToken string
 
 const (
 	OrbitRouteToken OrbitCommentToken = "orbit:route"
+	OrbitTitleToken OrbitCommentToken = "orbit:title"
+	OrbitMetaToken  OrbitCommentToken = "orbit:meta"
+	OrbitLinkToken  OrbitCommentToken = "orbit:link"
+	// OrbitPropertyToken declares a meta tag by its property rather than its name, this is used by OpenGraph tags e.g "// orbit:property og:type website"
+	OrbitPropertyToken OrbitCommentToken = "orbit:property"
+	// OrbitIslandToken declares imported components of the page as islands e.g "// orbit:island Counter Chart"
+	OrbitIslandToken OrbitCommentToken = "orbit:island"
+	// OrbitFrameworkToken declares the framework that renders the page when its extension is shared
+	// by multiple frameworks e.g "// orbit:framework preact"
+	OrbitFrameworkToken OrbitCommentToken = "orbit:framework"
 )
 
+// OrbitHeadTag is a static tag of the document head declared with a comment token e.g
+//   - "// orbit:title Some Title"
+//   - "// orbit:meta description some description of the page"
+//   - "// orbit:property og:type website"
+//   - "// orbit:link canonical https://example.com/page"
+type OrbitHeadTag struct {
+	Token OrbitCommentToken
+	// Name is the name or property of the meta tag or the rel of the link, this is empty for titles
+	Name string
+	// Value is the title, the content of the meta tag or the href of the link
+	Value string
+}
+
 // JSToken is some tokens found in javascript used to tokenize js statements.
 type JSToken string
 
@@ -107,15 +139,42 @@ type DefaultJSDocument struct {
 	name          string
 	inDeadBlock   bool
 	orbitRoute    string
+	orbitHead     []OrbitHeadTag
+	orbitIslands  []string
+	framework     string
 }
 
 func (p *DefaultJSDocument) OrbitRoutePath() string { return p.orbitRoute }
 
+func (p *DefaultJSDocument) OrbitHead() []OrbitHeadTag { return p.orbitHead }
+
+func (p *DefaultJSDocument) OrbitIslands() []string { return p.orbitIslands }
+
+func (p *DefaultJSDocument) OrbitFramework() string { return p.framework }
+
+func (p *DefaultJSDocument) SourceImport() *ImportDependency {
+	return NewSourceImport(p.name, p.pageDir)
+}
+
+// NewSourceImport creates the default import of the source file (relative to the working directory)
+// from the directory of the bundled pages, which is the same directory that the imports of documents are resolved from.
+func NewSourceImport(name string, path string) *ImportDependency {
+	path = strings.TrimLeft(path, "./")
+
+	return &ImportDependency{
+		FinalStatement: fmt.Sprintf("import %s from '../../../%s'", name, path),
+		InitialPath:    path,
+		Type:           LocalImportType,
+	}
+}
+
+// Clone copies the document, the clone does not share the imports, other or serializable
+// entries with the document so that either can be added to without modifying the other.
 func (p *DefaultJSDocument) Clone() JSDocument {
 	return &DefaultJSDocument{
-		imports:       p.imports,
-		other:         p.other,
-		serializable:  p.serializable,
+		imports:       append([]*ImportDependency{}, p.imports...),
+		other:         append([]string{}, p.other...),
+		serializable:  append([]JSSerialize{}, p.serializable...),
 		webDir:        p.webDir,
 		pageDir:       p.pageDir,
 		extension:     p.extension,
@@ -123,6 +182,10 @@ func (p *DefaultJSDocument) Clone() JSDocument {
 		defaultExport: p.defaultExport,
 		name:          p.name,
 		inDeadBlock:   p.inDeadBlock,
+		orbitRoute:    p.orbitRoute,
+		orbitHead:     p.orbitHead,
+		orbitIslands:  p.orbitIslands,
+		framework:     p.framework,
 	}
 }
 
@@ -132,6 +195,20 @@ func (p *DefaultJSDocument) parseComment(line string, commentDelimitedLine []str
 	}
 
 	switch {
+	case strings.Contains(commentDelimitedLine[1], string(OrbitFrameworkToken)):
+		t := strings.Split(line, string(OrbitFrameworkToken))
+		if len(t) <= 1 {
+			return
+		}
+
+		p.framework = strings.TrimSpace(t[1])
+	case strings.Contains(commentDelimitedLine[1], string(OrbitIslandToken)):
+		t := strings.Split(line, string(OrbitIslandToken))
+		if len(t) <= 1 {
+			return
+		}
+
+		p.orbitIslands = append(p.orbitIslands, strings.Fields(t[1])...)
 	case strings.Contains(commentDelimitedLine[1], string(OrbitRouteToken)):
 		t := strings.Split(line, string(OrbitRouteToken))
 		if len(t) <= 1 {
@@ -139,6 +216,36 @@ func (p *DefaultJSDocument) parseComment(line string, commentDelimitedLine []str
 		}
 
 		p.orbitRoute = strings.TrimSpace(t[1])
+	case strings.Contains(commentDelimitedLine[1], string(OrbitTitleToken)):
+		t := strings.Split(line, string(OrbitTitleToken))
+		if len(t) <= 1 || strings.TrimSpace(t[1]) == "" {
+			return
+		}
+
+		p.orbitHead = append(p.orbitHead, OrbitHeadTag{Token: OrbitTitleToken, Value: strings.TrimSpace(t[1])})
+	case strings.Contains(commentDelimitedLine[1], string(OrbitMetaToken)),
+		strings.Contains(commentDelimitedLine[1], string(OrbitPropertyToken)),
+		strings.Contains(commentDelimitedLine[1], string(OrbitLinkToken)):
+		token := OrbitMetaToken
+		switch {
+		case strings.Contains(commentDelimitedLine[1], string(OrbitPropertyToken)):
+			token = OrbitPropertyToken
+		case strings.Contains(commentDelimitedLine[1], string(OrbitLinkToken)):
+			token = OrbitLinkToken
+		}
+
+		t := strings.Split(line, string(token))
+		if len(t) <= 1 {
+			return
+		}
+
+		// the first word is the name of the tag, the remainder of the line is its value
+		parts := strings.SplitN(strings.TrimSpace(t[1]), " ", 2)
+		if len(parts) < 2 {
+			return
+		}
+
+		p.orbitHead = append(p.orbitHead, OrbitHeadTag{Token: token, Name: parts[0], Value: strings.TrimSpace(parts[1])})
 	}
 }
 
@@ -239,6 +346,19 @@ func (p *DefaultJSDocument) tokenizeLine(ctx context.Context, pageDir string, li
 					p.defaultExport = p.scope[name]
 					p.name = name
 				}
+
+				// a function that is declared as the default export (e.g "export default function Page() {}") is kept
+				// without the export, as the page is exported by its web wrapper. anonymous functions are named after the page.
+				if decToken == FuncToken {
+					if name == "" {
+						_, err := p.parseInformalExportDefault(pageDir, line)
+						return ctx, err
+					}
+
+					p.defaultExport = p.scope[name]
+					p.name = name
+					p.AddOther(strings.Replace(line, fmt.Sprintf("%s ", ExportDefaultToken), "", 1))
+				}
 				return ctx, nil
 			}
 		}
@@ -280,12 +400,19 @@ func (p *DefaultJSDocument) parseInformalExportDefault(pageDir string, line stri
 
 	pageName := formatPathToPageName(pageDir)
 
+	// the args of a function that is exported as is (e.g "export default ({ title }: Props) => {}") are
+	// the args of the page, whereas the args of a call (e.g a higher order component) are not.
+	args := make(JSDocArgList, 0)
+	if strings.HasPrefix(exportData, string(FuncToken)) || strings.HasPrefix(exportData, "(") {
+		args, _ = parseArgs(exportData)
+	}
+
 	p.AddOther(fmt.Sprintf("const %s = %s", pageName, exportData))
 	p.scope[pageName] = &JsDocumentScope{
 		Name:      pageName,
 		Export:    ExportDefault,
 		TokenType: ConstToken,
-		Args:      make(JSDocArgList, 0),
+		Args:      args,
 	}
 
 	p.defaultExport = p.scope[pageName]
@@ -194,6 +194,14 @@ func TestTokenizeLine(t *testing.T) {
 			extension: "jsx",
 			other:     []string{"const SomethingEasy = () => (<> </>)"},
 		}, "something_easy", "SomethingEasy"},
+		{"export default function Thing({ from }) {", DefaultJSDocument{
+			extension: "jsx",
+			other:     []string{"function Thing({ from }) {"},
+		}, "", "Thing"},
+		{"export default function ({ from }) {", DefaultJSDocument{
+			extension: "jsx",
+			other:     []string{"const AnonThing = function ({ from }) {"},
+		}, "anon_thing", "AnonThing"},
 		{"const thing = `//cat", DefaultJSDocument{
 			extension: "jsx",
 			other:     []string{"const thing = `//cat"},
@@ -283,6 +291,22 @@ func TestDefaultJSDocumentClone(t *testing.T) {
 	}
 }
 
+func TestDefaultJSDocumentClone_Independent(t *testing.T) {
+	d := NewEmptyDocument()
+	// the entries are grown beyond their length, so that appending to a shared slice would overwrite
+	d.AddOther("first", "second", "third")
+
+	a := d.Clone()
+	b := d.Clone()
+
+	a.AddOther("from a")
+	b.AddOther("from b")
+
+	if a.Other()[3] != "from a" || b.Other()[3] != "from b" || len(d.Other()) != 3 {
+		t.Errorf("expected clones to not share entries got '%v' & '%v'", a.Other(), b.Other())
+	}
+}
+
 func TestJsDocSwitchSerialize(t *testing.T) {
 	d := NewSwitch("thing")
 	d.Add(JSString, "apple", "break;")
@@ -328,3 +352,71 @@ func TestParseComment(t *testing.T) {
 		t.Errorf("incorrect orbit route '%s'", doc.orbitRoute)
 	}
 }
+
+func TestParseComment_Islands(t *testing.T) {
+	lines := []string{
+		"// orbit:island Counter",
+		"// orbit:island Chart  Search",
+		"// orbit:island",
+	}
+
+	doc := &DefaultJSDocument{}
+	for _, line := range lines {
+		doc.parseComment(line, strings.Split(line, string(CommentToken)))
+	}
+
+	expected := []string{"Counter", "Chart", "Search"}
+	if strings.Join(doc.OrbitIslands(), ",") != strings.Join(expected, ",") {
+		t.Errorf("expected islands '%v' got '%v'", expected, doc.OrbitIslands())
+	}
+}
+
+func TestParseComment_Framework(t *testing.T) {
+	line := "// orbit:framework preact"
+
+	doc := &DefaultJSDocument{}
+	doc.parseComment(line, strings.Split(line, string(CommentToken)))
+
+	if doc.OrbitFramework() != "preact" {
+		t.Errorf("expected framework 'preact' got '%s'", doc.OrbitFramework())
+	}
+
+	if doc.Clone().OrbitFramework() != "preact" {
+		t.Errorf("expected framework to be cloned")
+	}
+}
+
+func TestParseComment_Head(t *testing.T) {
+	lines := []string{
+		"// orbit:title Some Title",
+		"// orbit:meta description some description of the page",
+		"// orbit:property og:type website",
+		"// orbit:meta twitter:card summary",
+		"// orbit:link canonical https://example.com/page",
+		"// orbit:meta invalid",
+	}
+
+	doc := &DefaultJSDocument{}
+	for _, line := range lines {
+		doc.parseComment(line, strings.Split(line, string(CommentToken)))
+	}
+
+	expected := []OrbitHeadTag{
+		{Token: OrbitTitleToken, Value: "Some Title"},
+		{Token: OrbitMetaToken, Name: "description", Value: "some description of the page"},
+		{Token: OrbitPropertyToken, Name: "og:type", Value: "website"},
+		{Token: OrbitMetaToken, Name: "twitter:card", Value: "summary"},
+		{Token: OrbitLinkToken, Name: "canonical", Value: "https://example.com/page"},
+	}
+
+	if len(doc.OrbitHead()) != len(expected) {
+		t.Errorf("expected %d head tags got %d", len(expected), len(doc.OrbitHead()))
+		return
+	}
+
+	for i, tag := range doc.OrbitHead() {
+		if tag != expected[i] {
+			t.Errorf("(%d) expected head tag '%v' got '%v'", i, expected[i], tag)
+		}
+	}
+}
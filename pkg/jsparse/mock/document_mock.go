@@ -4,16 +4,37 @@
 
 package mock
 
-import "github.com/GuyARoss/orbit/pkg/jsparse"
+import (
+	"fmt"
+
+	"github.com/GuyARoss/orbit/pkg/jsparse"
+)
 
 type MockJsDocument struct {
 	name          string
 	extension     string
 	defaultExport string
+	framework     string
 }
 
 func (m *MockJsDocument) OrbitRoutePath() string { return "" }
 
+func (m *MockJsDocument) OrbitHead() []jsparse.OrbitHeadTag { return nil }
+
+func (m *MockJsDocument) OrbitIslands() []string { return nil }
+
+func (m *MockJsDocument) OrbitFramework() string { return m.framework }
+
+// WithFramework sets the framework that is declared by the document
+func (m *MockJsDocument) WithFramework(framework string) *MockJsDocument {
+	m.framework = framework
+	return m
+}
+
+func (m *MockJsDocument) SourceImport() *jsparse.ImportDependency {
+	return jsparse.NewSourceImport(m.name, fmt.Sprintf("%s.%s", m.name, m.extension))
+}
+
 func (m *MockJsDocument) Clone() jsparse.JSDocument {
 	return nil
 }
@@ -9,6 +9,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 )
 
@@ -20,7 +21,7 @@ type JSParser interface {
 type JSFileParser struct{}
 
 func (p *JSFileParser) CanParse(path string) bool {
-	validExts := []string{"jsx", "js"}
+	validExts := []string{"jsx", "js", "tsx", "ts", vueExtension, svelteExtension}
 	ext := strings.Split(path, ".")
 
 	for _, e := range validExts {
@@ -48,14 +49,40 @@ func (p *JSFileParser) Parse(pageDir string, webDir string) (JSDocument, error)
 
 	page := NewDocument(webDir, pageDir)
 
+	// only the <script> block of a single-file component is javascript, the template & style blocks are skipped
+	sfc := page.extension == vueExtension || page.extension == svelteExtension
+	inScript := !sfc
+	hasProps := false
+
 	ctx := context.Background()
 	for scanner.Scan() {
-		ctx, err = page.tokenizeLine(ctx, pageDir, scanner.Text())
+		line := scanner.Text()
+
+		if sfc {
+			trimmed := strings.TrimSpace(line)
+			if !inScript {
+				inScript = strings.HasPrefix(trimmed, "<script")
+				continue
+			}
+
+			if strings.HasPrefix(trimmed, "</script>") {
+				inScript = false
+				continue
+			}
+
+			hasProps = hasProps || sfcPropsDeclaration.MatchString(line)
+		}
+
+		ctx, err = page.tokenizeLine(ctx, pageDir, line)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	if sfc {
+		page.sfcDefaultExport(hasProps)
+	}
+
 	if page.name == "" {
 		page.name = defaultPageName(pageDir)
 	}
@@ -63,6 +90,33 @@ func (p *JSFileParser) Parse(pageDir string, webDir string) (JSDocument, error)
 	return page, nil
 }
 
+const (
+	vueExtension    = "vue"
+	svelteExtension = "svelte"
+)
+
+// sfcPropsDeclaration matches the props declaration of a single-file component, either the "props" option or
+// "defineProps" macro of a vue component, or an exported variable (e.g "export let name") of a svelte component.
+var sfcPropsDeclaration = regexp.MustCompile(`^\s*props\s*:|defineProps\s*[(<]|^\s*export\s+let\s`)
+
+// sfcDefaultExport sets the default export of a single-file component, svelte components & vue <script setup> blocks
+// do not have a default export so the component is named after its file. the props of the component are its only argument.
+func (p *DefaultJSDocument) sfcDefaultExport(hasProps bool) {
+	if p.defaultExport == nil || p.defaultExport.Name == "" {
+		p.name = formatPathToPageName(p.pageDir)
+		p.defaultExport = &JsDocumentScope{
+			Name:      p.name,
+			Export:    ExportDefault,
+			TokenType: ConstToken,
+		}
+	}
+
+	p.defaultExport.Args = make(JSDocArgList, 0)
+	if hasProps {
+		p.defaultExport.Args = append(p.defaultExport.Args, "props")
+	}
+}
+
 // FunctionDefinition is a light-weight struct to define JS function definition
 type FunctionDefinition struct {
 	Content    string
@@ -5,6 +5,7 @@
 package jsparse
 
 import (
+	"os"
 	"testing"
 )
 
@@ -46,6 +47,8 @@ func TestCanParse(t *testing.T) {
 		{`thing.jsx`, true},
 		{"cat.css", false},
 		{"tose.js", true},
+		{"hello.vue", true},
+		{"hello.svelte", true},
 	}
 
 	j := &JSFileParser{}
@@ -57,3 +60,166 @@ func TestCanParse(t *testing.T) {
 		}
 	}
 }
+
+func TestParse_SingleFileComponent(t *testing.T) {
+	tt := []struct {
+		source     string
+		name       string
+		args       int
+		imports    int
+		sourceLine string
+	}{
+		{`<template>
+	<div>{{ from }}</div>
+</template>
+
+<script>
+import { ref } from 'vue'
+
+export default {
+	props: ['from'],
+}
+</script>
+
+<style>
+div { color: red; }
+</style>`, "Hello", 1, 1, "import Hello from '../../../pages/hello.vue'"},
+		{`<script setup>
+const count = 0
+</script>
+
+<template>
+	<div>{{ count }}</div>
+</template>`, "Hello", 0, 0, "import Hello from '../../../pages/hello.vue'"},
+	}
+
+	for _, d := range tt {
+		dir := t.TempDir()
+		current, _ := os.Getwd()
+		os.Chdir(dir)
+
+		os.Mkdir("pages", 0755)
+		os.WriteFile("pages/hello.vue", []byte(d.source), 0644)
+
+		page, err := (&JSFileParser{}).Parse("pages/hello.vue", "pages")
+		os.Chdir(current)
+
+		if err != nil {
+			t.Errorf("did not expect error '%s'", err)
+			continue
+		}
+
+		if page.Extension() != "vue" || page.Name() != d.name || page.DefaultExport().Name != d.name {
+			t.Errorf("expected component '%s' got '%s' (%s)", d.name, page.Name(), page.Extension())
+		}
+
+		if len(page.DefaultExport().Args) != d.args {
+			t.Errorf("expected %d args got %d", d.args, len(page.DefaultExport().Args))
+		}
+
+		if len(page.Imports()) != d.imports {
+			t.Errorf("expected %d imports got %d", d.imports, len(page.Imports()))
+		}
+
+		if page.SourceImport().FinalStatement != d.sourceLine {
+			t.Errorf("expected '%s' got '%s'", d.sourceLine, page.SourceImport().FinalStatement)
+		}
+	}
+}
+
+func TestParse_SvelteComponent(t *testing.T) {
+	dir := t.TempDir()
+	current, _ := os.Getwd()
+	os.Chdir(dir)
+
+	os.Mkdir("pages", 0755)
+	os.WriteFile("pages/counter.svelte", []byte(`<script>
+	export let start = 0
+	let count = start
+</script>
+
+<button on:click={() => count++}>{count}</button>`), 0644)
+
+	page, err := (&JSFileParser{}).Parse("pages/counter.svelte", "pages")
+	os.Chdir(current)
+
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	if page.Extension() != "svelte" || page.Name() != "Counter" || page.DefaultExport().Name != "Counter" {
+		t.Errorf("expected component 'Counter' got '%s' (%s)", page.Name(), page.Extension())
+	}
+
+	if len(page.DefaultExport().Args) != 1 {
+		t.Errorf("expected the exported variables to be the props of the component")
+	}
+
+	if page.SourceImport().FinalStatement != "import Counter from '../../../pages/counter.svelte'" {
+		t.Errorf("unexpected source import '%s'", page.SourceImport().FinalStatement)
+	}
+}
+
+func TestParse_TypeScriptPage(t *testing.T) {
+	tt := []struct {
+		source string
+		args   int
+	}{
+		{`import React from 'react'
+import { HomeProps } from './props'
+
+interface CountProps {
+	count: number
+}
+
+const Home = ({ title }: HomeProps & CountProps): JSX.Element => {
+	return <h1>{title}</h1>
+}
+
+export default Home`, 2},
+		{`import React from 'react'
+import { HomeProps } from './props'
+
+export default function Home<T>({ title }: HomeProps<T>) {
+	return <h1>{title}</h1>
+}`, 2},
+		{`import React from 'react'
+
+const Home: React.FC = () => <h1>static</h1>
+
+export default Home`, 0},
+	}
+
+	for i, d := range tt {
+		dir := t.TempDir()
+		current, _ := os.Getwd()
+		os.Chdir(dir)
+
+		os.Mkdir("pages", 0755)
+		os.WriteFile("pages/props.ts", []byte("export type HomeProps = { title: string }"), 0644)
+		os.WriteFile("pages/home.tsx", []byte(d.source), 0644)
+
+		page, err := (&JSFileParser{}).Parse("pages/home.tsx", "pages")
+		os.Chdir(current)
+
+		if err != nil {
+			t.Errorf("(%d) did not expect error '%s'", i, err)
+			continue
+		}
+
+		if page.Extension() != "tsx" || page.Name() != "Home" {
+			t.Errorf("(%d) expected tsx component 'Home' got '%s' (%s)", i, page.Name(), page.Extension())
+		}
+
+		if len(page.DefaultExport().Args) != d.args {
+			t.Errorf("(%d) expected %d args got '%v'", i, d.args, page.DefaultExport().Args)
+		}
+
+		for _, imp := range page.Imports() {
+			if imp.Type == LocalImportType && imp.InitialPath != "pages/props.ts" {
+				t.Errorf("(%d) expected the typescript import to be resolved got '%s'", i, imp.InitialPath)
+			}
+		}
+	}
+}
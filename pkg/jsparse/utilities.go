@@ -23,16 +23,21 @@ func pageExtension(importPath string) string {
 		return split[len(split)-1]
 	}
 
-	extension := "js"
 	// todo(issue/#11): context should be used here to pass in a "defaultExtension" type
 	// provided by the pages web wrapper method.
-	_, err := os.Stat(fmt.Sprintf("%s.js", importPath))
-	if err != nil {
-		extension = "jsx"
+	for _, extension := range resolvedExtensions {
+		if _, err := os.Stat(fmt.Sprintf("%s.%s", importPath, extension)); err == nil {
+			return extension
+		}
 	}
-	return extension
+
+	return "jsx"
 }
 
+// resolvedExtensions are the extensions that are resolved (in order) for import paths without an extension,
+// paths that do not resolve to any of these extensions are assumed to be jsx.
+var resolvedExtensions = []string{"js", "ts", "tsx"}
+
 var ErrFunctionExport = errors.New("function export cannot be the name of the default export")
 var ErrInvalidName = errors.New("variable name is invalid")
 
@@ -60,7 +65,17 @@ func parseArgs(line string) (JSDocArgList, error) {
 	vname := []rune{}
 	anonLen := 0
 	inAnonCtx := false
+
+	// type annotations of typescript args (e.g "{ items }: Props<T>") are skipped until the next arg,
+	// an annotation only starts outside of a destructured arg as that is where a ":" renames the property.
+	argDepth := 0
+	inType := false
+	typeDepth := 0
+	prev := ' '
 	for _, c := range line {
+		last := prev
+		prev = c
+
 		if !isInCtx && c == '(' {
 			isInCtx = true
 		}
@@ -69,6 +84,48 @@ func parseArgs(line string) (JSDocArgList, error) {
 			continue
 		}
 
+		if inType {
+			switch c {
+			case '<', '(', '[', '{':
+				typeDepth += 1
+				continue
+			case '>':
+				// the ">" of an arrow function type e.g "() => void" does not close a type argument
+				if last != '=' {
+					typeDepth -= 1
+				}
+				continue
+			case ')', ']', '}':
+				if typeDepth > 0 {
+					typeDepth -= 1
+					continue
+				}
+			}
+
+			if typeDepth > 0 || (c != ',' && c != ')' && c != '=') {
+				continue
+			}
+
+			inType = false
+		}
+
+		switch c {
+		case '{', '[':
+			argDepth += 1
+		case '}', ']':
+			argDepth -= 1
+		case ':':
+			if argDepth == 0 {
+				if len(vname) > 0 {
+					args = append(args, string(vname))
+					vname = []rune{}
+				}
+
+				inType = true
+				continue
+			}
+		}
+
 		if c == ')' {
 			isInCtx = false
 		}
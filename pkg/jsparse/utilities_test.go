@@ -6,6 +6,8 @@ package jsparse
 
 import (
 	"errors"
+	"fmt"
+	"os"
 	"strings"
 	"testing"
 )
@@ -83,6 +85,7 @@ func TestPageExtension(t *testing.T) {
 	}{
 		{"test", "jsx"},
 		{"test.jsx", "jsx"},
+		{"./home.tsx", "tsx"},
 	}
 
 	for i, d := range tt {
@@ -94,6 +97,27 @@ func TestPageExtension(t *testing.T) {
 	}
 }
 
+func TestPageExtension_Resolved(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(fmt.Sprintf("%s/props.ts", dir), []byte("export type Props = {}"), 0644)
+	os.WriteFile(fmt.Sprintf("%s/counter.tsx", dir), []byte("export default Counter"), 0644)
+
+	var tt = []struct {
+		i string
+		o string
+	}{
+		{fmt.Sprintf("%s/props", dir), "ts"},
+		{fmt.Sprintf("%s/counter", dir), "tsx"},
+		{fmt.Sprintf("%s/missing", dir), "jsx"},
+	}
+
+	for i, d := range tt {
+		if got := pageExtension(d.i); got != d.o {
+			t.Errorf("(%d) expected %s got %s", i, d.o, got)
+		}
+	}
+}
+
 func TestExtractJsTokenName(t *testing.T) {
 	var tt = []struct {
 		i string
@@ -139,6 +163,29 @@ func TestParseArgs(t *testing.T) {
 	}
 }
 
+func TestParseArgs_TypeAnnotations(t *testing.T) {
+	var tt = []struct {
+		ts string
+		js string
+	}{
+		{"const Home = ({ count }: HomeProps) => {", "const Home = ({ count }) => {"},
+		{"const Home: React.FC<Props> = ({ count, from }) => {", "const Home = ({ count, from }) => {"},
+		{"function List<T>({ items, render }: ListProps<T>): JSX.Element {", "function List({ items, render }) {"},
+		{"const Home = (props: { from: string, onClick: () => void }, ctx?: Context) => {", "const Home = (props, ctx) => {"},
+		{"const Home = ({ from: name }: Props, count: number = 5) => {", "const Home = ({ from: name }, count = 5) => {"},
+		{"const Home = (): JSX.Element => {", "const Home = () => {"},
+	}
+
+	for i, d := range tt {
+		got, _ := parseArgs(d.ts)
+		expected, _ := parseArgs(d.js)
+
+		if strings.Join(got, ",") != strings.Join(expected, ",") {
+			t.Errorf("(%d) expected args '%v' got '%v'", i, expected, got)
+		}
+	}
+}
+
 func TestLineImportType(t *testing.T) {
 	var tt = []struct {
 		i string
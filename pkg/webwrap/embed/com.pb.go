@@ -25,8 +25,9 @@ type RenderRequest struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	BundleID string `protobuf:"bytes,1,opt,name=BundleID,proto3" json:"BundleID,omitempty"`
-	JSONData string `protobuf:"bytes,2,opt,name=JSONData,proto3" json:"JSONData,omitempty"`
+	BundleID string          `protobuf:"bytes,1,opt,name=BundleID,proto3" json:"BundleID,omitempty"`
+	JSONData string          `protobuf:"bytes,2,opt,name=JSONData,proto3" json:"JSONData,omitempty"`
+	Request  *RequestContext `protobuf:"bytes,3,opt,name=Request,proto3" json:"Request,omitempty"`
 }
 
 func (x *RenderRequest) Reset() {
@@ -75,18 +76,115 @@ func (x *RenderRequest) GetJSONData() string {
 	return ""
 }
 
+func (x *RenderRequest) GetRequest() *RequestContext {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+type RequestContext struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	URL     string            `protobuf:"bytes,1,opt,name=URL,proto3" json:"URL,omitempty"`
+	Path    string            `protobuf:"bytes,2,opt,name=Path,proto3" json:"Path,omitempty"`
+	Method  string            `protobuf:"bytes,3,opt,name=Method,proto3" json:"Method,omitempty"`
+	Host    string            `protobuf:"bytes,4,opt,name=Host,proto3" json:"Host,omitempty"`
+	Locale  string            `protobuf:"bytes,5,opt,name=Locale,proto3" json:"Locale,omitempty"`
+	Headers map[string]string `protobuf:"bytes,6,rep,name=Headers,proto3" json:"Headers,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func (x *RequestContext) Reset() {
+	*x = RequestContext{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_src_com_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestContext) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestContext) ProtoMessage() {}
+
+func (x *RequestContext) ProtoReflect() protoreflect.Message {
+	mi := &file_src_com_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestContext.ProtoReflect.Descriptor instead.
+func (*RequestContext) Descriptor() ([]byte, []int) {
+	return file_src_com_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RequestContext) GetURL() string {
+	if x != nil {
+		return x.URL
+	}
+	return ""
+}
+
+func (x *RequestContext) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *RequestContext) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *RequestContext) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *RequestContext) GetLocale() string {
+	if x != nil {
+		return x.Locale
+	}
+	return ""
+}
+
+func (x *RequestContext) GetHeaders() map[string]string {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
 type RenderResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	StaticContent string `protobuf:"bytes,1,opt,name=StaticContent,proto3" json:"StaticContent,omitempty"`
+	StaticContent string   `protobuf:"bytes,1,opt,name=StaticContent,proto3" json:"StaticContent,omitempty"`
+	Head          []string `protobuf:"bytes,2,rep,name=Head,proto3" json:"Head,omitempty"`
+	Status        int32    `protobuf:"varint,3,opt,name=Status,proto3" json:"Status,omitempty"`
+	Redirect      string   `protobuf:"bytes,4,opt,name=Redirect,proto3" json:"Redirect,omitempty"`
 }
 
 func (x *RenderResponse) Reset() {
 	*x = RenderResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_src_com_proto_msgTypes[1]
+		mi := &file_src_com_proto_msgTypes[2]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -99,7 +197,7 @@ func (x *RenderResponse) String() string {
 func (*RenderResponse) ProtoMessage() {}
 
 func (x *RenderResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_src_com_proto_msgTypes[1]
+	mi := &file_src_com_proto_msgTypes[2]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -112,7 +210,7 @@ func (x *RenderResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RenderResponse.ProtoReflect.Descriptor instead.
 func (*RenderResponse) Descriptor() ([]byte, []int) {
-	return file_src_com_proto_rawDescGZIP(), []int{1}
+	return file_src_com_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *RenderResponse) GetStaticContent() string {
@@ -122,25 +220,179 @@ func (x *RenderResponse) GetStaticContent() string {
 	return ""
 }
 
+func (x *RenderResponse) GetHead() []string {
+	if x != nil {
+		return x.Head
+	}
+	return nil
+}
+
+func (x *RenderResponse) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *RenderResponse) GetRedirect() string {
+	if x != nil {
+		return x.Redirect
+	}
+	return ""
+}
+
+type RenderManyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Renders []*RenderRequest `protobuf:"bytes,1,rep,name=Renders,proto3" json:"Renders,omitempty"`
+}
+
+func (x *RenderManyRequest) Reset() {
+	*x = RenderManyRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_src_com_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RenderManyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenderManyRequest) ProtoMessage() {}
+
+func (x *RenderManyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_src_com_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenderManyRequest.ProtoReflect.Descriptor instead.
+func (*RenderManyRequest) Descriptor() ([]byte, []int) {
+	return file_src_com_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RenderManyRequest) GetRenders() []*RenderRequest {
+	if x != nil {
+		return x.Renders
+	}
+	return nil
+}
+
+type RenderManyResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Renders []*RenderResponse `protobuf:"bytes,1,rep,name=Renders,proto3" json:"Renders,omitempty"`
+}
+
+func (x *RenderManyResponse) Reset() {
+	*x = RenderManyResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_src_com_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RenderManyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenderManyResponse) ProtoMessage() {}
+
+func (x *RenderManyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_src_com_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenderManyResponse.ProtoReflect.Descriptor instead.
+func (*RenderManyResponse) Descriptor() ([]byte, []int) {
+	return file_src_com_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *RenderManyResponse) GetRenders() []*RenderResponse {
+	if x != nil {
+		return x.Renders
+	}
+	return nil
+}
+
 var File_src_com_proto protoreflect.FileDescriptor
 
 var file_src_com_proto_rawDesc = []byte{
 	0x0a, 0x0d, 0x73, 0x72, 0x63, 0x2f, 0x63, 0x6f, 0x6d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
-	0x04, 0x6d, 0x61, 0x69, 0x6e, 0x22, 0x47, 0x0a, 0x0d, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x52,
+	0x04, 0x6d, 0x61, 0x69, 0x6e, 0x22, 0x77, 0x0a, 0x0d, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x52,
 	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65,
 	0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65,
 	0x49, 0x44, 0x12, 0x1a, 0x0a, 0x08, 0x4a, 0x53, 0x4f, 0x4e, 0x44, 0x61, 0x74, 0x61, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x4a, 0x53, 0x4f, 0x4e, 0x44, 0x61, 0x74, 0x61, 0x22, 0x36,
-	0x0a, 0x0e, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
-	0x12, 0x24, 0x0a, 0x0d, 0x53, 0x74, 0x61, 0x74, 0x69, 0x63, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e,
-	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x53, 0x74, 0x61, 0x74, 0x69, 0x63, 0x43,
-	0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x32, 0x46, 0x0a, 0x0d, 0x52, 0x65, 0x61, 0x63, 0x74, 0x52,
-	0x65, 0x6e, 0x64, 0x65, 0x72, 0x65, 0x72, 0x12, 0x35, 0x0a, 0x06, 0x52, 0x65, 0x6e, 0x64, 0x65,
-	0x72, 0x12, 0x13, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x52, 0x65,
-	0x6e, 0x64, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x0c,
-	0x5a, 0x0a, 0x2e, 0x2f, 0x73, 0x72, 0x63, 0x3b, 0x6d, 0x61, 0x69, 0x6e, 0x62, 0x06, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x33,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x4a, 0x53, 0x4f, 0x4e, 0x44, 0x61, 0x74, 0x61, 0x12, 0x2e,
+	0x0a, 0x07, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x14, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x43, 0x6f,
+	0x6e, 0x74, 0x65, 0x78, 0x74, 0x52, 0x07, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0xf3,
+	0x01, 0x0a, 0x0e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x78,
+	0x74, 0x12, 0x10, 0x0a, 0x03, 0x55, 0x52, 0x4c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x55, 0x52, 0x4c, 0x12, 0x12, 0x0a, 0x04, 0x50, 0x61, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x50, 0x61, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x4d, 0x65, 0x74, 0x68, 0x6f,
+	0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x12,
+	0x12, 0x0a, 0x04, 0x48, 0x6f, 0x73, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x48,
+	0x6f, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x4c, 0x6f, 0x63, 0x61, 0x6c, 0x65, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x4c, 0x6f, 0x63, 0x61, 0x6c, 0x65, 0x12, 0x3b, 0x0a, 0x07, 0x48,
+	0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x6d,
+	0x61, 0x69, 0x6e, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x43, 0x6f, 0x6e, 0x74, 0x65,
+	0x78, 0x74, 0x2e, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52,
+	0x07, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x1a, 0x3a, 0x0a, 0x0c, 0x48, 0x65, 0x61, 0x64,
+	0x65, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x3a, 0x02, 0x38, 0x01, 0x22, 0x7e, 0x0a, 0x0e, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x24, 0x0a, 0x0d, 0x53, 0x74, 0x61, 0x74, 0x69, 0x63,
+	0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x53,
+	0x74, 0x61, 0x74, 0x69, 0x63, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04,
+	0x48, 0x65, 0x61, 0x64, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x48, 0x65, 0x61, 0x64,
+	0x12, 0x16, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x52, 0x65, 0x64, 0x69,
+	0x72, 0x65, 0x63, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x52, 0x65, 0x64, 0x69,
+	0x72, 0x65, 0x63, 0x74, 0x22, 0x42, 0x0a, 0x11, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x4d, 0x61,
+	0x6e, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2d, 0x0a, 0x07, 0x52, 0x65, 0x6e,
+	0x64, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x6d, 0x61, 0x69,
+	0x6e, 0x2e, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52,
+	0x07, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x73, 0x22, 0x44, 0x0a, 0x12, 0x52, 0x65, 0x6e, 0x64,
+	0x65, 0x72, 0x4d, 0x61, 0x6e, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2e,
+	0x0a, 0x07, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x14, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x07, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x73, 0x32, 0xc8,
+	0x01, 0x0a, 0x0d, 0x52, 0x65, 0x61, 0x63, 0x74, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x65, 0x72,
+	0x12, 0x35, 0x0a, 0x06, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x12, 0x13, 0x2e, 0x6d, 0x61, 0x69,
+	0x6e, 0x2e, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x14, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x41, 0x0a, 0x0a, 0x52, 0x65, 0x6e, 0x64, 0x65,
+	0x72, 0x4d, 0x61, 0x6e, 0x79, 0x12, 0x17, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x52, 0x65, 0x6e,
+	0x64, 0x65, 0x72, 0x4d, 0x61, 0x6e, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18,
+	0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x4d, 0x61, 0x6e, 0x79,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x3d, 0x0a, 0x0c, 0x52, 0x65,
+	0x6e, 0x64, 0x65, 0x72, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x13, 0x2e, 0x6d, 0x61, 0x69,
+	0x6e, 0x2e, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x14, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x30, 0x01, 0x42, 0x0c, 0x5a, 0x0a, 0x2e, 0x2f, 0x73,
+	0x72, 0x63, 0x3b, 0x6d, 0x61, 0x69, 0x6e, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -155,19 +407,31 @@ func file_src_com_proto_rawDescGZIP() []byte {
 	return file_src_com_proto_rawDescData
 }
 
-var file_src_com_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_src_com_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_src_com_proto_goTypes = []interface{}{
-	(*RenderRequest)(nil),  // 0: main.RenderRequest
-	(*RenderResponse)(nil), // 1: main.RenderResponse
+	(*RenderRequest)(nil),      // 0: main.RenderRequest
+	(*RequestContext)(nil),     // 1: main.RequestContext
+	(*RenderResponse)(nil),     // 2: main.RenderResponse
+	(*RenderManyRequest)(nil),  // 3: main.RenderManyRequest
+	(*RenderManyResponse)(nil), // 4: main.RenderManyResponse
+	nil,                        // 5: main.RequestContext.HeadersEntry
 }
 var file_src_com_proto_depIdxs = []int32{
-	0, // 0: main.ReactRenderer.Render:input_type -> main.RenderRequest
-	1, // 1: main.ReactRenderer.Render:output_type -> main.RenderResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	1, // 0: main.RenderRequest.Request:type_name -> main.RequestContext
+	5, // 1: main.RequestContext.Headers:type_name -> main.RequestContext.HeadersEntry
+	0, // 2: main.RenderManyRequest.Renders:type_name -> main.RenderRequest
+	2, // 3: main.RenderManyResponse.Renders:type_name -> main.RenderResponse
+	0, // 4: main.ReactRenderer.Render:input_type -> main.RenderRequest
+	3, // 5: main.ReactRenderer.RenderMany:input_type -> main.RenderManyRequest
+	0, // 6: main.ReactRenderer.RenderStream:input_type -> main.RenderRequest
+	2, // 7: main.ReactRenderer.Render:output_type -> main.RenderResponse
+	4, // 8: main.ReactRenderer.RenderMany:output_type -> main.RenderManyResponse
+	2, // 9: main.ReactRenderer.RenderStream:output_type -> main.RenderResponse
+	7, // [7:10] is the sub-list for method output_type
+	4, // [4:7] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_src_com_proto_init() }
@@ -189,6 +453,18 @@ func file_src_com_proto_init() {
 			}
 		}
 		file_src_com_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RequestContext); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_src_com_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*RenderResponse); i {
 			case 0:
 				return &v.state
@@ -200,6 +476,30 @@ func file_src_com_proto_init() {
 				return nil
 			}
 		}
+		file_src_com_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RenderManyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_src_com_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RenderManyResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -207,7 +507,7 @@ func file_src_com_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_src_com_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
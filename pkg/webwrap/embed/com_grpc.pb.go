@@ -19,6 +19,8 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ReactRendererClient interface {
 	Render(ctx context.Context, in *RenderRequest, opts ...grpc.CallOption) (*RenderResponse, error)
+	RenderMany(ctx context.Context, in *RenderManyRequest, opts ...grpc.CallOption) (*RenderManyResponse, error)
+	RenderStream(ctx context.Context, in *RenderRequest, opts ...grpc.CallOption) (ReactRenderer_RenderStreamClient, error)
 }
 
 type reactRendererClient struct {
@@ -38,11 +40,54 @@ func (c *reactRendererClient) Render(ctx context.Context, in *RenderRequest, opt
 	return out, nil
 }
 
+func (c *reactRendererClient) RenderMany(ctx context.Context, in *RenderManyRequest, opts ...grpc.CallOption) (*RenderManyResponse, error) {
+	out := new(RenderManyResponse)
+	err := c.cc.Invoke(ctx, "/main.ReactRenderer/RenderMany", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reactRendererClient) RenderStream(ctx context.Context, in *RenderRequest, opts ...grpc.CallOption) (ReactRenderer_RenderStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ReactRenderer_ServiceDesc.Streams[0], "/main.ReactRenderer/RenderStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &reactRendererRenderStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ReactRenderer_RenderStreamClient interface {
+	Recv() (*RenderResponse, error)
+	grpc.ClientStream
+}
+
+type reactRendererRenderStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *reactRendererRenderStreamClient) Recv() (*RenderResponse, error) {
+	m := new(RenderResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // ReactRendererServer is the server API for ReactRenderer service.
 // All implementations must embed UnimplementedReactRendererServer
 // for forward compatibility
 type ReactRendererServer interface {
 	Render(context.Context, *RenderRequest) (*RenderResponse, error)
+	RenderMany(context.Context, *RenderManyRequest) (*RenderManyResponse, error)
+	RenderStream(*RenderRequest, ReactRenderer_RenderStreamServer) error
 	mustEmbedUnimplementedReactRendererServer()
 }
 
@@ -53,6 +98,12 @@ type UnimplementedReactRendererServer struct {
 func (UnimplementedReactRendererServer) Render(context.Context, *RenderRequest) (*RenderResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Render not implemented")
 }
+func (UnimplementedReactRendererServer) RenderMany(context.Context, *RenderManyRequest) (*RenderManyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenderMany not implemented")
+}
+func (UnimplementedReactRendererServer) RenderStream(*RenderRequest, ReactRenderer_RenderStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method RenderStream not implemented")
+}
 func (UnimplementedReactRendererServer) mustEmbedUnimplementedReactRendererServer() {}
 
 // UnsafeReactRendererServer may be embedded to opt out of forward compatibility for this service.
@@ -84,6 +135,45 @@ func _ReactRenderer_Render_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ReactRenderer_RenderMany_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenderManyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReactRendererServer).RenderMany(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/main.ReactRenderer/RenderMany",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReactRendererServer).RenderMany(ctx, req.(*RenderManyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReactRenderer_RenderStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RenderRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ReactRendererServer).RenderStream(m, &reactRendererRenderStreamServer{stream})
+}
+
+type ReactRenderer_RenderStreamServer interface {
+	Send(*RenderResponse) error
+	grpc.ServerStream
+}
+
+type reactRendererRenderStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *reactRendererRenderStreamServer) Send(m *RenderResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // ReactRenderer_ServiceDesc is the grpc.ServiceDesc for ReactRenderer service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -95,7 +185,17 @@ var ReactRenderer_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Render",
 			Handler:    _ReactRenderer_Render_Handler,
 		},
+		{
+			MethodName: "RenderMany",
+			Handler:    _ReactRenderer_RenderMany_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "RenderStream",
+			Handler:       _ReactRenderer_RenderStream_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "src/com.proto",
 }
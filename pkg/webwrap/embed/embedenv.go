@@ -1,38 +1,59 @@
 package webwrap
 
 import (
-	"io/ioutil"
-	"strings"
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/GuyARoss/orbit/pkg/htmlparse"
 )
 
 // htmlDoc represents a basic document model that will be rendered upon build request
 type htmlDoc struct {
-	Head []string
-	Body []string
+	Head     []string
+	Body     []string
+	Status   int
+	Redirect string
+	// fallback denotes that the server side render of the page failed & that the page is rendered on the client,
+	// these fragments are not cached so that the page is server side rendered again by the next request.
+	fallback bool
 }
 
-func innerHTML(str string, start string, end string) string {
-	return strings.Split(strings.Join(strings.Split(str, start)[1:], ""), end)[0]
+type routeCtxKey string
+
+const renderRequestKey routeCtxKey = "renderRequest"
+
+func renderRequest(ctx context.Context) *http.Request {
+	r, _ := ctx.Value(renderRequestKey).(*http.Request)
+	return r
 }
 
-func DocFromFile(path string) *htmlDoc {
-	data, err := ioutil.ReadFile(path)
+var ForwardedRequestHeaders = []string{"Accept-Language", "User-Agent"}
 
-	if len(data) == 0 || err != nil {
-		return &htmlDoc{}
-	}
+const renderStreamKey routeCtxKey = "renderStream"
 
-	return &htmlDoc{
-		Head: []string{innerHTML(string(data), "<head>", "</head>")},
-		Body: []string{innerHTML(string(data), "<body>", "</body>")},
-	}
+func renderStream(ctx context.Context) io.Writer {
+	w, _ := ctx.Value(renderStreamKey).(io.Writer)
+	return w
+}
+
+// HTMLDocument is the document model that is shared with the autogenerated http file
+type HTMLDocument = htmlparse.HTMLDocument
+
+const (
+	HeadEnd = htmlparse.HeadEnd
+	BodyEnd = htmlparse.BodyEnd
+)
+
+func DocFromFile(path string) *HTMLDocument {
+	return htmlparse.DocFromFile(path)
 }
 
 func (s *htmlDoc) build(data []byte, page string) string {
 	return ""
 }
 
-func setupDoc() *htmlDoc { return &htmlDoc{} }
+func setupDoc() *HTMLDocument { return htmlparse.NewHTMLDocument() }
 
 var bundleDir string = ".orbit/dist"
 
@@ -40,28 +61,43 @@ var staticResourceMap map[PageRender]bool
 
 var pageDependencies map[PageRender][]string
 
+var bundleManifest = map[string]string{}
+
+func bundleFile(bundleKey string) string {
+	if hashed, ok := bundleManifest[bundleKey+".js"]; ok {
+		return hashed
+	}
+
+	return bundleKey + ".js"
+}
+
 var serverStartupTasks = []func(){}
 
 type PageRender string
 
 type DocumentRenderer struct {
-	fn      func(string, []byte, htmlDoc) htmlDoc
+	fn      func(context.Context, string, []byte, *htmlDoc) (*htmlDoc, error)
 	version string
 }
 
 func NewEmptyDocumentRenderer(version string) *DocumentRenderer {
 	return &DocumentRenderer{
 		version: version,
-		fn: func(s string, b []byte, hd htmlDoc) htmlDoc {
-			return hd
+		fn: func(ctx context.Context, s string, b []byte, hd *htmlDoc) (*htmlDoc, error) {
+			return hd, nil
 		},
 	}
 }
 
 var wrapDocRender = map[PageRender]*DocumentRenderer{}
 
-type HydrationCtxKey string
+// bareError is a render error that is responded to with only its status
+type bareError struct {
+	err error
+}
 
-const (
-	OrbitManifest HydrationCtxKey = "orbitManifest"
-)
+func (e *bareError) Error() string { return e.err.Error() }
+
+type batchRenderFunction func(ctx context.Context, pages []string, data [][]byte) ([]*htmlDoc, error)
+
+var batchRenderers = map[string]batchRenderFunction{}
@@ -0,0 +1,14 @@
+package webwrap
+
+import (
+	context "context"
+	"fmt"
+)
+
+func preactCSR(ctx context.Context, bundleKey string, data []byte, doc *htmlDoc) (*htmlDoc, error) {
+	// preact renders the page into the frame, so the frame must exist before the bundle is loaded
+	doc.Body = append(doc.Body, fmt.Sprintf(`<div id="%s_preact_frame"></div>`, bundleKey))
+	doc.Body = append(doc.Body, fmt.Sprintf(`<script class="orbit_bk" src="/p/%s"></script>`, bundleFile(bundleKey)))
+
+	return doc, nil
+}
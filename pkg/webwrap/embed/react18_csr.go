@@ -0,0 +1,10 @@
+package webwrap
+
+import (
+	context "context"
+)
+
+// react 18 pages are rendered to the same document as the legacy react pages, only the client bundle differs
+func react18CSR(ctx context.Context, bundleKey string, data []byte, doc *htmlDoc) (*htmlDoc, error) {
+	return reactCSR(ctx, bundleKey, data, doc)
+}
@@ -0,0 +1,93 @@
+package webwrap
+
+import (
+	context "context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// react 18 pages are server side rendered & batched the same as the legacy hydrated pages, the
+// server bundle streams the render of the page & the client bundle hydrates it with the root api.
+func init() {
+	batchRenderers["react18Hydrate"] = reactHydrateMany
+}
+
+func react18Hydrate(ctx context.Context, bundleKey string, data []byte, doc *htmlDoc) (*htmlDoc, error) {
+	if w := renderStream(ctx); w != nil {
+		return streamHydratePage(ctx, w, bundleKey, data, doc)
+	}
+
+	return reactHydrate(ctx, bundleKey, data, doc)
+}
+
+// streamHydratePage writes the server render of the page to the stream as each chunk of the page is rendered.
+// the frame of the page is written once the shell of the page has rendered, so that a page that fails to render
+// its shell can still fall back to being rendered on the client. the head of the document has already been written
+// by the time that the shell has rendered, so the head elements of the render are written with the shell & moved into
+// the head by the streamedHeadScript. the status & redirect of the render are returned with the fragment of the page.
+func streamHydratePage(ctx context.Context, w io.Writer, bundleKey string, data []byte, doc *htmlDoc) (*htmlDoc, error) {
+	framed := false
+	err := serverRenderStream(ctx, bundleKey, data, func(response *RenderResponse) error {
+		if !framed {
+			framed = true
+			doc.Status = int(response.Status)
+			doc.Redirect = response.Redirect
+
+			if _, err := io.WriteString(w, streamedHead(response.Head)+fmt.Sprintf(`<div id="%s_react_frame">`, bundleKey)); err != nil {
+				return err
+			}
+		}
+
+		_, err := io.WriteString(w, response.StaticContent)
+		return err
+	})
+
+	if err != nil && !framed {
+		if ferr := ssrFailure(ctx, bundleKey, err, true); ferr != nil {
+			return nil, ferr
+		}
+
+		return clientFragment(doc, bundleKey), nil
+	}
+
+	// part of the page has already been written, the root api renders the page on the client
+	// when the streamed page cannot be hydrated.
+	if err != nil && !errors.Is(ctx.Err(), context.Canceled) {
+		OnSSRFallback(PageRender(bundleKey), SSRFallbackCSR, err)
+	}
+
+	doc.Body = append(doc.Body, "</div>")
+	doc.Body = append(doc.Body, fmt.Sprintf(`<script class="orbit_bk" src="/p/%s"></script>`, bundleFile(bundleKey)))
+
+	return doc, nil
+}
+
+// streamedHeadScript moves the head elements of the preceding template into the head of the document, the elements
+// replace the matching elements of the head the same as the rendered fragments e.g a rendered <title>.
+const streamedHeadScript = `<script>(function (t) {
+	var key = function (el) {
+		if (el.tagName === "TITLE") return "title";
+		if (el.tagName === "META" && el.getAttribute("name")) return "meta name " + el.getAttribute("name");
+		if (el.tagName === "META" && el.getAttribute("property")) return "meta property " + el.getAttribute("property");
+		if (el.tagName === "LINK" && (el.getAttribute("rel") || "").toLowerCase() === "canonical") return "link canonical";
+		return "";
+	};
+	Array.from(t.content.children).forEach(function (el) {
+		var k = key(el);
+		if (k) Array.from(document.head.children).forEach(function (h) { if (key(h) === k) h.remove(); });
+		document.head.appendChild(el);
+	});
+	t.remove();
+	document.currentScript.remove();
+})(document.currentScript.previousElementSibling)</script>`
+
+// streamedHead creates the head elements of a streamed page, which are held by an inert template until they are moved
+func streamedHead(head []string) string {
+	if len(head) == 0 {
+		return ""
+	}
+
+	return `<template class="orbit_head">` + strings.Join(head, "") + `</template>` + streamedHeadScript
+}
@@ -3,60 +3,93 @@ package webwrap
 import (
 	context "context"
 	"fmt"
-
-	grpc "google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
-func serverRenderInnerHTML(bundleKey string, data []byte) string {
-	if nodeProcess == nil {
-		fmt.Println("react ssr process has not yet boot")
-		return ""
-	}
+// the hydrated pages of a request are server side rendered with a single call to the ssr renderer
+func init() {
+	batchRenderers["reactHydrate"] = reactHydrateMany
+}
 
-	opts := []grpc.DialOption{
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	}
+// ssrFragment adds the server rendered page to the document, a nil response denotes that the server side
+// render of the page failed & that it is to be rendered on the client.
+type ssrFragment func(doc *htmlDoc, bundleKey string, response *RenderResponse) *htmlDoc
 
-	conn, err := grpc.Dial("0.0.0.0:3024", opts...)
-	if err != nil {
-		return ""
+// hydrateFragment adds the server rendered page to the document along with the bundle that hydrates it
+func hydrateFragment(doc *htmlDoc, bundleKey string, response *RenderResponse) *htmlDoc {
+	if response == nil {
+		return clientFragment(doc, bundleKey)
 	}
 
-	defer conn.Close()
-	client := NewReactRendererClient(conn)
+	mergeRenderResponse(doc, response)
 
-	response, err := client.Render(context.TODO(), &RenderRequest{
-		BundleID: bundleKey,
-		JSONData: string(data),
-	})
+	// react requires the div id to exist before the necessary javascript is loaded in
+	doc.Body = append(doc.Body, fmt.Sprintf(`<div id="%s_react_frame">%s</div>`, bundleKey, response.StaticContent))
+	doc.Body = append(doc.Body, fmt.Sprintf(`<script class="orbit_bk" src="/p/%s"></script>`, bundleFile(bundleKey)))
+
+	return doc
+}
+
+// clientFragment adds the bundle of a page that failed to server side render, the frame is marked
+// so that the bundle renders the page rather than hydrating it. the fragment is not cached.
+func clientFragment(doc *htmlDoc, bundleKey string) *htmlDoc {
+	doc.fallback = true
+	doc.Body = append(doc.Body, fmt.Sprintf(`<div id="%s_react_frame" data-orbit-csr="true"></div>`, bundleKey))
+	doc.Body = append(doc.Body, fmt.Sprintf(`<script class="orbit_bk" src="/p/%s"></script>`, bundleFile(bundleKey)))
+
+	return doc
+}
 
+// hydratePage server side renders the page, clientRender is whether the client bundle of
+// the page renders the entire page & thus whether it can fall back to being rendered on the client.
+func hydratePage(ctx context.Context, bundleKey string, data []byte, doc *htmlDoc, fragment ssrFragment, clientRender bool) (*htmlDoc, error) {
+	response, err := serverRender(ctx, bundleKey, data)
 	if err != nil {
-		return ""
+		if ferr := ssrFailure(ctx, bundleKey, err, clientRender); ferr != nil {
+			return nil, ferr
+		}
 	}
 
-	return response.StaticContent
+	return fragment(doc, bundleKey, response), nil
 }
 
-func reactHydrate(ctx context.Context, bundleKey string, data []byte, doc *htmlDoc) (*htmlDoc, context.Context) {
-	innerServerHTML := serverRenderInnerHTML(bundleKey, data)
+// hydrateMany server side renders each of the pages with a single call, when the render fails
+// the fallback policy of each page is applied & the first of the resulting errors is returned.
+func hydrateMany(ctx context.Context, bundleKeys []string, data [][]byte, fragment ssrFragment, clientRender bool) ([]*htmlDoc, error) {
+	docs := make([]*htmlDoc, len(bundleKeys))
 
-	if v := ctx.Value(OrbitManifest); v == nil {
-		doc.Head = append(doc.Head, fmt.Sprintf(`<script id="orbit_manifest" type="application/json">%s</script>`, data))
-		ctx = context.WithValue(ctx, OrbitManifest, true)
-	}
+	responses, err := serverRenderMany(ctx, bundleKeys, data)
+	if err != nil {
+		var failure error
+		for i, key := range bundleKeys {
+			if ferr := ssrFailure(ctx, key, err, clientRender); ferr != nil {
+				if failure == nil {
+					failure = ferr
+				}
+
+				continue
+			}
 
-	doc.Body = append(doc.Body, fmt.Sprintf(`<script class="orbit_bk" src="/p/%s.js"></script>`, bundleKey))
-	copy := doc.Body
+			docs[i] = fragment(&htmlDoc{Head: []string{}, Body: []string{}}, key, nil)
+		}
 
-	// the doc body is adjusted +1 indices to insert the react frame at the front of the list
-	// this is due to react requiring the div id to exist before the necessary javascript is loaded in
-	doc.Body = make([]string, len(doc.Body)+1)
-	doc.Body[0] = fmt.Sprintf(`<div id="%s_react_frame">%s</div>`, bundleKey, innerServerHTML)
+		if failure != nil {
+			return nil, failure
+		}
+
+		return docs, nil
+	}
 
-	for i, c := range copy {
-		doc.Body[i+1] = c
+	for i, key := range bundleKeys {
+		docs[i] = fragment(&htmlDoc{Head: []string{}, Body: []string{}}, key, responses[i])
 	}
 
-	return doc, ctx
+	return docs, nil
+}
+
+func reactHydrate(ctx context.Context, bundleKey string, data []byte, doc *htmlDoc) (*htmlDoc, error) {
+	return hydratePage(ctx, bundleKey, data, doc, hydrateFragment, true)
+}
+
+func reactHydrateMany(ctx context.Context, bundleKeys []string, data [][]byte) ([]*htmlDoc, error) {
+	return hydrateMany(ctx, bundleKeys, data, hydrateFragment, true)
 }
@@ -0,0 +1,20 @@
+package webwrap
+
+import (
+	context "context"
+)
+
+// islands pages are server side rendered & batched the same as hydrated pages, only the client
+// bundle of the page differs as it hydrates the islands of the page rather than the entire page. for
+// the same reason, islands pages cannot fall back to being rendered on the client.
+func init() {
+	batchRenderers["reactIslands"] = reactIslandsMany
+}
+
+func reactIslands(ctx context.Context, bundleKey string, data []byte, doc *htmlDoc) (*htmlDoc, error) {
+	return hydratePage(ctx, bundleKey, data, doc, hydrateFragment, false)
+}
+
+func reactIslandsMany(ctx context.Context, bundleKeys []string, data [][]byte) ([]*htmlDoc, error) {
+	return hydrateMany(ctx, bundleKeys, data, hydrateFragment, false)
+}
@@ -1,38 +1,135 @@
 package webwrap
 
 import (
-	"bufio"
 	context "context"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"net/http"
 	"os"
-	"os/exec"
 	"strings"
-	"syscall"
+	"sync"
+	"time"
+)
+
+var ssrRenderer SSRRenderer
+
+// SSRBackend is the renderer used to server side render pages, either NodeSSRBackend or EmbeddedSSRBackend
+var SSRBackend = NodeSSRBackend
+
+// SSRWorkers is the number of node renderer processes (or embedded vms) that are run to server side render pages
+var SSRWorkers = 2
+
+// SSRAddress is the address that the node renderer processes listen on, either a tcp address
+// e.g "127.0.0.1:3024" or a unix socket e.g "unix:/tmp/orbit-ssr.sock"
+var SSRAddress = DefaultSSRAddress
+
+// SSRRenderTimeout is the deadline of a server side render when the request does not already have one
+var SSRRenderTimeout = 10 * time.Second
 
-	grpc "google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+var ErrSSRProcessNotStarted = errors.New("react ssr process has not been started")
+var ErrSSRRenderCount = errors.New("ssr renderer did not return a response for each of the pages")
+var ErrSSREmptyResponse = errors.New("ssr renderer did not return a response")
+
+// SSRFallbackPolicy is how a page is served when its server side render fails or times out
+type SSRFallbackPolicy int
+
+const (
+	// SSRFallbackCSR serves the page to be rendered on the client with the same props manifest & bundle,
+	// pages that do not have a client bundle that renders the entire page fall back to SSRFallbackErrorPage
+	SSRFallbackCSR SSRFallbackPolicy = iota
+	// SSRFallbackErrorPage fails the render of the page, so that the error page of the request is served
+	SSRFallbackErrorPage
+	// SSRFallbackFail fails the render of the page with only the status, the error page is not served
+	SSRFallbackFail
 )
 
-var nodeProcess *os.Process
+func (p SSRFallbackPolicy) String() string {
+	switch p {
+	case SSRFallbackCSR:
+		return "csr"
+	case SSRFallbackErrorPage:
+		return "error page"
+	case SSRFallbackFail:
+		return "fail"
+	}
+
+	return fmt.Sprintf("SSRFallbackPolicy(%d)", int(p))
+}
+
+// SSRFallback is the fallback policy of the pages that have not been given their own policy with SetSSRFallback
+var SSRFallback = SSRFallbackCSR
+
+var (
+	// ssrFallbackMu guards the policies of the pages, which are read by each of the failed renders of the requests
+	ssrFallbackMu    sync.RWMutex
+	ssrFallbackPages = map[PageRender]SSRFallbackPolicy{}
+)
+
+// SetSSRFallback sets the policy that is applied when the server side render of the page fails
+func SetSSRFallback(page PageRender, policy SSRFallbackPolicy) {
+	ssrFallbackMu.Lock()
+	defer ssrFallbackMu.Unlock()
+
+	ssrFallbackPages[page] = policy
+}
+
+// OnSSRFallback is called with the applied policy each time that the server side render of a page fails,
+// by default the failure is logged. this can be replaced to e.g record the failure as a metric.
+var OnSSRFallback = func(page PageRender, policy SSRFallbackPolicy, err error) {
+	log.Printf("server side render of page '%s' failed, applying the '%s' fallback policy => %s", page, policy, err)
+}
+
+// ssrFailure applies the fallback policy of the page to its failed server side render & returns the error that the
+// render fails with, where nil denotes that the page is to be rendered on the client. clientRender is whether the
+// client bundle of the page renders the entire page. renders that were canceled by the request are not retried.
+func ssrFailure(ctx context.Context, bundleKey string, err error, clientRender bool) error {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return err
+	}
+
+	page := PageRender(bundleKey)
 
+	ssrFallbackMu.RLock()
+	policy, ok := ssrFallbackPages[page]
+	ssrFallbackMu.RUnlock()
+
+	if !ok {
+		policy = SSRFallback
+	}
+
+	if policy == SSRFallbackCSR && !clientRender {
+		policy = SSRFallbackErrorPage
+	}
+
+	OnSSRFallback(page, policy, err)
+
+	switch policy {
+	case SSRFallbackCSR:
+		return nil
+	case SSRFallbackFail:
+		return &bareError{err: err}
+	}
+
+	return err
+}
+
+// Close drains the in-flight renders & stops the ssr renderer
 func Close() error {
-	// this is a hack, node process does not get terminated with the nodeProcess.Kill
-	// method, but I found that if I use ctrl^c in the terminal, it closes it correctly
-	if nodeProcess == nil {
+	if ssrRenderer == nil {
 		return nil
 	}
 
-	err := syscall.Kill(nodeProcess.Pid, syscall.SIGSTOP)
-	nodeProcess = nil
+	err := ssrRenderer.Close()
+	ssrRenderer = nil
 
 	return err
 }
 
 // TODO: phase out the init stuff, prefer this to be autogenerated.
 func init() {
-	serverStartupTasks = append(serverStartupTasks, StartupTaskReactSSR(bundleDir, wrapDocRender, staticResourceMap, make(map[PageRender]string), *setupDoc()))
+	serverStartupTasks = append(serverStartupTasks, StartupTaskReactSSR(bundleDir, wrapDocRender, staticResourceMap, make(map[PageRender]string), setupDoc()))
 }
 
 func StartupTaskReactSSR(
@@ -40,10 +137,10 @@ func StartupTaskReactSSR(
 	pages map[PageRender]*DocumentRenderer,
 	staticMap map[PageRender]bool,
 	nameMap map[PageRender]string,
-	doc htmlDoc,
+	doc *HTMLDocument,
 ) func() {
 	return func() {
-		err := startNodeServer()
+		err := startSSRRenderer()
 		if err != nil {
 			panic(err)
 		}
@@ -53,7 +150,11 @@ func StartupTaskReactSSR(
 				continue
 			}
 
-			sr, _ := reactSSR(context.Background(), string(renderKey), []byte("{}"), doc)
+			sr, err := reactSSR(context.Background(), string(renderKey), []byte("{}"), &htmlDoc{Head: []string{}, Body: []string{}})
+			if err != nil {
+				fmt.Printf("error rendering static resource for bundle %s => %s\n", renderKey, err)
+				continue
+			}
 
 			pathName := string(renderKey)
 			if nameMap[renderKey] != "" {
@@ -61,10 +162,14 @@ func StartupTaskReactSSR(
 			}
 
 			path := fmt.Sprintf("%s%c%s", http.Dir(outDir), os.PathSeparator, pathName)
-			body := append(pageDependencies[renderKey], sr.Body...)
 
-			so := fmt.Sprintf(`<!doctype html><head>%s</head><body>%s</body></html>`, strings.Join(sr.Head, ""), strings.Join(body, ""))
-			err := ioutil.WriteFile(path, []byte(so), 0644)
+			// the rendered page is placed at the end of the base document, so that its attributes are retained
+			page := doc.Clone()
+			page.Insert(HeadEnd, sr.Head...)
+			page.Insert(BodyEnd, pageDependencies[renderKey]...)
+			page.Insert(BodyEnd, sr.Body...)
+
+			err = ioutil.WriteFile(path, []byte(page.Render()), 0644)
 			if err != nil {
 				fmt.Printf("error creating static resource for bundle %s => %s\n", renderKey, err)
 				continue
@@ -73,86 +178,172 @@ func StartupTaskReactSSR(
 	}
 }
 
-func startNodeServer() error {
-	if nodeProcess != nil {
-		// TODO: already started
+func startSSRRenderer() error {
+	if ssrRenderer != nil {
 		return nil
 	}
 
-	// TODO(stability) verify that babel node & grpc are both installed.
-	// TODO(swc): replace babel node for swc
-	cmd := exec.Command("./node_modules/.bin/babel-node", ".orbit/base/pages/react_ssr.js", "--presets", "@babel/react,@babel/preset-env")
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return err
+	if SSRBackend == EmbeddedSSRBackend {
+		pool, err := NewVMPool(&VMPoolOpts{
+			VMs:           SSRWorkers,
+			RenderTimeout: SSRRenderTimeout,
+		})
+		if err != nil {
+			return err
+		}
+
+		ssrRenderer = pool
+		return nil
 	}
 
-	err = cmd.Start()
-	if err != nil {
+	// TODO(stability) verify that node is installed.
+	pool := NewSSRPool(&SSRPoolOpts{
+		Workers:       SSRWorkers,
+		BaseAddress:   SSRAddress,
+		RenderTimeout: SSRRenderTimeout,
+	})
+	if err := pool.Start(); err != nil {
 		return err
 	}
 
-	nodeProcess = cmd.Process
-	booted := make(chan bool)
-
-	go func() {
-		scanner := bufio.NewScanner(stdout)
-		for scanner.Scan() {
-			line := scanner.Text()
+	ssrRenderer = pool
+	return nil
+}
 
-			if strings.Contains(line, "boot success") {
-				booted <- true
-			}
+// ssrRequestContext creates the request context of the render from the http request that the page is rendered for,
+// pages that are rendered outside of a request (e.g static pages) do not have a request context.
+// only the ForwardedRequestHeaders of the request are provided, as the renders of a page can be cached.
+func ssrRequestContext(ctx context.Context) *RequestContext {
+	r := renderRequest(ctx)
+	if r == nil {
+		return nil
+	}
 
-			if strings.Contains(line, "boot fail") {
-				booted <- false
-			}
+	headers := make(map[string]string, len(ForwardedRequestHeaders))
+	for _, header := range ForwardedRequestHeaders {
+		if values := r.Header.Values(header); len(values) > 0 {
+			headers[http.CanonicalHeaderKey(header)] = strings.Join(values, ", ")
 		}
-	}()
+	}
 
-	go func() {
-		_, err := nodeProcess.Wait()
+	return &RequestContext{
+		URL:     r.URL.RequestURI(),
+		Path:    r.URL.Path,
+		Method:  r.Method,
+		Host:    r.Host,
+		Locale:  requestLocale(r),
+		Headers: headers,
+	}
+}
 
-		if err != nil {
-			panic(err)
+// requestLocale finds the preferred locale of the request from the "Accept-Language" header e.g "en-US"
+func requestLocale(r *http.Request) string {
+	for _, lang := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		locale := strings.TrimSpace(strings.Split(lang, ";")[0])
+		if locale != "" && locale != "*" {
+			return locale
 		}
-	}()
+	}
+
+	return ""
+}
+
+// serverRender renders the bundle with the ssr renderer, the request context is provided to the page
+func serverRender(ctx context.Context, bundleKey string, data []byte) (*RenderResponse, error) {
+	if ssrRenderer == nil {
+		return nil, ErrSSRProcessNotStarted
+	}
 
+	response, err := ssrRenderer.Render(ctx, &RenderRequest{
+		BundleID: bundleKey,
+		JSONData: string(data),
+		Request:  ssrRequestContext(ctx),
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	<-booted
-	return nil
+	if response == nil {
+		return nil, ErrSSREmptyResponse
+	}
+
+	return response, nil
 }
 
-func reactSSR(ctx context.Context, bundleKey string, data []byte, doc htmlDoc) (htmlDoc, context.Context) {
-	if nodeProcess == nil {
-		fmt.Println("react ssr process has not yet boot")
-		return doc, ctx
+// serverRenderMany renders each of the bundles with a single call to the ssr renderer, each bundle
+// is rendered with the props at the same index. the responses are returned in the order of the bundle keys.
+func serverRenderMany(ctx context.Context, bundleKeys []string, data [][]byte) ([]*RenderResponse, error) {
+	if ssrRenderer == nil {
+		return nil, ErrSSRProcessNotStarted
 	}
 
-	opts := []grpc.DialOption{
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	request := ssrRequestContext(ctx)
+	renders := make([]*RenderRequest, len(bundleKeys))
+	for i, key := range bundleKeys {
+		renders[i] = &RenderRequest{
+			BundleID: key,
+			JSONData: string(data[i]),
+			Request:  request,
+		}
 	}
 
-	conn, err := grpc.Dial("0.0.0.0:3024", opts...)
+	response, err := ssrRenderer.RenderMany(ctx, &RenderManyRequest{Renders: renders})
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	if response == nil {
+		return nil, ErrSSREmptyResponse
+	}
+
+	if len(response.Renders) != len(bundleKeys) {
+		return nil, ErrSSRRenderCount
+	}
+
+	for _, render := range response.Renders {
+		if render == nil {
+			return nil, ErrSSREmptyResponse
+		}
 	}
-	defer conn.Close()
-	client := NewReactRendererClient(conn)
 
-	response, err := client.Render(ctx, &RenderRequest{
+	return response.Renders, nil
+}
+
+// serverRenderStream renders the bundle with the ssr renderer & sends each response of the render as it is streamed
+func serverRenderStream(ctx context.Context, bundleKey string, data []byte, send func(*RenderResponse) error) error {
+	if ssrRenderer == nil {
+		return ErrSSRProcessNotStarted
+	}
+
+	return ssrRenderer.RenderStream(ctx, &RenderRequest{
 		BundleID: bundleKey,
 		JSONData: string(data),
-	})
+		Request:  ssrRequestContext(ctx),
+	}, send)
+}
+
+// mergeRenderResponse adds the head elements, status & redirect of the render to the document
+func mergeRenderResponse(doc *htmlDoc, response *RenderResponse) {
+	doc.Head = append(doc.Head, response.Head...)
 
+	if response.Status != 0 {
+		doc.Status = int(response.Status)
+	}
+
+	if response.Redirect != "" {
+		doc.Redirect = response.Redirect
+	}
+}
+
+// reactSSR renders the page only on the server, as there is no client bundle the page cannot fall back to being rendered on the client
+func reactSSR(ctx context.Context, bundleKey string, data []byte, doc *htmlDoc) (*htmlDoc, error) {
+	response, err := serverRender(ctx, bundleKey, data)
 	if err != nil {
-		// TODO: return error & body
-		doc.Body = append(doc.Body, "<div>error loading page part of the page</div>")
+		return nil, ssrFailure(ctx, bundleKey, err, false)
 	}
 
+	mergeRenderResponse(doc, response)
 	doc.Body = append(doc.Body, response.StaticContent)
-	return doc, ctx
+
+	return doc, nil
 }
@@ -0,0 +1,427 @@
+package webwrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recordingRenderer struct {
+	req      *RenderRequest
+	response *RenderResponse
+	// chunks are the chunks of the static content that are streamed after the response
+	chunks []string
+}
+
+func (r *recordingRenderer) Render(ctx context.Context, req *RenderRequest) (*RenderResponse, error) {
+	r.req = req
+	return r.response, nil
+}
+
+func (r *recordingRenderer) RenderMany(ctx context.Context, req *RenderManyRequest) (*RenderManyResponse, error) {
+	res := &RenderManyResponse{}
+	for _, render := range req.Renders {
+		r.req = render
+		res.Renders = append(res.Renders, &RenderResponse{StaticContent: fmt.Sprintf("%s:%s", render.BundleID, render.JSONData)})
+	}
+
+	return res, nil
+}
+
+func (r *recordingRenderer) RenderStream(ctx context.Context, req *RenderRequest, send func(*RenderResponse) error) error {
+	r.req = req
+	if err := send(r.response); err != nil {
+		return err
+	}
+
+	for _, chunk := range r.chunks {
+		if err := send(&RenderResponse{StaticContent: chunk}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *recordingRenderer) Close() error { return nil }
+
+func useRenderer(t *testing.T, renderer SSRRenderer) {
+	current := ssrRenderer
+	ssrRenderer = renderer
+
+	t.Cleanup(func() {
+		ssrRenderer = current
+	})
+}
+
+func TestRequestLocale(t *testing.T) {
+	tt := []struct {
+		header   string
+		expected string
+	}{
+		{"fr-CH, fr;q=0.9, en;q=0.8", "fr-CH"},
+		{"en-US;q=0.8", "en-US"},
+		{"*", ""},
+		{"", ""},
+	}
+
+	for _, d := range tt {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept-Language", d.header)
+
+		if got := requestLocale(r); got != d.expected {
+			t.Errorf("expected '%s' got '%s'", d.expected, got)
+		}
+	}
+}
+
+func TestReactSSR_RequestContext(t *testing.T) {
+	renderer := &recordingRenderer{response: &RenderResponse{
+		StaticContent: "<div>page</div>",
+		Head:          []string{"<title>Page</title>"},
+		Status:        404,
+	}}
+	useRenderer(t, renderer)
+
+	r := httptest.NewRequest("GET", "http://example.com/page?id=1", nil)
+	r.Header.Set("Accept-Language", "de-DE")
+	r.Header.Add("User-Agent", "a")
+	r.Header.Add("User-Agent", "b")
+	r.Header.Set("Cookie", "session=secret")
+	r.Header.Set("Authorization", "Bearer secret")
+
+	ctx := context.WithValue(context.Background(), renderRequestKey, r)
+
+	doc, err := reactSSR(ctx, "page", []byte("{}"), &htmlDoc{})
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	req := renderer.req.Request
+	if req == nil {
+		t.Error("expected request context to be provided to the renderer")
+		return
+	}
+
+	if req.URL != "/page?id=1" || req.Path != "/page" || req.Method != "GET" || req.Host != "example.com" || req.Locale != "de-DE" {
+		t.Errorf("unexpected request context '%v'", req)
+	}
+
+	if req.Headers["User-Agent"] != "a, b" {
+		t.Errorf("expected header values to be joined got '%s'", req.Headers["User-Agent"])
+	}
+
+	if len(req.Headers) != 2 || req.Headers["Cookie"] != "" || req.Headers["Authorization"] != "" {
+		t.Errorf("expected only the forwarded headers to be provided got '%v'", req.Headers)
+	}
+
+	if len(doc.Head) != 1 || doc.Head[0] != "<title>Page</title>" {
+		t.Errorf("expected rendered head elements got '%v'", doc.Head)
+	}
+
+	if doc.Status != 404 || doc.Body[0] != "<div>page</div>" {
+		t.Errorf("unexpected document %d '%v'", doc.Status, doc.Body)
+	}
+}
+
+func TestReactHydrate_Redirect(t *testing.T) {
+	useRenderer(t, &recordingRenderer{response: &RenderResponse{Redirect: "/login"}})
+
+	doc, err := reactHydrate(context.Background(), "page", []byte("{}"), &htmlDoc{})
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	if doc.Redirect != "/login" {
+		t.Errorf("expected redirect got '%s'", doc.Redirect)
+	}
+}
+
+func TestReactHydrateMany(t *testing.T) {
+	useRenderer(t, &recordingRenderer{})
+
+	docs, err := reactHydrateMany(context.Background(), []string{"first", "second"}, [][]byte{[]byte(`{"a":1}`), []byte(`{"a":2}`)})
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	for i, key := range []string{"first", "second"} {
+		expected := fmt.Sprintf(`<div id="%s_react_frame">%s:{"a":%d}</div>`, key, key, i+1)
+		if docs[i].Body[0] != expected {
+			t.Errorf("expected '%s' got '%s'", expected, docs[i].Body[0])
+		}
+	}
+}
+
+type failingRenderer struct {
+	err error
+}
+
+func (r *failingRenderer) Render(ctx context.Context, req *RenderRequest) (*RenderResponse, error) {
+	return nil, r.err
+}
+
+func (r *failingRenderer) RenderMany(ctx context.Context, req *RenderManyRequest) (*RenderManyResponse, error) {
+	return nil, r.err
+}
+
+func (r *failingRenderer) RenderStream(ctx context.Context, req *RenderRequest, send func(*RenderResponse) error) error {
+	return r.err
+}
+
+func (r *failingRenderer) Close() error { return nil }
+
+func useFallback(t *testing.T, page PageRender, policy SSRFallbackPolicy) *[]SSRFallbackPolicy {
+	current := OnSSRFallback
+	applied := make([]SSRFallbackPolicy, 0)
+
+	SetSSRFallback(page, policy)
+	OnSSRFallback = func(page PageRender, policy SSRFallbackPolicy, err error) {
+		applied = append(applied, policy)
+	}
+
+	t.Cleanup(func() {
+		ssrFallbackMu.Lock()
+		delete(ssrFallbackPages, page)
+		ssrFallbackMu.Unlock()
+
+		OnSSRFallback = current
+	})
+
+	return &applied
+}
+
+func TestSetSSRFallback_Concurrent(t *testing.T) {
+	t.Cleanup(func() {
+		ssrFallbackMu.Lock()
+		delete(ssrFallbackPages, "concurrent")
+		ssrFallbackMu.Unlock()
+	})
+
+	current := OnSSRFallback
+	OnSSRFallback = func(page PageRender, policy SSRFallbackPolicy, err error) {}
+	t.Cleanup(func() { OnSSRFallback = current })
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			SetSSRFallback("concurrent", SSRFallbackFail)
+		}()
+
+		go func() {
+			defer wg.Done()
+			ssrFailure(context.Background(), "concurrent", errors.New("render failed"), true)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestReactHydrate_Fallback(t *testing.T) {
+	renderErr := errors.New("renderer unavailable")
+	useRenderer(t, &failingRenderer{err: renderErr})
+
+	tt := []struct {
+		policy      SSRFallbackPolicy
+		render      func(context.Context, string, []byte, *htmlDoc) (*htmlDoc, error)
+		expected    SSRFallbackPolicy
+		expectedErr bool
+		expectBare  bool
+	}{
+		{SSRFallbackCSR, reactHydrate, SSRFallbackCSR, false, false},
+		{SSRFallbackErrorPage, reactHydrate, SSRFallbackErrorPage, true, false},
+		{SSRFallbackFail, reactHydrate, SSRFallbackFail, true, true},
+		{SSRFallbackCSR, reactIslands, SSRFallbackErrorPage, true, false},
+		{SSRFallbackCSR, reactSSR, SSRFallbackErrorPage, true, false},
+	}
+
+	for _, d := range tt {
+		applied := useFallback(t, "page", d.policy)
+
+		doc, err := d.render(context.Background(), "page", []byte("{}"), &htmlDoc{})
+
+		if len(*applied) != 1 || (*applied)[0] != d.expected {
+			t.Errorf("expected '%s' policy to be applied got '%v'", d.expected, *applied)
+		}
+
+		if d.expectedErr != (err != nil) {
+			t.Errorf("expected error %t got '%v'", d.expectedErr, err)
+			continue
+		}
+
+		if err != nil {
+			var bare *bareError
+			if !errors.Is(err, renderErr) && !errors.As(err, &bare) {
+				t.Errorf("expected render error got '%s'", err)
+			}
+
+			if errors.As(err, &bare) != d.expectBare {
+				t.Errorf("expected bare error %t got '%s'", d.expectBare, err)
+			}
+
+			continue
+		}
+
+		expected := `<div id="page_react_frame" data-orbit-csr="true"></div>`
+		if len(doc.Body) != 2 || doc.Body[0] != expected {
+			t.Errorf("expected '%s' got '%v'", expected, doc.Body)
+		}
+
+		if !doc.fallback {
+			t.Error("expected the client rendered fragment to be marked as a fallback")
+		}
+	}
+}
+
+func TestReactHydrateMany_Fallback(t *testing.T) {
+	useRenderer(t, &failingRenderer{err: context.DeadlineExceeded})
+	applied := useFallback(t, "second", SSRFallbackCSR)
+
+	docs, err := reactHydrateMany(context.Background(), []string{"first", "second"}, [][]byte{[]byte("{}"), []byte("{}")})
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	if len(*applied) != 2 {
+		t.Errorf("expected fallback to be applied to each page got '%v'", *applied)
+	}
+
+	for i, key := range []string{"first", "second"} {
+		expected := fmt.Sprintf(`<div id="%s_react_frame" data-orbit-csr="true"></div>`, key)
+		if docs[i].Body[0] != expected {
+			t.Errorf("expected '%s' got '%s'", expected, docs[i].Body[0])
+		}
+	}
+
+	SetSSRFallback("second", SSRFallbackErrorPage)
+	if _, err := reactHydrateMany(context.Background(), []string{"first", "second"}, [][]byte{[]byte("{}"), []byte("{}")}); err == nil {
+		t.Error("expected error when a page does not fall back to csr")
+	}
+}
+
+func TestReactHydrate_CanceledRender(t *testing.T) {
+	useRenderer(t, &failingRenderer{err: context.Canceled})
+	applied := useFallback(t, "page", SSRFallbackCSR)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := reactHydrate(ctx, "page", []byte("{}"), &htmlDoc{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled render to fail got '%v'", err)
+	}
+
+	if len(*applied) != 0 {
+		t.Errorf("did not expect fallback to be applied got '%v'", *applied)
+	}
+}
+
+func TestServerRender_EmptyResponse(t *testing.T) {
+	useRenderer(t, &recordingRenderer{})
+
+	if _, err := serverRender(context.Background(), "page", []byte("{}")); !errors.Is(err, ErrSSREmptyResponse) {
+		t.Errorf("expected '%s' got '%v'", ErrSSREmptyResponse, err)
+	}
+}
+
+func TestVueHydrate(t *testing.T) {
+	useRenderer(t, &recordingRenderer{response: &RenderResponse{StaticContent: "<div>page</div>"}})
+
+	doc, err := vueHydrate(context.Background(), "page", []byte("{}"), &htmlDoc{})
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	if expected := `<div id="page_vue_frame"><div>page</div></div>`; doc.Body[0] != expected {
+		t.Errorf("expected '%s' got '%s'", expected, doc.Body[0])
+	}
+
+	useRenderer(t, &failingRenderer{err: context.DeadlineExceeded})
+	useFallback(t, "page", SSRFallbackCSR)
+
+	doc, err = vueHydrate(context.Background(), "page", []byte("{}"), &htmlDoc{})
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	if expected := `<div id="page_vue_frame"></div>`; doc.Body[0] != expected {
+		t.Errorf("expected the page to be mounted on the client got '%s'", doc.Body[0])
+	}
+}
+
+func TestReact18Hydrate_Stream(t *testing.T) {
+	renderer := &recordingRenderer{
+		response: &RenderResponse{StaticContent: "<h1>shell</h1>", Head: []string{"<title>streamed</title>"}},
+		chunks:   []string{"<p>suspended</p>"},
+	}
+	useRenderer(t, renderer)
+
+	stream := &strings.Builder{}
+	ctx := context.WithValue(context.Background(), renderStreamKey, stream)
+
+	doc, err := react18Hydrate(ctx, "page", []byte("{}"), &htmlDoc{Head: []string{}, Body: []string{}})
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	if expected := `<template class="orbit_head"><title>streamed</title></template>` + streamedHeadScript + `<div id="page_react_frame"><h1>shell</h1><p>suspended</p>`; stream.String() != expected {
+		t.Errorf("expected the frame & chunks of the page to be streamed got '%s'", stream.String())
+	}
+
+	// the body of the document has already been opened, so the head elements must be moved into the head
+	if outside := strings.ReplaceAll(stream.String(), `<template class="orbit_head"><title>streamed</title></template>`, ""); strings.Contains(outside, "<title>") {
+		t.Errorf("expected the head elements to be held by the template that is moved into the head got '%s'", stream.String())
+	}
+
+	if !strings.Contains(streamedHeadScript, "document.head.appendChild(el)") {
+		t.Error("expected the head script to move the elements into the head of the document")
+	}
+
+	if len(doc.Body) != 2 || doc.Body[0] != "</div>" || !strings.Contains(doc.Body[1], "orbit_bk") {
+		t.Errorf("expected the fragment to close the frame & load the bundle got '%v'", doc.Body)
+	}
+
+	t.Run("falls back to the client before the frame is written", func(t *testing.T) {
+		useRenderer(t, &failingRenderer{err: errors.New("shell failed")})
+		applied := useFallback(t, "page", SSRFallbackCSR)
+
+		stream := &strings.Builder{}
+		ctx := context.WithValue(context.Background(), renderStreamKey, stream)
+
+		doc, err := react18Hydrate(ctx, "page", []byte("{}"), &htmlDoc{Head: []string{}, Body: []string{}})
+		if err != nil {
+			t.Errorf("did not expect error '%s'", err)
+			return
+		}
+
+		if stream.Len() != 0 || len(*applied) != 1 || !strings.Contains(doc.Body[0], `data-orbit-csr="true"`) {
+			t.Errorf("expected the page to be rendered on the client got '%v'", doc.Body)
+		}
+	})
+
+	t.Run("renders without a stream", func(t *testing.T) {
+		useRenderer(t, renderer)
+
+		doc, err := react18Hydrate(context.Background(), "page", []byte("{}"), &htmlDoc{Head: []string{}, Body: []string{}})
+		if err != nil {
+			t.Errorf("did not expect error '%s'", err)
+			return
+		}
+
+		if !strings.Contains(doc.Body[0], "<h1>shell</h1>") || doc.Head[0] != "<title>streamed</title>" {
+			t.Errorf("expected the page to be rendered into the fragment got '%v'", doc)
+		}
+	})
+}
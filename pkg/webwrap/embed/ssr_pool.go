@@ -0,0 +1,607 @@
+package webwrap
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrNoSSRWorker    = errors.New("no healthy ssr renderer workers are available")
+	ErrSSRBootFailed  = errors.New("ssr renderer failed to boot")
+	ErrSSRBootTimeout = errors.New("ssr renderer did not boot before the timeout")
+	ErrSSRPoolClosed  = errors.New("ssr renderer pool has been closed")
+)
+
+// DefaultSSRAddress is the address of the ssr renderers when one has not been provided, a unix socket of
+// the project is used so that the renderers are not exposed to the network & do not collide with other apps
+// (a tcp address of the loopback interface is used on windows).
+const DefaultSSRAddress = defaultSSRAddress
+
+// SSRPoolOpts are the options used to create a pool of ssr renderer workers
+type SSRPoolOpts struct {
+	// Workers is the number of renderer processes that are run by the pool
+	Workers int
+	// Command creates the renderer process of a worker, the process should listen on the provided
+	// address & write "boot success" to stdout once it is ready to render.
+	Command func(address string) *exec.Cmd
+	// BaseAddress is the address of the first worker, either a tcp address e.g "127.0.0.1:3024"
+	// or a unix socket e.g "unix:.orbit/ssr.sock". the address of each following worker is
+	// created from it, see ssrWorkerAddress.
+	BaseAddress string
+	// Address creates the address that the worker listens on, this takes precedence over the base address
+	Address func(worker int) string
+	// HealthInterval is the duration between the health checks of each worker
+	HealthInterval time.Duration
+	// BootTimeout is the duration that a worker has to report that it has booted
+	BootTimeout time.Duration
+	// MinBackoff & MaxBackoff bound the delay before a crashed worker is restarted, the delay
+	// doubles for each crash that happens before the worker has stayed up for the max backoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// RenderTimeout is the deadline of a render when the context of the render does not already have one
+	RenderTimeout time.Duration
+	// DrainTimeout is the duration that in-flight renders (& then the worker processes)
+	// are given to finish once the pool has been closed.
+	DrainTimeout time.Duration
+}
+
+// ssrSocketPath returns the path of the socket file when the address is a unix socket
+func ssrSocketPath(address string) (string, bool) {
+	if !strings.HasPrefix(address, "unix:") {
+		return "", false
+	}
+
+	return strings.TrimPrefix(strings.TrimPrefix(address, "unix:"), "//"), true
+}
+
+// ssrWorkerAddress creates the address of the worker from the base address, workers of a tcp address listen
+// on the following ports (e.g 127.0.0.1:3025) & workers of a unix socket listen on their own socket file
+// (e.g unix:.orbit/ssr-1.sock).
+func ssrWorkerAddress(base string, worker int) string {
+	if path, ok := ssrSocketPath(base); ok {
+		ext := filepath.Ext(path)
+		return fmt.Sprintf("unix:%s-%d%s", strings.TrimSuffix(path, ext), worker, ext)
+	}
+
+	host, port, err := net.SplitHostPort(base)
+	if err != nil {
+		return base
+	}
+
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return base
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(p+worker))
+}
+
+// DefaultSSRServerPath is the path of the server bundle that is run by each node renderer process
+const DefaultSSRServerPath = ".orbit/ssr/react_ssr.js"
+
+// nodeSSRCommand creates the node renderer process that listens on the address
+func nodeSSRCommand(address string) *exec.Cmd {
+	cmd := exec.Command("node", DefaultSSRServerPath)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("ORBIT_SSR_ADDRESS=%s", address))
+
+	return cmd
+}
+
+// ssrWorker is a single renderer process of the pool, the process is replaced each time that it is restarted.
+type ssrWorker struct {
+	address string
+
+	mu       sync.Mutex
+	process  *os.Process
+	exited   chan struct{}
+	conn     *grpc.ClientConn
+	healthy  bool
+	restarts int
+}
+
+func (w *ssrWorker) attach(process *os.Process, exited chan struct{}, conn *grpc.ClientConn) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.process = process
+	w.exited = exited
+	w.conn = conn
+	w.healthy = true
+}
+
+func (w *ssrWorker) detach() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn != nil {
+		w.conn.Close()
+	}
+
+	w.process = nil
+	w.exited = nil
+	w.conn = nil
+	w.healthy = false
+}
+
+// client returns the connection to the worker, if the worker is healthy
+func (w *ssrWorker) client() (*grpc.ClientConn, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.conn, w.healthy
+}
+
+// signal terminates the process group of the worker (or kills it when force is set), this
+// ensures that any of the processes started by the renderer are also stopped.
+func (w *ssrWorker) signal(force bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.healthy = false
+	if w.process != nil {
+		signalProcessGroup(w.process, force)
+	}
+}
+
+// stop terminates the worker, the process is killed if it has not exited before the timeout
+func (w *ssrWorker) stop(timeout time.Duration) {
+	w.mu.Lock()
+	exited := w.exited
+	w.mu.Unlock()
+
+	if exited == nil {
+		return
+	}
+
+	w.signal(false)
+
+	select {
+	case <-exited:
+	case <-time.After(timeout):
+		w.signal(true)
+		<-exited
+	}
+}
+
+// SSRPool runs a set of ssr renderer workers, renders are spread across each of the healthy
+// workers & workers that crash (or fail their health check) are restarted with a backoff.
+type SSRPool struct {
+	opts    SSRPoolOpts
+	workers []*ssrWorker
+	next    uint32
+
+	mu       sync.RWMutex
+	closing  bool
+	done     chan struct{}
+	inflight sync.WaitGroup
+	stopped  sync.WaitGroup
+}
+
+// NewSSRPool creates a pool of ssr renderer workers, the workers are not run until the pool is started
+func NewSSRPool(opts *SSRPoolOpts) *SSRPool {
+	o := *opts
+
+	if o.Workers <= 0 {
+		o.Workers = 1
+	}
+
+	if o.Command == nil {
+		o.Command = nodeSSRCommand
+	}
+
+	if o.BaseAddress == "" {
+		o.BaseAddress = DefaultSSRAddress
+	}
+
+	if o.Address == nil {
+		o.Address = func(worker int) string {
+			return ssrWorkerAddress(o.BaseAddress, worker)
+		}
+	}
+
+	if o.HealthInterval <= 0 {
+		o.HealthInterval = 5 * time.Second
+	}
+
+	if o.BootTimeout <= 0 {
+		o.BootTimeout = 30 * time.Second
+	}
+
+	if o.MinBackoff <= 0 {
+		o.MinBackoff = 100 * time.Millisecond
+	}
+
+	if o.MaxBackoff < o.MinBackoff {
+		o.MaxBackoff = 10 * time.Second
+	}
+
+	if o.RenderTimeout <= 0 {
+		o.RenderTimeout = 10 * time.Second
+	}
+
+	if o.DrainTimeout <= 0 {
+		o.DrainTimeout = 5 * time.Second
+	}
+
+	p := &SSRPool{
+		opts:    o,
+		workers: make([]*ssrWorker, o.Workers),
+		done:    make(chan struct{}),
+	}
+
+	for i := range p.workers {
+		p.workers[i] = &ssrWorker{address: o.Address(i)}
+	}
+
+	return p
+}
+
+// Start runs each of the workers & waits for them to boot, an error is only returned
+// if none of the workers were able to boot. workers that fail are retried in the background.
+func (p *SSRPool) Start() error {
+	booted := make(chan error, len(p.workers))
+	for _, w := range p.workers {
+		p.stopped.Add(1)
+		go p.supervise(w, booted)
+	}
+
+	p.stopped.Add(1)
+	go p.healthCheck()
+
+	var bootErr error
+	healthy := 0
+	for range p.workers {
+		if err := <-booted; err != nil {
+			bootErr = err
+			continue
+		}
+
+		healthy++
+	}
+
+	if healthy == 0 {
+		p.Close()
+		return fmt.Errorf("%w: %s", ErrNoSSRWorker, bootErr)
+	}
+
+	return nil
+}
+
+func (p *SSRPool) isClosing() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.closing
+}
+
+// supervise runs the worker until the pool is closed, restarting the worker each time that it exits.
+// the result of the first boot is sent to booted.
+func (p *SSRPool) supervise(w *ssrWorker, booted chan<- error) {
+	defer p.stopped.Done()
+
+	backoff := p.opts.MinBackoff
+	for {
+		started := time.Now()
+
+		exited, err := p.boot(w)
+		if booted != nil {
+			booted <- err
+			booted = nil
+		}
+
+		if err == nil {
+			<-exited
+			w.detach()
+		}
+
+		if p.isClosing() {
+			return
+		}
+
+		// a worker that stayed up for the max backoff is considered to have recovered
+		if time.Since(started) > p.opts.MaxBackoff {
+			backoff = p.opts.MinBackoff
+		}
+
+		select {
+		case <-p.done:
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > p.opts.MaxBackoff {
+			backoff = p.opts.MaxBackoff
+		}
+
+		w.mu.Lock()
+		w.restarts++
+		w.mu.Unlock()
+	}
+}
+
+// boot starts the process of the worker & waits for it to report that it is ready to render,
+// the returned channel is closed once the process has exited.
+func (p *SSRPool) boot(w *ssrWorker) (chan struct{}, error) {
+	// a socket file left behind by a previous renderer would prevent the renderer from listening
+	if path, ok := ssrSocketPath(w.address); ok {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	cmd := p.opts.Command(w.address)
+
+	startProcessGroup(cmd)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	ready := make(chan bool, 1)
+	exited := make(chan struct{})
+
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			line := scanner.Text()
+
+			if strings.Contains(line, "boot success") {
+				select {
+				case ready <- true:
+				default:
+				}
+			}
+
+			if strings.Contains(line, "boot fail") {
+				select {
+				case ready <- false:
+				default:
+				}
+			}
+		}
+
+		cmd.Wait()
+		close(exited)
+	}()
+
+	timeout := time.NewTimer(p.opts.BootTimeout)
+	defer timeout.Stop()
+
+	var bootErr error
+	select {
+	case ok := <-ready:
+		if !ok {
+			bootErr = ErrSSRBootFailed
+		}
+	case <-exited:
+		bootErr = ErrSSRBootFailed
+	case <-timeout.C:
+		bootErr = ErrSSRBootTimeout
+	case <-p.done:
+		bootErr = ErrSSRPoolClosed
+	}
+
+	if bootErr == nil {
+		bootErr = p.attach(w, cmd.Process, exited)
+	}
+
+	if bootErr != nil {
+		signalProcessGroup(cmd.Process, true)
+		<-exited
+
+		return nil, bootErr
+	}
+
+	return exited, nil
+}
+
+// attach connects to the booted worker, the worker is only attached while the pool is open
+// so that it is always stopped by the pool once it has been closed.
+func (p *SSRPool) attach(w *ssrWorker, process *os.Process, exited chan struct{}) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closing {
+		return ErrSSRPoolClosed
+	}
+
+	conn, err := grpc.Dial(w.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return err
+	}
+
+	w.attach(process, exited, conn)
+	return nil
+}
+
+// healthCheck checks the health of each of the workers at the health interval,
+// workers that are not serving are restarted.
+func (p *SSRPool) healthCheck() {
+	defer p.stopped.Done()
+
+	ticker := time.NewTicker(p.opts.HealthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+
+		for _, w := range p.workers {
+			conn, ok := w.client()
+			if !ok {
+				continue
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), p.opts.HealthInterval)
+			res, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+			cancel()
+
+			if err != nil || res.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+				w.signal(true)
+			}
+		}
+	}
+}
+
+// Render renders the request with the next healthy worker, if a worker cannot be
+// reached then it is restarted & the request is retried with the following worker.
+func (p *SSRPool) Render(ctx context.Context, req *RenderRequest) (*RenderResponse, error) {
+	var res *RenderResponse
+	err := p.call(ctx, func(ctx context.Context, client ReactRendererClient) (err error) {
+		res, err = client.Render(ctx, req)
+		return err
+	})
+
+	return res, err
+}
+
+// RenderMany renders each of the requests with a single call to the next healthy worker
+func (p *SSRPool) RenderMany(ctx context.Context, req *RenderManyRequest) (*RenderManyResponse, error) {
+	var res *RenderManyResponse
+	err := p.call(ctx, func(ctx context.Context, client ReactRendererClient) (err error) {
+		res, err = client.RenderMany(ctx, req)
+		return err
+	})
+
+	return res, err
+}
+
+// RenderStream renders the request with the next healthy worker & sends each response as it is received,
+// once a response has been sent the render is no longer retried with the following worker.
+func (p *SSRPool) RenderStream(ctx context.Context, req *RenderRequest, send func(*RenderResponse) error) error {
+	var streamErr error
+	err := p.call(ctx, func(ctx context.Context, client ReactRendererClient) error {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		stream, err := client.RenderStream(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		for sent := false; ; sent = true {
+			res, err := stream.Recv()
+			if err == io.EOF {
+				return nil
+			}
+
+			if err != nil {
+				if !sent {
+					return err
+				}
+
+				streamErr = err
+				return nil
+			}
+
+			if streamErr = send(res); streamErr != nil {
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return streamErr
+}
+
+// call runs the rpc against the next healthy worker, tracking it as an in-flight render so that it is drained on close
+func (p *SSRPool) call(ctx context.Context, rpc func(ctx context.Context, client ReactRendererClient) error) error {
+	p.mu.RLock()
+	if p.closing {
+		p.mu.RUnlock()
+		return ErrSSRPoolClosed
+	}
+	p.inflight.Add(1)
+	p.mu.RUnlock()
+
+	defer p.inflight.Done()
+
+	// the deadline of the incoming request is used for the render, otherwise the render timeout is applied
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.opts.RenderTimeout)
+		defer cancel()
+	}
+
+	start := int(atomic.AddUint32(&p.next, 1))
+	for i := range p.workers {
+		w := p.workers[(start+i)%len(p.workers)]
+
+		conn, ok := w.client()
+		if !ok {
+			continue
+		}
+
+		err := rpc(ctx, NewReactRendererClient(conn))
+		if status.Code(err) == codes.Unavailable {
+			w.signal(true)
+			continue
+		}
+
+		return err
+	}
+
+	return ErrNoSSRWorker
+}
+
+// Close stops accepting renders & waits for the in-flight renders to drain,
+// each of the workers is then terminated.
+func (p *SSRPool) Close() error {
+	p.mu.Lock()
+	if p.closing {
+		p.mu.Unlock()
+		return nil
+	}
+
+	p.closing = true
+	close(p.done)
+	p.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		p.inflight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-time.After(p.opts.DrainTimeout):
+	}
+
+	for _, w := range p.workers {
+		w.stop(p.opts.DrainTimeout)
+	}
+
+	p.stopped.Wait()
+
+	return nil
+}
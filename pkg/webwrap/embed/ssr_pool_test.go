@@ -0,0 +1,376 @@
+package webwrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+type fakeRenderer struct {
+	UnimplementedReactRendererServer
+	address string
+	health  *health.Server
+}
+
+func (r *fakeRenderer) Render(ctx context.Context, req *RenderRequest) (*RenderResponse, error) {
+	switch req.BundleID {
+	case "crash":
+		os.Exit(1)
+	case "unhealthy":
+		r.health.SetServingStatus("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	case "slow":
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	return &RenderResponse{StaticContent: fmt.Sprintf("%s@%s", req.BundleID, r.address)}, nil
+}
+
+func (r *fakeRenderer) RenderMany(ctx context.Context, req *RenderManyRequest) (*RenderManyResponse, error) {
+	res := &RenderManyResponse{}
+	for _, render := range req.Renders {
+		rendered, err := r.Render(ctx, render)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Renders = append(res.Renders, rendered)
+	}
+
+	return res, nil
+}
+
+func (r *fakeRenderer) RenderStream(req *RenderRequest, stream ReactRenderer_RenderStreamServer) error {
+	for _, chunk := range []string{req.BundleID, "@" + r.address} {
+		if err := stream.Send(&RenderResponse{StaticContent: chunk}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TestFakeSSRRenderer is the renderer binary used by the ssr pool tests, the test
+// binary is started as a worker process with the fake renderer address set.
+func TestFakeSSRRenderer(t *testing.T) {
+	address := os.Getenv("ORBIT_FAKE_SSR_ADDRESS")
+	if address == "" {
+		t.Skip("only run as a worker of the ssr pool")
+	}
+
+	network, listenAddress := "tcp", address
+	if path, ok := ssrSocketPath(address); ok {
+		network, listenAddress = "unix", path
+	}
+
+	lis, err := net.Listen(network, listenAddress)
+	if err != nil {
+		fmt.Println("boot fail", err)
+		os.Exit(1)
+	}
+
+	s := grpc.NewServer()
+	h := health.NewServer()
+
+	RegisterReactRendererServer(s, &fakeRenderer{address: address, health: h})
+	grpc_health_v1.RegisterHealthServer(s, h)
+
+	fmt.Println("boot success")
+	s.Serve(lis)
+	os.Exit(0)
+}
+
+func freeAddress(t *testing.T) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot find free address '%s'", err)
+	}
+	defer lis.Close()
+
+	return lis.Addr().String()
+}
+
+func fakeSSRPoolOpts(t *testing.T, workers int) *SSRPoolOpts {
+	addresses := make([]string, workers)
+	for i := range addresses {
+		addresses[i] = freeAddress(t)
+	}
+
+	return &SSRPoolOpts{
+		Workers: workers,
+		Command: func(address string) *exec.Cmd {
+			cmd := exec.Command(os.Args[0], "-test.run=^TestFakeSSRRenderer$")
+			cmd.Env = append(os.Environ(), fmt.Sprintf("ORBIT_FAKE_SSR_ADDRESS=%s", address))
+
+			return cmd
+		},
+		Address:        func(worker int) string { return addresses[worker] },
+		HealthInterval: 50 * time.Millisecond,
+		BootTimeout:    10 * time.Second,
+		MinBackoff:     10 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+		DrainTimeout:   time.Second,
+	}
+}
+
+func startFakeSSRPool(t *testing.T, opts *SSRPoolOpts) *SSRPool {
+	pool := NewSSRPool(opts)
+
+	if err := pool.Start(); err != nil {
+		t.Fatalf("should not fail to start pool '%s'", err)
+	}
+	t.Cleanup(func() { pool.Close() })
+
+	return pool
+}
+
+// waitForRender retries the render until it succeeds, this allows for workers to be restarted
+func waitForRender(pool *SSRPool, bundleID string) (*RenderResponse, error) {
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		res, err := pool.Render(context.Background(), &RenderRequest{BundleID: bundleID})
+		if err == nil || time.Now().After(deadline) {
+			return res, err
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSSRPoolRender(t *testing.T) {
+	pool := startFakeSSRPool(t, fakeSSRPoolOpts(t, 2))
+
+	served := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		res, err := pool.Render(context.Background(), &RenderRequest{BundleID: "page"})
+		if err != nil {
+			t.Errorf("did not expect error '%s'", err)
+			return
+		}
+
+		served[res.StaticContent] = true
+	}
+
+	for _, w := range pool.workers {
+		if !served[fmt.Sprintf("page@%s", w.address)] {
+			t.Errorf("expected renders to be spread across the workers got '%v'", served)
+		}
+	}
+}
+
+func TestSSRPoolRenderMany(t *testing.T) {
+	pool := startFakeSSRPool(t, fakeSSRPoolOpts(t, 1))
+
+	res, err := pool.RenderMany(context.Background(), &RenderManyRequest{
+		Renders: []*RenderRequest{{BundleID: "first"}, {BundleID: "second"}},
+	})
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	for i, key := range []string{"first", "second"} {
+		if res.Renders[i].StaticContent != fmt.Sprintf("%s@%s", key, pool.workers[0].address) {
+			t.Errorf("expected renders in the order of the requests got '%s'", res.Renders[i].StaticContent)
+		}
+	}
+}
+
+func TestSSRPoolRenderStream(t *testing.T) {
+	pool := startFakeSSRPool(t, fakeSSRPoolOpts(t, 1))
+
+	chunks := make([]string, 0)
+	err := pool.RenderStream(context.Background(), &RenderRequest{BundleID: "page"}, func(res *RenderResponse) error {
+		chunks = append(chunks, res.StaticContent)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	if len(chunks) != 2 || strings.Join(chunks, "") != fmt.Sprintf("page@%s", pool.workers[0].address) {
+		t.Errorf("expected each response of the stream to be sent got '%v'", chunks)
+	}
+
+	t.Run("send errors stop the stream", func(t *testing.T) {
+		sendErr := errors.New("client disconnected")
+		sends := 0
+
+		err := pool.RenderStream(context.Background(), &RenderRequest{BundleID: "page"}, func(res *RenderResponse) error {
+			sends++
+			return sendErr
+		})
+
+		if !errors.Is(err, sendErr) || sends != 1 {
+			t.Errorf("expected the stream to stop with the send error got '%v' after %d sends", err, sends)
+		}
+	})
+}
+
+func TestSSRPoolRestart(t *testing.T) {
+	pool := startFakeSSRPool(t, fakeSSRPoolOpts(t, 1))
+
+	if _, err := pool.Render(context.Background(), &RenderRequest{BundleID: "crash"}); err == nil {
+		t.Error("expected error when the worker crashes")
+	}
+
+	res, err := waitForRender(pool, "page")
+	if err != nil {
+		t.Errorf("expected crashed worker to recover got '%s'", err)
+		return
+	}
+
+	if res.StaticContent != fmt.Sprintf("page@%s", pool.workers[0].address) {
+		t.Errorf("unexpected render '%s'", res.StaticContent)
+	}
+
+	pool.workers[0].mu.Lock()
+	restarts := pool.workers[0].restarts
+	pool.workers[0].mu.Unlock()
+
+	if restarts == 0 {
+		t.Error("expected the worker to be restarted")
+	}
+}
+
+func TestSSRPoolHealthCheck(t *testing.T) {
+	pool := startFakeSSRPool(t, fakeSSRPoolOpts(t, 1))
+
+	if _, err := pool.Render(context.Background(), &RenderRequest{BundleID: "unhealthy"}); err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		pool.workers[0].mu.Lock()
+		restarts := pool.workers[0].restarts
+		pool.workers[0].mu.Unlock()
+
+		if restarts > 0 {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Error("expected the unhealthy worker to be restarted")
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := waitForRender(pool, "page"); err != nil {
+		t.Errorf("expected restarted worker to render got '%s'", err)
+	}
+}
+
+func TestSSRPoolClose(t *testing.T) {
+	pool := startFakeSSRPool(t, fakeSSRPoolOpts(t, 1))
+
+	rendered := make(chan error)
+	go func() {
+		_, err := pool.Render(context.Background(), &RenderRequest{BundleID: "slow"})
+		rendered <- err
+	}()
+
+	// allow for the slow render to be in-flight before the pool is closed
+	time.Sleep(50 * time.Millisecond)
+	pool.Close()
+
+	if err := <-rendered; err != nil {
+		t.Errorf("expected in-flight render to be drained got '%s'", err)
+	}
+
+	if _, err := pool.Render(context.Background(), &RenderRequest{BundleID: "page"}); !errors.Is(err, ErrSSRPoolClosed) {
+		t.Errorf("expected closed error got '%v'", err)
+	}
+
+	if conn, err := net.DialTimeout("tcp", pool.workers[0].address, time.Second); err == nil {
+		conn.Close()
+		t.Error("expected the worker process to be stopped")
+	}
+}
+
+func TestSSRPoolBootFailure(t *testing.T) {
+	pool := NewSSRPool(&SSRPoolOpts{
+		Command:     func(address string) *exec.Cmd { return exec.Command("false") },
+		Address:     func(worker int) string { return freeAddress(t) },
+		BootTimeout: time.Second,
+	})
+
+	if err := pool.Start(); !errors.Is(err, ErrNoSSRWorker) {
+		t.Errorf("expected boot error got '%v'", err)
+	}
+}
+
+func TestSSRWorkerAddress(t *testing.T) {
+	tt := []struct {
+		base     string
+		worker   int
+		expected string
+	}{
+		{"127.0.0.1:3024", 0, "127.0.0.1:3024"},
+		{"127.0.0.1:3024", 2, "127.0.0.1:3026"},
+		{"unix:.orbit/ssr.sock", 1, "unix:.orbit/ssr-1.sock"},
+		{"unix:///tmp/orbit.sock", 0, "unix:/tmp/orbit-0.sock"},
+		{"invalid", 1, "invalid"},
+	}
+
+	for _, d := range tt {
+		if got := ssrWorkerAddress(d.base, d.worker); got != d.expected {
+			t.Errorf("expected '%s' got '%s'", d.expected, got)
+		}
+	}
+}
+
+func TestSSRPoolUnixSocket(t *testing.T) {
+	opts := fakeSSRPoolOpts(t, 2)
+	opts.Address = nil
+	opts.BaseAddress = fmt.Sprintf("unix:%s/ssr.sock", t.TempDir())
+
+	// a stale socket file should not prevent the worker from booting
+	stale, _ := ssrSocketPath(ssrWorkerAddress(opts.BaseAddress, 0))
+	os.WriteFile(stale, []byte{}, 0644)
+
+	pool := startFakeSSRPool(t, opts)
+
+	res, err := pool.Render(context.Background(), &RenderRequest{BundleID: "page"})
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	if res.StaticContent != fmt.Sprintf("page@%s", ssrWorkerAddress(opts.BaseAddress, 1)) {
+		t.Errorf("unexpected render '%s'", res.StaticContent)
+	}
+}
+
+func TestSSRPoolRenderDeadline(t *testing.T) {
+	opts := fakeSSRPoolOpts(t, 1)
+	opts.RenderTimeout = 50 * time.Millisecond
+
+	pool := startFakeSSRPool(t, opts)
+
+	if _, err := pool.Render(context.Background(), &RenderRequest{BundleID: "slow"}); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("expected render timeout to be applied got '%v'", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if _, err := pool.Render(ctx, &RenderRequest{BundleID: "slow"}); err != nil {
+		t.Errorf("expected the deadline of the context to be used got '%s'", err)
+	}
+}
@@ -0,0 +1,27 @@
+//go:build !windows
+
+package webwrap
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// defaultSSRAddress is a unix socket of the project, so that the renderers are not exposed to the network
+const defaultSSRAddress = "unix:.orbit/ssr.sock"
+
+// startProcessGroup runs the renderer in its own process group, so that it can be stopped along with its child processes
+func startProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// signalProcessGroup terminates the process group of the process, the group is killed when force is set
+func signalProcessGroup(p *os.Process, force bool) error {
+	sig := syscall.SIGTERM
+	if force {
+		sig = syscall.SIGKILL
+	}
+
+	return syscall.Kill(-p.Pid, sig)
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package webwrap
+
+import (
+	"os"
+	"os/exec"
+)
+
+// defaultSSRAddress is a tcp address of the loopback interface, so that the renderers are not exposed to the network
+const defaultSSRAddress = "127.0.0.1:3024"
+
+// startProcessGroup is a no-op, processes do not have a process group that can be signalled on windows
+func startProcessGroup(cmd *exec.Cmd) {}
+
+// signalProcessGroup kills the process, windows processes cannot be asked to terminate so the process is always killed
+func signalProcessGroup(p *os.Process, force bool) error {
+	return p.Kill()
+}
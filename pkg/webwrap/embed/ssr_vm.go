@@ -0,0 +1,260 @@
+package webwrap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+// SSRRenderer renders the server side content of a bundle, this is implemented by the
+// pool of node renderer processes & by the pool of embedded javascript vms.
+type SSRRenderer interface {
+	Render(ctx context.Context, req *RenderRequest) (*RenderResponse, error)
+	// RenderMany renders each of the requests in a single round trip, the responses are in the order of the requests
+	RenderMany(ctx context.Context, req *RenderManyRequest) (*RenderManyResponse, error)
+	// RenderStream renders the request & sends each response of the render as it is streamed, the first response
+	// holds the head, status & redirect of the render & each response holds the next chunk of the static content.
+	RenderStream(ctx context.Context, req *RenderRequest, send func(*RenderResponse) error) error
+	Close() error
+}
+
+const (
+	// NodeSSRBackend renders with a pool of node processes that are called over grpc
+	NodeSSRBackend = "node"
+	// EmbeddedSSRBackend renders with a pool of javascript vms that are embedded in the go binary
+	EmbeddedSSRBackend = "embedded"
+)
+
+var ErrNoJSVM = errors.New("embedded ssr requires a javascript vm, one has not been provided with NewJSVM")
+
+// JSVM is a javascript engine (e.g goja) that runs the server bundle for the embedded ssr renderer.
+// the request & response are exchanged as json rather than as the types of this package, so that the same
+// vm (e.g "github.com/GuyARoss/orbit/pkg/webwrap/gojavm") can be used by each of the generated packages.
+type JSVM interface {
+	// Run evaluates the server bundle, the bundle is expected to define the global "buildStaticContent" function
+	Run(script string) error
+	// BuildStaticContent calls the "buildStaticContent" function of the server bundle with the json of the
+	// render request, the json of the object returned (or resolved) by the function is the render response.
+	BuildStaticContent(request string) (string, error)
+	// Interrupt stops the current call to the vm, the vm should remain usable by following calls
+	Interrupt()
+}
+
+// NewJSVM creates the javascript vm used by the embedded ssr renderer, a vm is not included by default
+// so that apps that use the node renderer do not depend on one.
+var NewJSVM func() JSVM
+
+// DefaultSSRScriptPath is the path of the server bundle that is run by the embedded ssr renderer
+const DefaultSSRScriptPath = ".orbit/ssr/react_ssr.vm.js"
+
+// VMPoolOpts are the options used to create a pool of javascript vms
+type VMPoolOpts struct {
+	// VMs is the number of vms in the pool, which is the number of renders that can run at once
+	VMs int
+	// ScriptPath is the path of the server bundle that is run by each vm
+	ScriptPath string
+	// NewVM creates each of the vms, defaults to NewJSVM
+	NewVM func() JSVM
+	// RenderTimeout is the deadline of a render when the context of the render does not already have one
+	RenderTimeout time.Duration
+	// DrainTimeout is the duration that in-flight renders are given to finish once the pool has been closed
+	DrainTimeout time.Duration
+}
+
+// VMPool renders with a set of javascript vms, a vm only runs a single render at a time
+// so renders wait for a vm to become available.
+type VMPool struct {
+	vms           chan JSVM
+	renderTimeout time.Duration
+	drainTimeout  time.Duration
+
+	mu       sync.RWMutex
+	closed   bool
+	inflight sync.WaitGroup
+}
+
+// NewVMPool creates the vms of the pool & runs the server bundle in each of them
+func NewVMPool(opts *VMPoolOpts) (*VMPool, error) {
+	newVM := opts.NewVM
+	if newVM == nil {
+		newVM = NewJSVM
+	}
+
+	if newVM == nil {
+		return nil, ErrNoJSVM
+	}
+
+	path := opts.ScriptPath
+	if path == "" {
+		path = DefaultSSRScriptPath
+	}
+
+	script, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	count := opts.VMs
+	if count <= 0 {
+		count = 1
+	}
+
+	p := &VMPool{
+		vms:           make(chan JSVM, count),
+		renderTimeout: opts.RenderTimeout,
+		drainTimeout:  opts.DrainTimeout,
+	}
+
+	if p.renderTimeout <= 0 {
+		p.renderTimeout = 10 * time.Second
+	}
+
+	if p.drainTimeout <= 0 {
+		p.drainTimeout = 5 * time.Second
+	}
+
+	for i := 0; i < count; i++ {
+		vm := newVM()
+		if err := vm.Run(string(script)); err != nil {
+			return nil, fmt.Errorf("cannot run server bundle '%s': %w", path, err)
+		}
+
+		p.vms <- vm
+	}
+
+	return p, nil
+}
+
+type vmResult struct {
+	response *RenderResponse
+	err      error
+}
+
+// Render renders the request with the next available vm, the vm is interrupted if the deadline is exceeded.
+// the render is tracked as an in-flight render so that it is drained when the pool is closed.
+func (p *VMPool) Render(ctx context.Context, req *RenderRequest) (*RenderResponse, error) {
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		return nil, ErrSSRPoolClosed
+	}
+	p.inflight.Add(1)
+	p.mu.RUnlock()
+
+	defer p.inflight.Done()
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.renderTimeout)
+		defer cancel()
+	}
+
+	var vm JSVM
+	select {
+	case vm = <-p.vms:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { p.vms <- vm }()
+
+	request, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan vmResult, 1)
+	go func() {
+		done <- buildStaticContent(vm, string(request))
+	}()
+
+	select {
+	case r := <-done:
+		return r.response, r.err
+	case <-ctx.Done():
+		vm.Interrupt()
+		<-done
+
+		return nil, ctx.Err()
+	}
+}
+
+// buildStaticContent renders the json request with the vm & decodes the response of the render
+func buildStaticContent(vm JSVM, request string) vmResult {
+	out, err := vm.BuildStaticContent(request)
+	if err != nil {
+		return vmResult{err: err}
+	}
+
+	response := &RenderResponse{}
+	if err := json.Unmarshal([]byte(out), response); err != nil {
+		return vmResult{err: fmt.Errorf("cannot decode the render response: %w", err)}
+	}
+
+	return vmResult{response: response}
+}
+
+// RenderMany renders the requests concurrently across the vms of the pool
+func (p *VMPool) RenderMany(ctx context.Context, req *RenderManyRequest) (*RenderManyResponse, error) {
+	res := &RenderManyResponse{Renders: make([]*RenderResponse, len(req.Renders))}
+	errs := make([]error, len(req.Renders))
+
+	wg := &sync.WaitGroup{}
+	for i, r := range req.Renders {
+		wg.Add(1)
+
+		go func(i int, r *RenderRequest) {
+			defer wg.Done()
+			res.Renders[i], errs[i] = p.Render(ctx, r)
+		}(i, r)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// RenderStream renders the request with the next available vm, the vms do not support node streams
+// so the render is sent as a single response once it has completed.
+func (p *VMPool) RenderStream(ctx context.Context, req *RenderRequest, send func(*RenderResponse) error) error {
+	response, err := p.Render(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return send(response)
+}
+
+// Close stops the pool from accepting renders & waits for the in-flight renders to finish, renders
+// that have not finished before the drain timeout are left to their own deadline.
+func (p *VMPool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+
+	p.closed = true
+	p.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		p.inflight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-time.After(p.drainTimeout):
+	}
+
+	return nil
+}
@@ -0,0 +1,245 @@
+package webwrap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var errFakeVMInterrupted = errors.New("interrupted")
+
+type fakeJSVM struct {
+	script    string
+	interrupt chan struct{}
+}
+
+func (v *fakeJSVM) Run(script string) error {
+	if script == "" {
+		return errors.New("empty script")
+	}
+
+	v.script = script
+	return nil
+}
+
+func (v *fakeJSVM) BuildStaticContent(request string) (string, error) {
+	req := &RenderRequest{}
+	if err := json.Unmarshal([]byte(request), req); err != nil {
+		return "", err
+	}
+
+	if req.BundleID == "slow" {
+		select {
+		case <-v.interrupt:
+			return "", errFakeVMInterrupted
+		case <-time.After(time.Second):
+		}
+	}
+
+	if req.BundleID == "invalid" {
+		return "{", nil
+	}
+
+	out, err := json.Marshal(&RenderResponse{
+		StaticContent: fmt.Sprintf("%s:%s:%s", v.script, req.BundleID, req.JSONData),
+		Status:        int32(len(req.GetRequest().GetPath())),
+	})
+
+	return string(out), err
+}
+
+func (v *fakeJSVM) Interrupt() {
+	v.interrupt <- struct{}{}
+}
+
+func fakeVMPoolOpts(t *testing.T, script string) *VMPoolOpts {
+	path := filepath.Join(t.TempDir(), "react_ssr.vm.js")
+	os.WriteFile(path, []byte(script), 0644)
+
+	return &VMPoolOpts{
+		VMs:        2,
+		ScriptPath: path,
+		NewVM: func() JSVM {
+			return &fakeJSVM{interrupt: make(chan struct{}, 1)}
+		},
+	}
+}
+
+func TestVMPoolRender(t *testing.T) {
+	pool, err := NewVMPool(fakeVMPoolOpts(t, "bundle"))
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+	defer pool.Close()
+
+	res, err := pool.Render(context.Background(), &RenderRequest{BundleID: "page", JSONData: "{}"})
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	if res.StaticContent != "bundle:page:{}" {
+		t.Errorf("unexpected render '%s'", res.StaticContent)
+	}
+
+	res, err = pool.Render(context.Background(), &RenderRequest{BundleID: "page", Request: &RequestContext{Path: "/path"}})
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	if res.Status != 5 {
+		t.Errorf("expected the request context to be provided to the vm got status '%d'", res.Status)
+	}
+
+	if _, err := pool.Render(context.Background(), &RenderRequest{BundleID: "invalid"}); err == nil {
+		t.Error("expected error when the render response cannot be decoded")
+	}
+
+	sent := make([]*RenderResponse, 0)
+	err = pool.RenderStream(context.Background(), &RenderRequest{BundleID: "page", JSONData: "{}"}, func(res *RenderResponse) error {
+		sent = append(sent, res)
+		return nil
+	})
+
+	if err != nil || len(sent) != 1 || sent[0].StaticContent != "bundle:page:{}" {
+		t.Errorf("expected the render to be sent as a single response got '%v' '%v'", sent, err)
+	}
+}
+
+func TestVMPoolRenderMany(t *testing.T) {
+	pool, err := NewVMPool(fakeVMPoolOpts(t, "bundle"))
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+	defer pool.Close()
+
+	res, err := pool.RenderMany(context.Background(), &RenderManyRequest{
+		Renders: []*RenderRequest{{BundleID: "first"}, {BundleID: "second"}, {BundleID: "third"}},
+	})
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	for i, key := range []string{"first", "second", "third"} {
+		if res.Renders[i].StaticContent != fmt.Sprintf("bundle:%s:", key) {
+			t.Errorf("expected renders in the order of the requests got '%s'", res.Renders[i].StaticContent)
+		}
+	}
+}
+
+func TestNewVMPool_Errors(t *testing.T) {
+	opts := fakeVMPoolOpts(t, "bundle")
+	opts.NewVM = nil
+
+	if _, err := NewVMPool(opts); !errors.Is(err, ErrNoJSVM) {
+		t.Errorf("expected missing vm error got '%v'", err)
+	}
+
+	if _, err := NewVMPool(fakeVMPoolOpts(t, "")); err == nil {
+		t.Error("expected error when the server bundle cannot be run")
+	}
+
+	opts = fakeVMPoolOpts(t, "bundle")
+	opts.ScriptPath = filepath.Join(t.TempDir(), "missing.js")
+
+	if _, err := NewVMPool(opts); err == nil {
+		t.Error("expected error when the server bundle does not exist")
+	}
+}
+
+func TestVMPoolRenderDeadline(t *testing.T) {
+	opts := fakeVMPoolOpts(t, "bundle")
+	opts.VMs = 1
+	opts.RenderTimeout = 50 * time.Millisecond
+
+	pool, err := NewVMPool(opts)
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+	defer pool.Close()
+
+	if _, err := pool.Render(context.Background(), &RenderRequest{BundleID: "slow"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected render timeout to be applied got '%v'", err)
+	}
+
+	// the interrupted vm should be returned to the pool
+	if _, err := pool.Render(context.Background(), &RenderRequest{BundleID: "page"}); err != nil {
+		t.Errorf("expected the interrupted vm to render got '%s'", err)
+	}
+}
+
+func TestVMPoolClose(t *testing.T) {
+	pool, err := NewVMPool(fakeVMPoolOpts(t, "bundle"))
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	pool.Close()
+
+	if _, err := pool.Render(context.Background(), &RenderRequest{BundleID: "page"}); !errors.Is(err, ErrSSRPoolClosed) {
+		t.Errorf("expected closed error got '%v'", err)
+	}
+}
+
+func TestVMPoolClose_Drain(t *testing.T) {
+	opts := fakeVMPoolOpts(t, "bundle")
+	opts.VMs = 1
+
+	pool, err := NewVMPool(opts)
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	rendered := make(chan error, 1)
+	go func() {
+		_, err := pool.Render(context.Background(), &RenderRequest{BundleID: "slow"})
+		rendered <- err
+	}()
+
+	// the render is given time to start before the pool is closed
+	time.Sleep(50 * time.Millisecond)
+	pool.Close()
+
+	select {
+	case err := <-rendered:
+		if err != nil {
+			t.Errorf("expected the in-flight render to finish got '%s'", err)
+		}
+	default:
+		t.Error("expected close to wait for the in-flight render")
+	}
+}
+
+func TestVMPoolClose_DrainTimeout(t *testing.T) {
+	opts := fakeVMPoolOpts(t, "bundle")
+	opts.VMs = 1
+	opts.DrainTimeout = 50 * time.Millisecond
+
+	pool, err := NewVMPool(opts)
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	go pool.Render(context.Background(), &RenderRequest{BundleID: "slow"})
+	time.Sleep(20 * time.Millisecond)
+
+	start := time.Now()
+	pool.Close()
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected close to be bounded by the drain timeout got '%s'", elapsed)
+	}
+}
@@ -0,0 +1,14 @@
+package webwrap
+
+import (
+	context "context"
+	"fmt"
+)
+
+func vueCSR(ctx context.Context, bundleKey string, data []byte, doc *htmlDoc) (*htmlDoc, error) {
+	// vue mounts the page to the frame, so the frame must exist before the bundle is loaded
+	doc.Body = append(doc.Body, fmt.Sprintf(`<div id="%s_vue_frame"></div>`, bundleKey))
+	doc.Body = append(doc.Body, fmt.Sprintf(`<script class="orbit_bk" src="/p/%s"></script>`, bundleFile(bundleKey)))
+
+	return doc, nil
+}
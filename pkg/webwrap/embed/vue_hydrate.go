@@ -0,0 +1,36 @@
+package webwrap
+
+import (
+	context "context"
+	"fmt"
+)
+
+// vue pages are server side rendered & batched by the same renderer as the hydrated react pages
+func init() {
+	batchRenderers["vueHydrate"] = vueHydrateMany
+}
+
+// vueFragment adds the server rendered page to the document along with the bundle that hydrates it, vue
+// mounts the page rather than hydrating it when the frame is empty (e.g the server side render failed).
+func vueFragment(doc *htmlDoc, bundleKey string, response *RenderResponse) *htmlDoc {
+	content := ""
+	if response != nil {
+		mergeRenderResponse(doc, response)
+		content = response.StaticContent
+	} else {
+		doc.fallback = true
+	}
+
+	doc.Body = append(doc.Body, fmt.Sprintf(`<div id="%s_vue_frame">%s</div>`, bundleKey, content))
+	doc.Body = append(doc.Body, fmt.Sprintf(`<script class="orbit_bk" src="/p/%s"></script>`, bundleFile(bundleKey)))
+
+	return doc
+}
+
+func vueHydrate(ctx context.Context, bundleKey string, data []byte, doc *htmlDoc) (*htmlDoc, error) {
+	return hydratePage(ctx, bundleKey, data, doc, vueFragment, true)
+}
+
+func vueHydrateMany(ctx context.Context, bundleKeys []string, data [][]byte) ([]*htmlDoc, error) {
+	return hydrateMany(ctx, bundleKeys, data, vueFragment, true)
+}
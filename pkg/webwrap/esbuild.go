@@ -0,0 +1,164 @@
+// Copyright (c) 2021 Guy A. Ross
+// This source code is licensed under the GNU GPLv3 found in the
+// license file in the root directory of this source tree.
+
+package webwrap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/GuyARoss/orbit/pkg/jsparse"
+	parseerror "github.com/GuyARoss/orbit/pkg/parse_error"
+)
+
+// esbuildConfigExtension is the extension of the esbuild config of a page, which is
+// written in place of the webpack config of the page when the page is bundled by esbuild.
+const esbuildConfigExtension = ".esbuild.json"
+
+// esbuildConfig is the config of the esbuild bundle of a page
+type esbuildConfig struct {
+	Entry   string            `json:"entry"`
+	Outfile string            `json:"outfile"`
+	Mode    BundlerMode       `json:"mode"`
+	Alias   map[string]string `json:"alias,omitempty"`
+}
+
+// BundlerBackend is the bundler of the client bundles of the wrappers that are created with the base bundler
+type BundlerBackend string
+
+const (
+	WebpackBackend BundlerBackend = "webpack"
+	// ESBuildBackend bundles the pages of the wrappers that support esbuild (react, preact & javascript pages),
+	// the esbuild binary is native so these pages do not require node to be bundled.
+	ESBuildBackend BundlerBackend = "esbuild"
+)
+
+var ErrESBuildUnsupported = errors.New("the pages of the wrapper cannot be bundled by esbuild, the wrapper requires the webpack backend")
+
+// usesESBuild reports whether the client bundles of the wrapper are bundled by esbuild rather than by webpack
+func (b *BaseBundler) usesESBuild() bool {
+	return b.Backend == ESBuildBackend
+}
+
+// withBackend copies the base bundler with another backend, so that a wrapper can be bundled by another bundler
+// than the other wrappers e.g vue & svelte pages fall back to webpack as they are not supported by esbuild.
+func (b *BaseBundler) withBackend(backend BundlerBackend) *BaseBundler {
+	bundler := *b
+	bundler.Backend = backend
+
+	return &bundler
+}
+
+// esbuildConfigurator creates the configurator of the esbuild config of the page, the bundle is output to the
+// same directory as the webpack bundles (".orbit/dist" for the default ".orbit/base/pages" output directory).
+func (b *BaseBundler) esbuildConfigurator(settings *BundleOpts, entryFilePath string, alias map[string]string) BundleConfigurator {
+	config, _ := json.MarshalIndent(&esbuildConfig{
+		Entry:   entryFilePath,
+		Outfile: path.Join(b.BundleOutputDir(), fmt.Sprintf("%s.js", settings.BundleKey)),
+		Mode:    b.Mode,
+		Alias:   alias,
+	}, "", "\t")
+
+	page := jsparse.NewEmptyDocument()
+	page.AddOther(string(config))
+
+	return BundleConfigurator{
+		FilePath: fmt.Sprintf("%s/%s%s", b.PageOutputDir, settings.BundleKey, esbuildConfigExtension),
+		Page:     page,
+	}
+}
+
+// esbuildArgs creates the cli args of the esbuild config, the loaders mirror the loaders of the base
+// webpack config. css is output to its own file as the css modules of "css-loader" are not supported.
+func esbuildArgs(config *esbuildConfig) []string {
+	args := []string{
+		config.Entry,
+		"--bundle",
+		fmt.Sprintf("--outfile=%s", config.Outfile),
+		"--platform=browser",
+		"--format=iife",
+		"--target=es2017",
+		"--log-level=error",
+		"--loader:.js=jsx",
+		"--loader:.html=text",
+		fmt.Sprintf(`--define:process.env.NODE_ENV="%s"`, config.Mode),
+	}
+
+	if config.Mode == ProductionBundle {
+		args = append(args, "--minify")
+	}
+
+	// the aliases are sorted so that the args of the same config are always the same
+	modules := make([]string, 0, len(config.Alias))
+	for module := range config.Alias {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+
+	for _, module := range modules {
+		args = append(args, fmt.Sprintf("--alias:%s=%s", module, config.Alias[module]))
+	}
+
+	return args
+}
+
+// esbuildPath is the path of the esbuild binary that is installed with the "esbuild" node module
+func (b *BaseBundler) esbuildPath() string {
+	// windows does not support the shebang cmds of ".bin", so we prefer the native binary of the module instead.
+	if runtime.GOOS == "windows" {
+		return fmt.Sprintf("%s%cesbuild%cesbuild.exe", b.NodeModulesDir, os.PathSeparator, os.PathSeparator)
+	}
+
+	return fmt.Sprintf("%s%c%s%c%s", b.NodeModulesDir, os.PathSeparator, ".bin", os.PathSeparator, "esbuild")
+}
+
+func (b *BaseBundler) verifyESBuild() error {
+	if _, err := os.Stat(b.esbuildPath()); err != nil {
+		return fmt.Errorf("node module not found: esbuild. It is possible that you need to run `npm i esbuild` in your workspace directory to remedy this issue")
+	}
+
+	return nil
+}
+
+// esbuildBundle bundles the page with the esbuild config that is found at the configurator file path,
+// the node modules of the bundle are resolved from "NodeModulesDir".
+func (b *BaseBundler) esbuildBundle(configuratorFilePath string, filePath string) error {
+	data, err := os.ReadFile(configuratorFilePath)
+	if err != nil {
+		return err
+	}
+
+	config := &esbuildConfig{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return err
+	}
+
+	args := esbuildArgs(config)
+
+	cmd := exec.Command(b.esbuildPath(), args...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("NODE_PATH=%s", b.NodeModulesDir))
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if b.Logger != nil {
+			b.Logger.Warn(fmt.Sprintf(`invalid pack: "esbuild %s"\n "%s"`, strings.Join(args, " "), string(output)))
+		}
+
+		return parseerror.New("failed to bundle, this could denote a syntax error", filePath)
+	}
+
+	return nil
+}
+
+// isESBuildConfig reports whether the configurator file is an esbuild config
+func isESBuildConfig(configuratorFilePath string) bool {
+	return strings.HasSuffix(configuratorFilePath, esbuildConfigExtension)
+}
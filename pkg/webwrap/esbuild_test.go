@@ -0,0 +1,145 @@
+// Copyright (c) 2021 Guy A. Ross
+// This source code is licensed under the GNU GPLv3 found in the
+// license file in the root directory of this source tree.
+
+package webwrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestESBuildArgs(t *testing.T) {
+	args := strings.Join(esbuildArgs(&esbuildConfig{
+		Entry:   ".orbit/base/pages/abc.tsx",
+		Outfile: ".orbit/dist/abc.js",
+		Mode:    ProductionBundle,
+		Alias:   map[string]string{"react-dom": "preact/compat", "react": "preact/compat"},
+	}), " ")
+
+	for _, expected := range []string{
+		".orbit/base/pages/abc.tsx --bundle --outfile=.orbit/dist/abc.js",
+		`--define:process.env.NODE_ENV="production"`,
+		"--minify",
+		"--alias:react=preact/compat --alias:react-dom=preact/compat",
+	} {
+		if !strings.Contains(args, expected) {
+			t.Errorf("expected args to contain '%s' got '%s'", expected, args)
+		}
+	}
+
+	if args := strings.Join(esbuildArgs(&esbuildConfig{Mode: DevelopmentBundle}), " "); strings.Contains(args, "--minify") {
+		t.Errorf("expected development bundles to not be minified got '%s'", args)
+	}
+}
+
+func TestSetup_ESBuild(t *testing.T) {
+	bundler := &BaseBundler{PageOutputDir: ".orbit/base/pages", Mode: DevelopmentBundle, Backend: ESBuildBackend}
+	settings := &BundleOpts{FileName: "pages/home.jsx", BundleKey: "abc", Name: "Home"}
+
+	tt := []struct {
+		wrapper JSWebWrapper
+		config  string
+	}{
+		{NewReactCSR(bundler), `"outfile": ".orbit/dist/abc.js"`},
+		{NewPreactCSR(bundler), `"react": "preact/compat"`},
+		{&JavascriptWrap{BaseBundler: bundler}, `"entry": ".orbit/base/pages/abc.js"`},
+		{NewReactHydrate(bundler), `"entry": ".orbit/base/pages/abc.js"`},
+	}
+
+	for _, d := range tt {
+		resource, err := d.wrapper.Setup(context.Background(), settings)
+		if err != nil {
+			t.Errorf("did not expect error '%s'", err)
+			continue
+		}
+
+		client := resource.Configurators[len(resource.Configurators)-1]
+		if client.FilePath != ".orbit/base/pages/abc.esbuild.json" {
+			t.Errorf("expected the esbuild config of '%s' got '%s'", d.wrapper.Version(), client.FilePath)
+		}
+
+		if config := strings.Join(client.Page.Other(), ""); !strings.Contains(config, d.config) {
+			t.Errorf("expected the config of '%s' to contain '%s' got '%s'", d.wrapper.Version(), d.config, config)
+		}
+
+		if d.wrapper.Stats().Bundler != "esbuild" {
+			t.Errorf("expected '%s' to report the esbuild bundler got '%s'", d.wrapper.Version(), d.wrapper.Stats().Bundler)
+		}
+	}
+
+	for _, wrapper := range []JSWebWrapper{NewVueCSR(bundler), NewSvelteCSR(bundler)} {
+		if err := wrapper.VerifyRequirements(); !errors.Is(err, ErrESBuildUnsupported) {
+			t.Errorf("expected '%s' to require the webpack backend got '%v'", wrapper.Version(), err)
+		}
+	}
+}
+
+func TestNewActiveMap_ESBuildFallback(t *testing.T) {
+	bundler := &BaseBundler{PageOutputDir: ".orbit/base/pages", Backend: ESBuildBackend, ReactVersion: 16}
+
+	for _, wrapper := range NewActiveMap(bundler) {
+		expected := "esbuild"
+		switch wrapper.(type) {
+		case *VueCSR, *SvelteCSR:
+			// vue & svelte pages fall back to webpack, as they are not supported by esbuild
+			expected = "webpack"
+		}
+
+		if wrapper.Stats().Bundler != expected {
+			t.Errorf("expected '%s' to be bundled by '%s' got '%s'", wrapper.Version(), expected, wrapper.Stats().Bundler)
+		}
+	}
+
+	if bundler.Backend != ESBuildBackend {
+		t.Error("expected the fallback to not change the backend of the other wrappers")
+	}
+}
+
+func TestESBuildBundle(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the esbuild binary is replaced by a shell script")
+	}
+
+	dir := t.TempDir()
+	os.MkdirAll(fmt.Sprintf("%s/node_modules/.bin", dir), 0755)
+	os.MkdirAll(fmt.Sprintf("%s/.orbit/base/pages", dir), 0755)
+
+	// the script records the args & the node path that it is invoked with
+	script := fmt.Sprintf("#!/bin/sh\necho \"$NODE_PATH $@\" > %s/args\n", dir)
+	os.WriteFile(fmt.Sprintf("%s/node_modules/.bin/esbuild", dir), []byte(script), 0755)
+
+	bundler := &BaseBundler{
+		PageOutputDir:  fmt.Sprintf("%s/.orbit/base/pages", dir),
+		NodeModulesDir: fmt.Sprintf("%s/node_modules", dir),
+		Mode:           ProductionBundle,
+		Backend:        ESBuildBackend,
+	}
+
+	wrapper := NewReactCSR(bundler)
+	if err := wrapper.VerifyRequirements(); err != nil {
+		t.Errorf("did not expect error '%s'", err)
+	}
+
+	resource, _ := wrapper.Setup(context.Background(), &BundleOpts{FileName: "pages/home.jsx", BundleKey: "abc", Name: "Home"})
+
+	config := resource.Configurators[0]
+	config.Page.WriteFile(config.FilePath)
+
+	if err := wrapper.Bundle(config.FilePath, "pages/home.jsx"); err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	args, _ := os.ReadFile(fmt.Sprintf("%s/args", dir))
+	for _, expected := range []string{fmt.Sprintf("%s/node_modules ", dir), fmt.Sprintf("--outfile=%s/.orbit/dist/abc.js", dir), "--minify"} {
+		if !strings.Contains(string(args), expected) {
+			t.Errorf("expected esbuild to be invoked with '%s' got '%s'", expected, string(args))
+		}
+	}
+}
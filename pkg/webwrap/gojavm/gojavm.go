@@ -0,0 +1,87 @@
+// Copyright (c) 2021 Guy A. Ross
+// This source code is licensed under the GNU GPLv3 found in the
+// license file in the root directory of this source tree.
+
+//go:build goja
+
+// gojavm provides the goja javascript engine to the embedded ssr renderer, it is only built with
+// the "goja" build tag so that the engine is not a dependency of apps that do not use it.
+// e.g "go build -tags goja"
+package gojavm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dop251/goja"
+)
+
+var (
+	ErrNoEntryPoint  = errors.New("server bundle does not define the global 'buildStaticContent' function")
+	ErrPendingRender = errors.New("server bundle render did not resolve, the render cannot wait on io")
+)
+
+// buildSource wraps the "buildStaticContent" function of the server bundle, so that the request & response are exchanged as json.
+// renders that are async (e.g vue pages) return a promise of the json, which is resolved by the job queue of the runtime.
+const buildSource = `(request) => Promise.resolve(buildStaticContent(JSON.parse(request))).then((response) => JSON.stringify(response))`
+
+// VM is a single goja runtime that runs the server bundle, it implements the JSVM of the embedded ssr renderer
+// of both the webwrap package & of the generated packages.
+type VM struct {
+	rt    *goja.Runtime
+	build goja.Callable
+}
+
+// New creates a goja vm, this can be used by the NewJSVM of the embedded ssr renderer
+// e.g "NewJSVM = func() JSVM { return gojavm.New() }"
+func New() *VM {
+	return &VM{rt: goja.New()}
+}
+
+func (v *VM) Run(script string) error {
+	if _, err := v.rt.RunString(script); err != nil {
+		return err
+	}
+
+	if _, ok := goja.AssertFunction(v.rt.Get("buildStaticContent")); !ok {
+		return ErrNoEntryPoint
+	}
+
+	build, err := v.rt.RunString(buildSource)
+	if err != nil {
+		return err
+	}
+
+	v.build, _ = goja.AssertFunction(build)
+	return nil
+}
+
+func (v *VM) BuildStaticContent(request string) (string, error) {
+	// a previous render may have been interrupted, which would otherwise interrupt this render
+	v.rt.ClearInterrupt()
+
+	res, err := v.build(goja.Undefined(), v.rt.ToValue(request))
+	if err != nil {
+		return "", err
+	}
+
+	// the job queue of the runtime has been run once the call has returned, so the promise
+	// has settled unless the render waits on something that is never resolved by the vm.
+	promise, ok := res.Export().(*goja.Promise)
+	if !ok {
+		return res.String(), nil
+	}
+
+	switch promise.State() {
+	case goja.PromiseStateFulfilled:
+		return promise.Result().String(), nil
+	case goja.PromiseStateRejected:
+		return "", fmt.Errorf("server bundle render was rejected: %s", promise.Result().String())
+	default:
+		return "", ErrPendingRender
+	}
+}
+
+func (v *VM) Interrupt() {
+	v.rt.Interrupt("render deadline exceeded")
+}
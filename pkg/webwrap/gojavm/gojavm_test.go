@@ -0,0 +1,137 @@
+// Copyright (c) 2021 Guy A. Ross
+// This source code is licensed under the GNU GPLv3 found in the
+// license file in the root directory of this source tree.
+
+//go:build goja
+
+package gojavm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	webwrap "github.com/GuyARoss/orbit/pkg/webwrap/embed"
+)
+
+const testBundle = `
+globalThis.buildStaticContent = (request) => {
+	if (request.BundleID === 'loop') {
+		for (;;) {}
+	}
+
+	if (request.BundleID === 'async') {
+		return Promise.resolve('async:' + request.JSONData).then((StaticContent) => ({ StaticContent, Head: [], Status: 200 }))
+	}
+
+	if (request.BundleID === 'pending') {
+		return new Promise(() => {})
+	}
+
+	if (request.BundleID === 'rejected') {
+		return Promise.reject(new Error('render failed'))
+	}
+
+	return {
+		StaticContent: request.BundleID + ':' + request.JSONData,
+		Head: ['<title>' + request.Request.Path + '</title>'],
+		Status: 201,
+	}
+}
+`
+
+func TestVMPoolRender(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "react_ssr.vm.js")
+	os.WriteFile(path, []byte(testBundle), 0644)
+
+	pool, err := webwrap.NewVMPool(&webwrap.VMPoolOpts{
+		ScriptPath: path,
+		NewVM:      func() webwrap.JSVM { return New() },
+	})
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+	defer pool.Close()
+
+	res, err := pool.Render(context.Background(), &webwrap.RenderRequest{
+		BundleID: "page",
+		JSONData: "{}",
+		Request:  &webwrap.RequestContext{Path: "/page"},
+	})
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	if res.StaticContent != "page:{}" || res.Status != 201 || len(res.Head) != 1 || res.Head[0] != "<title>/page</title>" {
+		t.Errorf("unexpected render response '%v'", res)
+	}
+}
+
+func TestVMPoolRenderDeadline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "react_ssr.vm.js")
+	os.WriteFile(path, []byte(testBundle), 0644)
+
+	pool, err := webwrap.NewVMPool(&webwrap.VMPoolOpts{
+		ScriptPath:    path,
+		NewVM:         func() webwrap.JSVM { return New() },
+		RenderTimeout: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+	defer pool.Close()
+
+	if _, err := pool.Render(context.Background(), &webwrap.RenderRequest{BundleID: "loop"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the render to be interrupted got '%v'", err)
+	}
+
+	if _, err := pool.Render(context.Background(), &webwrap.RenderRequest{BundleID: "page", Request: &webwrap.RequestContext{}}); err != nil {
+		t.Errorf("expected the interrupted vm to render got '%s'", err)
+	}
+}
+
+func TestRun_NoEntryPoint(t *testing.T) {
+	if err := New().Run("const page = 1"); !errors.Is(err, ErrNoEntryPoint) {
+		t.Errorf("expected missing entry point error got '%v'", err)
+	}
+}
+
+func TestVMPoolRender_Async(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "react_ssr.vm.js")
+	os.WriteFile(path, []byte(testBundle), 0644)
+
+	pool, err := webwrap.NewVMPool(&webwrap.VMPoolOpts{
+		ScriptPath: path,
+		NewVM:      func() webwrap.JSVM { return New() },
+	})
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+	defer pool.Close()
+
+	res, err := pool.Render(context.Background(), &webwrap.RenderRequest{BundleID: "async", JSONData: "{}"})
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	if res.StaticContent != "async:{}" || res.Status != 200 {
+		t.Errorf("expected the resolved render response got '%v'", res)
+	}
+
+	if _, err := pool.Render(context.Background(), &webwrap.RenderRequest{BundleID: "pending"}); !errors.Is(err, ErrPendingRender) {
+		t.Errorf("expected pending render error got '%v'", err)
+	}
+
+	if _, err := pool.Render(context.Background(), &webwrap.RenderRequest{BundleID: "rejected"}); err == nil || !strings.Contains(err.Error(), "render failed") {
+		t.Errorf("expected the rejection of the render got '%v'", err)
+	}
+}
@@ -21,26 +21,33 @@ type JavascriptWrap struct {
 	*BaseBundler
 }
 
-const javascriptExtension string = "js"
+const (
+	javascriptExtension string = "js"
+	typescriptExtension string = "ts"
+)
 
 func (s *JavascriptWrap) DocumentTag(string) string { return "" }
 
 func (s *JavascriptWrap) Apply(page jsparse.JSDocument) (map[string]jsparse.JSDocument, error) {
-	if page.Extension() != javascriptExtension { // @@todo bad pattern fix this
+	if !s.DoesSatisfyConstraints(page) { // @@todo bad pattern fix this
 		return nil, fmt.Errorf("invalid extension %s", page.Extension())
 	}
 
 	page.AddOther(fmt.Sprintf(
 		`onLoadTasks.push(
-			() => %s({...JSON.parse(document.getElementById('orbit_manifest').textContent)})
+			() => %s({...%s})
 		)`,
-		page.Name()),
+		page.Name(), manifestProps(page.Key())),
 	)
 
 	return map[string]jsparse.JSDocument{"normal": page}, nil
 }
 
 func (b *JavascriptWrap) VerifyRequirements() error {
+	if b.usesESBuild() {
+		return b.verifyESBuild()
+	}
+
 	webpackPath := fmt.Sprintf("%s%c%s%c%s", b.NodeModulesDir, os.PathSeparator, ".bin", os.PathSeparator, "webpack")
 
 	// due to a "bug" with windows, it has an issue with shebang cmds, so we prefer the webpack.js file instead.
@@ -57,7 +64,7 @@ func (b *JavascriptWrap) VerifyRequirements() error {
 }
 
 func (s *JavascriptWrap) DoesSatisfyConstraints(page jsparse.JSDocument) bool {
-	return page.Extension() == javascriptExtension
+	return page.Extension() == javascriptExtension || page.Extension() == typescriptExtension
 }
 
 func (s *JavascriptWrap) Version() string {
@@ -65,6 +72,13 @@ func (s *JavascriptWrap) Version() string {
 }
 
 func (s *JavascriptWrap) Stats() *WrapStats {
+	if s.usesESBuild() {
+		return &WrapStats{
+			WebVersion: "javascript",
+			Bundler:    "esbuild",
+		}
+	}
+
 	return &WrapStats{
 		WebVersion: "javascript",
 		Bundler:    "webpack",
@@ -78,41 +92,34 @@ func (s *JavascriptWrap) RequiredBodyDOMElements(ctx context.Context, cache *Cac
 }
 
 func (b *JavascriptWrap) Setup(ctx context.Context, settings *BundleOpts) (*BundledResource, error) {
-	page := jsparse.NewEmptyDocument()
-
-	page.AddImport(&jsparse.ImportDependency{
-		FinalStatement: "const {merge} = require('webpack-merge')",
-		Type:           jsparse.ModuleImportType,
-	})
-
-	page.AddImport(&jsparse.ImportDependency{
-		FinalStatement: "const baseConfig = require('../../assets/base.config.js')",
-		Type:           jsparse.ModuleImportType,
-	})
-
-	outputFileName := fmt.Sprintf("%s.js", settings.BundleKey)
-	bundleFilePath := fmt.Sprintf("%s/%s.js", b.PageOutputDir, settings.BundleKey)
-
-	page.AddOther(fmt.Sprintf(`module.exports = merge(baseConfig, {
-		entry: ['./%s'],
-		mode: '%s',
-		output: {
-			filename: '%s'
-		},
-	})`, bundleFilePath, string(b.Mode), outputFileName))
+	if b.usesESBuild() {
+		bundleFilePath := fmt.Sprintf("%s/%s.%s", b.PageOutputDir, settings.BundleKey, settings.EntryExtension())
+
+		return &BundledResource{
+			BundleOpFileDescriptor: map[string]string{"normal": bundleFilePath},
+			Configurators:          []BundleConfigurator{b.esbuildConfigurator(settings, bundleFilePath, nil)},
+		}, nil
+	}
+
+	bundleFilePath := fmt.Sprintf("%s/%s.%s", b.PageOutputDir, settings.BundleKey, settings.EntryExtension())
 
 	return &BundledResource{
 		BundleOpFileDescriptor: map[string]string{"normal": bundleFilePath},
 		Configurators: []BundleConfigurator{
-			{
-				FilePath: fmt.Sprintf("%s/%s.config.js", b.PageOutputDir, settings.BundleKey),
-				Page:     page,
-			},
+			b.webpackConfigurator(&WebpackEntry{
+				BundleKey: settings.BundleKey,
+				FilePath:  bundleFilePath,
+				Requires:  []string{webpackBaseConfig},
+			}),
 		},
 	}, nil
 }
 
 func (b *JavascriptWrap) Bundle(configuratorFilePath string, filePath string) error {
+	if isESBuildConfig(configuratorFilePath) {
+		return b.esbuildBundle(configuratorFilePath, filePath)
+	}
+
 	webpackPath := fmt.Sprintf("%s%c%s%c%s", b.NodeModulesDir, os.PathSeparator, ".bin", os.PathSeparator, "webpack")
 
 	// due to a "bug" with windows, it has an issue with shebang cmds, so we prefer the webpack.js file instead.
@@ -0,0 +1,128 @@
+// Copyright (c) 2021 Guy A. Ross
+// This source code is licensed under the GNU GPLv3 found in the
+// license file in the root directory of this source tree.
+
+package webwrap
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/GuyARoss/orbit/pkg/embedutils"
+	"github.com/GuyARoss/orbit/pkg/jsparse"
+)
+
+// preactCompatConfig is the webpack config that resolves react imports to "preact/compat", so that
+// the components which are shared with the react pages can be rendered by preact.
+const preactCompatConfig = `{
+	resolve: {
+		alias: {
+			'react': 'preact/compat',
+			'react-dom': 'preact/compat',
+		},
+	},
+}`
+
+// PreactCSR renders jsx pages with preact on the client, pages are rendered by preact when they declare
+// it with "// orbit:framework preact" or when preact is the configured jsx framework.
+type PreactCSR struct {
+	*ReactCSR
+}
+
+func (s *PreactCSR) DocumentTag(key string) string {
+	return fmt.Sprintf(`<div id="%s_preact_frame"></div>`, key)
+}
+
+func (s *PreactCSR) Apply(page jsparse.JSDocument) (map[string]jsparse.JSDocument, error) {
+	if len(page.Name()) == 0 {
+		return nil, ErrInvalidComponent
+	}
+
+	if string(page.Name()[0]) != strings.ToUpper(string(page.Name()[0])) {
+		return nil, ErrComponentExport
+	}
+
+	// the imports are aliased as the page may already import "h" from preact
+	page.AddImport(&jsparse.ImportDependency{
+		FinalStatement: "import { h as orbitH, Fragment as OrbitFragment, render as orbitRender } from 'preact'",
+		Type:           jsparse.ModuleImportType,
+	})
+
+	page.AddOther(fmt.Sprintf(
+		`/** @jsx orbitH */
+/** @jsxFrag OrbitFragment */
+const data = %s;
+orbitRender(orbitH(%s, data), document.getElementById('%s_preact_frame'))`,
+		manifestProps(page.Key()), page.Name(), page.Key()),
+	)
+
+	return map[string]jsparse.JSDocument{"normal": page}, nil
+}
+
+func (s *PreactCSR) VerifyRequirements() error {
+	if err := s.ReactCSR.VerifyRequirements(); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(fmt.Sprintf("%s%cpreact", s.NodeModulesDir, os.PathSeparator)); err != nil {
+		return fmt.Errorf("node module not found: preact. It is possible that you need to run `npm i preact` in your workspace directory to remedy this issue")
+	}
+
+	return nil
+}
+
+func (s *PreactCSR) Version() string {
+	return "preactCSR"
+}
+
+func (s *PreactCSR) Stats() *WrapStats {
+	stats := s.ReactCSR.Stats()
+	stats.WebVersion = "Preact CSR"
+
+	return stats
+}
+
+// RequiredBodyDOMElements preact is bundled with each of the pages, so it does not require any elements
+func (s *PreactCSR) RequiredBodyDOMElements(ctx context.Context, cache *CacheDOMOpts) []string {
+	return []string{}
+}
+
+func (b *PreactCSR) Setup(ctx context.Context, settings *BundleOpts) (*BundledResource, error) {
+	if b.usesESBuild() {
+		bundleFilePath := fmt.Sprintf("%s/%s.%s", b.PageOutputDir, settings.BundleKey, settings.EntryExtension())
+		alias := map[string]string{"react": "preact/compat", "react-dom": "preact/compat"}
+
+		return &BundledResource{
+			BundleOpFileDescriptor: map[string]string{"normal": bundleFilePath},
+			Configurators:          []BundleConfigurator{b.esbuildConfigurator(settings, bundleFilePath, alias)},
+		}, nil
+	}
+
+	bundleFilePath := fmt.Sprintf("%s/%s.%s", b.PageOutputDir, settings.BundleKey, settings.EntryExtension())
+
+	return &BundledResource{
+		BundleOpFileDescriptor: map[string]string{"normal": bundleFilePath},
+		Configurators: []BundleConfigurator{
+			b.webpackConfigurator(&WebpackEntry{
+				BundleKey: settings.BundleKey,
+				FilePath:  bundleFilePath,
+				Requires:  []string{clientBaseConfig()},
+				Config:    preactCompatConfig,
+			}),
+		},
+	}, nil
+}
+
+func (b *PreactCSR) HydrationFile() []embedutils.FileReader {
+	return []embedutils.FileReader{&embedFileReader{fileName: "preact_csr.go"}}
+}
+
+func (b *PreactCSR) DoesSatisfyConstraints(page jsparse.JSDocument) bool {
+	return isJSXExtension(page.Extension()) && page.DefaultExport() != nil && b.PageFramework(page) == PreactFramework
+}
+
+func NewPreactCSR(bundler *BaseBundler) *PreactCSR {
+	return &PreactCSR{ReactCSR: NewReactCSR(bundler)}
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2021 Guy A. Ross
+// This source code is licensed under the GNU GPLv3 found in the
+// license file in the root directory of this source tree.
+
+package webwrap
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/GuyARoss/orbit/pkg/experiments"
+	"github.com/GuyARoss/orbit/pkg/jsparse/mock"
+)
+
+func TestApplyPreact(t *testing.T) {
+	page := islandsPage(t, `import { h } from 'preact'
+// orbit:framework preact
+
+const Home = ({ count }) => {
+	return <div>{count}</div>
+}
+
+export default Home`)
+
+	pages, err := NewPreactCSR(&BaseBundler{}).Apply(page)
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	source := strings.Join(pages["normal"].Other(), "\n")
+	for _, expected := range []string{"/** @jsx orbitH */", "orbitRender(orbitH(Home, data), document.getElementById('", "_preact_frame'))"} {
+		if !strings.Contains(source, expected) {
+			t.Errorf("expected page to contain '%s' got '%s'", expected, source)
+		}
+	}
+}
+
+func TestPreactSetup_BundleConfig(t *testing.T) {
+	resource, err := NewPreactCSR(&BaseBundler{PageOutputDir: ".orbit/base/pages", Mode: ProductionBundle}).Setup(context.Background(), &BundleOpts{
+		FileName:  "pages/home.jsx",
+		BundleKey: "abc",
+		Name:      "Home",
+	})
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	config := strings.Join(resource.Configurators[0].Page.Other(), "")
+	for _, expected := range []string{"'react': 'preact/compat'", "entry: ['./.orbit/base/pages/abc.js']"} {
+		if !strings.Contains(config, expected) {
+			t.Errorf("expected bundle config to contain '%s' got '%s'", expected, config)
+		}
+	}
+}
+
+func TestNewActiveMap_JSXFramework(t *testing.T) {
+	current := experiments.GlobalExperimentalFeatures
+	t.Cleanup(func() { experiments.GlobalExperimentalFeatures = current })
+
+	tt := []struct {
+		features  *experiments.Features
+		framework string
+		page      *mock.MockJsDocument
+		expected  string
+	}{
+		{&experiments.Features{}, "", mock.NewMockJSDocument("Home", "jsx", "Home"), "reactCSR"},
+		{&experiments.Features{}, "", mock.NewMockJSDocument("Home", "jsx", "Home").WithFramework("preact"), "preactCSR"},
+		{&experiments.Features{}, "preact", mock.NewMockJSDocument("Home", "jsx", "Home"), "preactCSR"},
+		{&experiments.Features{}, "preact", mock.NewMockJSDocument("Home", "jsx", "Home").WithFramework("react"), "reactCSR"},
+		{&experiments.Features{PreferSSR: true}, "preact", mock.NewMockJSDocument("Home", "jsx", "Home"), "preactCSR"},
+		{&experiments.Features{PreferSSR: true}, "", mock.NewMockJSDocument("Home", "jsx", "Home"), "reactHydrate"},
+		{&experiments.Features{}, "", mock.NewMockJSDocument("Counter", "svelte", "Counter"), "svelteCSR"},
+	}
+
+	for i, d := range tt {
+		experiments.GlobalExperimentalFeatures = d.features
+
+		wrapper := NewActiveMap(&BaseBundler{ReactVersion: 16, JSXFramework: d.framework}).FindFirst(d.page)
+		if wrapper == nil || wrapper.Version() != d.expected {
+			t.Errorf("(%d) expected '%s' got '%v'", i, d.expected, wrapper)
+		}
+	}
+}
+
+func TestPreactHydrationFile(t *testing.T) {
+	tt := []struct {
+		wrapper  JSWebWrapper
+		expected string
+	}{
+		{NewReactCSR(&BaseBundler{}), "react_csr.go"},
+		{NewPreactCSR(&BaseBundler{}), "preact_csr.go"},
+	}
+
+	for _, d := range tt {
+		files := d.wrapper.HydrationFile()
+		if len(files) != 1 {
+			t.Errorf("expected a single hydration file got %d", len(files))
+			continue
+		}
+
+		file, err := files[0].Read()
+		if err != nil {
+			t.Errorf("did not expect error '%s'", err)
+			continue
+		}
+
+		stat, _ := file.Stat()
+		if stat.Name() != d.expected {
+			t.Errorf("expected '%s' got '%s'", d.expected, stat.Name())
+		}
+	}
+}
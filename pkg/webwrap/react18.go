@@ -0,0 +1,95 @@
+// Copyright (c) 2021 Guy A. Ross
+// This source code is licensed under the GNU GPLv3 found in the
+// license file in the root directory of this source tree.
+
+package webwrap
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/GuyARoss/orbit/pkg/embedutils"
+)
+
+// ReactMajorVersion finds the major version of react that is targeted by the react wrappers, which is either
+// the configured "ReactVersion" or the version of the installed react package. 0 denotes an unknown version.
+func (b *BaseBundler) ReactMajorVersion() int {
+	if b.ReactVersion > 0 {
+		return b.ReactVersion
+	}
+
+	data, err := os.ReadFile(fmt.Sprintf("%s%creact%cpackage.json", b.NodeModulesDir, os.PathSeparator, os.PathSeparator))
+	if err != nil {
+		return 0
+	}
+
+	pkg := struct {
+		Version string `json:"version"`
+	}{}
+
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return 0
+	}
+
+	major, err := strconv.Atoi(strings.Split(pkg.Version, ".")[0])
+	if err != nil {
+		return 0
+	}
+
+	return major
+}
+
+// React18CSR renders react pages on the client with the react 18 "createRoot" api
+type React18CSR struct {
+	*ReactCSR
+}
+
+func (s *React18CSR) Version() string {
+	return "react18CSR"
+}
+
+func (s *React18CSR) Stats() *WrapStats {
+	stats := s.ReactCSR.Stats()
+	stats.WebVersion = "React 18 CSR"
+
+	return stats
+}
+
+func (s *React18CSR) HydrationFile() []embedutils.FileReader {
+	return append(s.ReactCSR.HydrationFile(), &embedFileReader{fileName: "react18_csr.go"})
+}
+
+func NewReact18CSR(bundler *BaseBundler) JSWebWrapper {
+	csr := NewReactCSR(bundler)
+	csr.reactRoot = true
+
+	return &React18CSR{ReactCSR: csr}
+}
+
+// React18Hydrate streams the server render of react pages with "renderToPipeableStream" & hydrates
+// them on the client with the react 18 "hydrateRoot" api
+type React18Hydrate struct {
+	*ReactHydrate
+}
+
+func (s *React18Hydrate) Version() string {
+	return "react18Hydrate"
+}
+
+func (s *React18Hydrate) Stats() *WrapStats {
+	stats := s.ReactHydrate.Stats()
+	stats.WebVersion = "React 18 Hydrate"
+
+	return stats
+}
+
+func (s *React18Hydrate) HydrationFile() []embedutils.FileReader {
+	return append(s.ReactHydrate.HydrationFile(), &embedFileReader{fileName: "react18_hydrate.go"})
+}
+
+func NewReact18Hydrate(bundler *BaseBundler) JSWebWrapper {
+	return &React18Hydrate{ReactHydrate: newReactHydrate(bundler, true)}
+}
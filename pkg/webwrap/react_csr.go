@@ -22,6 +22,8 @@ import (
 type ReactCSR struct {
 	*BaseWebWrapper
 	*BaseBundler
+	// reactRoot renders with the react 18 root api (createRoot/hydrateRoot) in place of the legacy render api
+	reactRoot bool
 }
 
 var ErrComponentExport = errors.New("prefer capitalization for jsx components")
@@ -41,21 +43,40 @@ func (s *ReactCSR) Apply(page jsparse.JSDocument) (map[string]jsparse.JSDocument
 		return nil, ErrComponentExport
 	}
 
+	if s.reactRoot {
+		page.AddImport(&jsparse.ImportDependency{
+			FinalStatement: "import { createRoot } from 'react-dom/client'",
+			Type:           jsparse.ModuleImportType,
+		})
+
+		page.AddOther(fmt.Sprintf(
+			`const data = %s;
+createRoot(document.getElementById('%s_react_frame')).render(<%s {...data}/>)`,
+			manifestProps(page.Key()), page.Key(), page.Name()),
+		)
+
+		return map[string]jsparse.JSDocument{"normal": page}, nil
+	}
+
 	page.AddImport(&jsparse.ImportDependency{
 		FinalStatement: "import ReactDOM from 'react-dom'",
 		Type:           jsparse.ModuleImportType,
 	})
 
 	page.AddOther(fmt.Sprintf(
-		`const data = !!document.getElementById('orbit_manifest')?.textContent ? JSON.parse(document.getElementById('orbit_manifest').textContent) : {};
+		`const data = %s;
 ReactDOM.render(<%s {...data}/>, document.getElementById('%s_react_frame'))`,
-		page.Name(), page.Key()),
+		manifestProps(page.Key()), page.Name(), page.Key()),
 	)
 
 	return map[string]jsparse.JSDocument{"normal": page}, nil
 }
 
 func (r *ReactCSR) VerifyRequirements() error {
+	if r.usesESBuild() {
+		return r.verifyESBuild()
+	}
+
 	webpackPath := fmt.Sprintf("%s%c%s%c%s", r.NodeModulesDir, os.PathSeparator, ".bin", os.PathSeparator, "webpack")
 
 	// due to a "bug" with windows, it has an issue with shebang cmds, so we prefer the webpack.js file instead.
@@ -76,6 +97,13 @@ func (s *ReactCSR) Version() string {
 }
 
 func (s *ReactCSR) Stats() *WrapStats {
+	if s.usesESBuild() {
+		return &WrapStats{
+			WebVersion: "React CSR",
+			Bundler:    "esbuild",
+		}
+	}
+
 	if experiments.GlobalExperimentalFeatures.PreferSWCCompiler {
 		return &WrapStats{
 			WebVersion: "React CSR",
@@ -95,14 +123,20 @@ func (s *ReactCSR) RequiredBodyDOMElements(ctx context.Context, cache *CacheDOMO
 		mode = ctx.Value(BundlerID).(string)
 	}
 
+	// the root api is only available from react 18, so the umd builds are pinned to the same major version
+	react, reactDOM := "react", "react-dom"
+	if s.reactRoot {
+		react, reactDOM = "react@18", "react-dom@18"
+	}
+
 	uris := make([]string, 0)
 	switch BundlerMode(mode) {
 	case DevelopmentBundle:
-		uris = append(uris, "https://unpkg.com/react/umd/react.development.js")
-		uris = append(uris, "https://unpkg.com/react-dom/umd/react-dom.development.js")
+		uris = append(uris, fmt.Sprintf("https://unpkg.com/%s/umd/react.development.js", react))
+		uris = append(uris, fmt.Sprintf("https://unpkg.com/%s/umd/react-dom.development.js", reactDOM))
 	default:
-		uris = append(uris, "https://unpkg.com/react/umd/react.production.min.js")
-		uris = append(uris, "https://unpkg.com/react-dom/umd/react-dom.production.min.js")
+		uris = append(uris, fmt.Sprintf("https://unpkg.com/%s/umd/react.production.min.js", react))
+		uris = append(uris, fmt.Sprintf("https://unpkg.com/%s/umd/react-dom.production.min.js", reactDOM))
 	}
 
 	files, _ := cache.CacheWebRequest(uris)
@@ -117,48 +151,34 @@ func (s *ReactCSR) RequiredBodyDOMElements(ctx context.Context, cache *CacheDOMO
 }
 
 func (b *ReactCSR) Setup(ctx context.Context, settings *BundleOpts) (*BundledResource, error) {
-	page := jsparse.NewEmptyDocument()
+	if b.usesESBuild() {
+		bundleFilePath := fmt.Sprintf("%s/%s.%s", b.PageOutputDir, settings.BundleKey, settings.EntryExtension())
 
-	page.AddImport(&jsparse.ImportDependency{
-		FinalStatement: "const {merge} = require('webpack-merge')",
-		Type:           jsparse.ModuleImportType,
-	})
-
-	if experiments.GlobalExperimentalFeatures.PreferSWCCompiler {
-		page.AddImport(&jsparse.ImportDependency{
-			FinalStatement: "const baseConfig = require('../../assets/swc-base.config.js')",
-			Type:           jsparse.ModuleImportType,
-		})
-	} else {
-		page.AddImport(&jsparse.ImportDependency{
-			FinalStatement: "const baseConfig = require('../../assets/base.config.js')",
-			Type:           jsparse.ModuleImportType,
-		})
+		return &BundledResource{
+			BundleOpFileDescriptor: map[string]string{"normal": bundleFilePath},
+			Configurators:          []BundleConfigurator{b.esbuildConfigurator(settings, bundleFilePath, nil)},
+		}, nil
 	}
 
-	outputFileName := fmt.Sprintf("%s.js", settings.BundleKey)
-	bundleFilePath := fmt.Sprintf("%s/%s.js", b.PageOutputDir, settings.BundleKey)
-
-	page.AddOther(fmt.Sprintf(`module.exports = merge(baseConfig, {
-		entry: ['./%s'],
-		mode: '%s',
-		output: {
-			filename: '%s'
-		},
-	})`, bundleFilePath, string(b.Mode), outputFileName))
+	bundleFilePath := fmt.Sprintf("%s/%s.%s", b.PageOutputDir, settings.BundleKey, settings.EntryExtension())
 
 	return &BundledResource{
 		BundleOpFileDescriptor: map[string]string{"normal": bundleFilePath},
 		Configurators: []BundleConfigurator{
-			{
-				FilePath: fmt.Sprintf("%s/%s.config.js", b.PageOutputDir, settings.BundleKey),
-				Page:     page,
-			},
+			b.webpackConfigurator(&WebpackEntry{
+				BundleKey: settings.BundleKey,
+				FilePath:  bundleFilePath,
+				Requires:  []string{clientBaseConfig()},
+			}),
 		},
 	}, nil
 }
 
 func (b *ReactCSR) Bundle(configuratorFilePath string, filePath string) error {
+	if isESBuildConfig(configuratorFilePath) {
+		return b.esbuildBundle(configuratorFilePath, filePath)
+	}
+
 	webpackPath := fmt.Sprintf("%s%c%s%c%s", b.NodeModulesDir, os.PathSeparator, ".bin", os.PathSeparator, "webpack")
 
 	// due to a "bug" with windows, it has an issue with shebang cmds, so we prefer the webpack.js file instead.
@@ -184,7 +204,7 @@ func (b *ReactCSR) HydrationFile() []embedutils.FileReader {
 	}
 
 	for _, file := range files {
-		if strings.Contains(file.Name(), "react_csr.go") {
+		if file.Name() == "react_csr.go" {
 			return []embedutils.FileReader{&embedFileReader{fileName: file.Name()}}
 		}
 	}
@@ -192,7 +212,7 @@ func (b *ReactCSR) HydrationFile() []embedutils.FileReader {
 }
 
 func (b *ReactCSR) DoesSatisfyConstraints(page jsparse.JSDocument) bool {
-	return page.Extension() == "jsx" && page.DefaultExport() != nil
+	return isJSXExtension(page.Extension()) && page.DefaultExport() != nil && b.PageFramework(page) == ReactFramework
 }
 
 func NewReactCSR(bundler *BaseBundler) *ReactCSR {
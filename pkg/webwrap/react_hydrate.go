@@ -29,17 +29,31 @@ func (s *ReactHydrate) Apply(page jsparse.JSDocument) (map[string]jsparse.JSDocu
 		return nil, ErrComponentExport
 	}
 
+	// the frame of a page that failed to server side render is marked by the server, so that the page is rendered rather than hydrated
 	csrHydratePage := page.Clone()
 
-	csrHydratePage.AddImport(&jsparse.ImportDependency{
-		FinalStatement: "import ReactDOM from 'react-dom'",
-		Type:           jsparse.ModuleImportType,
-	})
+	if s.csr.reactRoot {
+		csrHydratePage.AddImport(&jsparse.ImportDependency{
+			FinalStatement: "import { createRoot, hydrateRoot } from 'react-dom/client'",
+			Type:           jsparse.ModuleImportType,
+		})
+
+		csrHydratePage.AddOther(fmt.Sprintf(`const orbitFrame = document.getElementById('%s_react_frame')
+if (orbitFrame.dataset.orbitCsr) {
+	createRoot(orbitFrame).render(React.createElement(%s, %s))
+} else {
+	hydrateRoot(orbitFrame, React.createElement(%s, %s))
+}`, page.Key(), page.Name(), manifestProps(page.Key()), page.Name(), manifestProps(page.Key())))
+	} else {
+		csrHydratePage.AddImport(&jsparse.ImportDependency{
+			FinalStatement: "import ReactDOM from 'react-dom'",
+			Type:           jsparse.ModuleImportType,
+		})
 
-	csrHydratePage.AddOther("// testing", fmt.Sprintf(
-		"ReactDOM.hydrate(React.createElement(%s, JSON.parse(document.getElementById('orbit_manifest').textContent)), document.getElementById('%s_react_frame'))",
-		page.Name(), page.Key()),
-	)
+		csrHydratePage.AddOther(fmt.Sprintf(`const orbitFrame = document.getElementById('%s_react_frame')
+const orbitRender = orbitFrame.dataset.orbitCsr ? ReactDOM.render : ReactDOM.hydrate
+orbitRender(React.createElement(%s, %s), orbitFrame)`, page.Key(), page.Name(), manifestProps(page.Key())))
+	}
 
 	ssrPage, err := s.ssr.Apply(page.Clone())
 	if err != nil {
@@ -63,6 +77,14 @@ func (s *ReactHydrate) Version() string {
 }
 
 func (s *ReactHydrate) Stats() *WrapStats {
+	// only the client bundle is bundled by esbuild, the server bundle targets node so it is bundled by webpack
+	if s.csr.usesESBuild() {
+		return &WrapStats{
+			WebVersion: "React Hydrate",
+			Bundler:    "esbuild",
+		}
+	}
+
 	if experiments.GlobalExperimentalFeatures.PreferSWCCompiler {
 		return &WrapStats{
 			WebVersion: "React Hydrate",
@@ -81,80 +103,45 @@ func (s *ReactHydrate) RequiredBodyDOMElements(ctx context.Context, cache *Cache
 }
 
 func (b *ReactHydrate) Setup(ctx context.Context, settings *BundleOpts) (*BundledResource, error) {
-	page := jsparse.NewEmptyDocument()
-
-	page.AddImport(&jsparse.ImportDependency{
-		FinalStatement: "const {merge} = require('webpack-merge')",
-		Type:           jsparse.ModuleImportType,
-	})
-
-	if experiments.GlobalExperimentalFeatures.PreferSWCCompiler {
-		page.AddImport(&jsparse.ImportDependency{
-			FinalStatement: "const baseConfig = require('../../assets/swc-base.config.js')",
-			Type:           jsparse.ModuleImportType,
-		})
-	} else {
-		page.AddImport(&jsparse.ImportDependency{
-			FinalStatement: "const baseConfig = require('../../assets/base.config.js')",
-			Type:           jsparse.ModuleImportType,
-		})
-	}
-
-	outputFileName := fmt.Sprintf("%s.js", settings.BundleKey)
-	clientBundleFilePath := fmt.Sprintf("%s/%s.js", b.csr.PageOutputDir, settings.BundleKey)
-
-	page.AddOther(fmt.Sprintf(`module.exports = merge(baseConfig, {
-		entry: ['./%s'],
-		mode: '%s',
-		output: {
-			filename: '%s'
-		},
-	})`, clientBundleFilePath, string(b.csr.Mode), outputFileName))
+	clientBundleFilePath := fmt.Sprintf("%s/%s.%s", b.csr.PageOutputDir, settings.BundleKey, settings.EntryExtension())
+	serverFileName := fmt.Sprintf("%s.ssr.%s", settings.BundleKey, settings.EntryExtension())
 
 	b.ssr.sourceMapDoc.AddImport(&jsparse.ImportDependency{
-		FinalStatement: fmt.Sprintf("import %s from '%s'", settings.Name, fmt.Sprintf("./%s.ssr.js", settings.BundleKey)),
+		FinalStatement: fmt.Sprintf("import %s from './%s'", settings.Name, serverFileName),
 		Type:           jsparse.LocalImportType,
 	})
 
-	b.ssr.sourceMapDoc.AddOther(fmt.Sprintf(`export const %s = (d) => ReactDOMServer.renderToString(<%s {...d}/>)`, strings.ToLower(settings.Name), settings.Name))
-	b.ssr.initDoc.AddImport(&jsparse.ImportDependency{
-		FinalStatement: fmt.Sprintf("import { %s } from '%s'", strings.ToLower(settings.Name), fmt.Sprintf("./%s", "react_ssr.map.js")),
-		Type:           jsparse.LocalImportType,
+	b.ssr.addRenderFunc(settings)
+
+	client := b.csr.webpackConfigurator(&WebpackEntry{
+		BundleKey: settings.BundleKey,
+		FilePath:  clientBundleFilePath,
+		Requires:  []string{clientBaseConfig()},
 	})
 
-	b.ssr.jsSwitch.Add(jsparse.JSString, settings.BundleKey, fmt.Sprintf(`return %s(JSON.parse(JSONData))`, strings.ToLower(settings.Name)))
+	if b.csr.usesESBuild() {
+		client = b.csr.esbuildConfigurator(settings, clientBundleFilePath, nil)
+	}
 
 	return &BundledResource{
 		BundleOpFileDescriptor: map[string]string{
 			"csr": clientBundleFilePath,
-			"ssr": fmt.Sprintf("%s/%s.ssr.js", b.ssr.PageOutputDir, settings.BundleKey),
-		},
-		Configurators: []BundleConfigurator{
-			{
-				FilePath: fmt.Sprintf("%s/react_ssr.map.js", b.ssr.PageOutputDir),
-				Page:     b.ssr.sourceMapDoc,
-			}, {
-				FilePath: fmt.Sprintf("%s/react_ssr.js", b.ssr.PageOutputDir),
-				Page:     b.ssr.initDoc,
-			},
-			{
-				FilePath: fmt.Sprintf("%s/%s.config.js", b.csr.PageOutputDir, settings.BundleKey),
-				Page:     page,
-			},
+			"ssr": fmt.Sprintf("%s/%s", b.ssr.PageOutputDir, serverFileName),
 		},
+		Configurators: append(b.ssr.serverConfigurators(), client),
 	}, nil
 }
 
 func (b *ReactHydrate) Bundle(configuratorFilePath string, filePath string) error {
 	if strings.Contains(configuratorFilePath, "ssr") { // todo: avoid doing this.
-		return nil
+		return b.ssr.Bundle(configuratorFilePath, filePath)
 	}
 
 	return b.csr.Bundle(configuratorFilePath, filePath)
 }
 
 func (b *ReactHydrate) DoesSatisfyConstraints(page jsparse.JSDocument) bool {
-	return page.Extension() == "jsx" && page.DefaultExport() != nil
+	return b.csr.DoesSatisfyConstraints(page)
 }
 
 func (b *ReactHydrate) HydrationFile() []embedutils.FileReader {
@@ -172,6 +159,10 @@ func (b *ReactHydrate) HydrationFile() []embedutils.FileReader {
 			u = append(u, &embedFileReader{fileName: file.Name()})
 			continue
 		}
+		if strings.Contains(file.Name(), "ssr_pool.go") || strings.Contains(file.Name(), "ssr_vm.go") || strings.Contains(file.Name(), "ssr_proc_") {
+			u = append(u, &embedFileReader{fileName: file.Name()})
+			continue
+		}
 		if strings.Contains(file.Name(), "pb.go") {
 			u = append(u, &embedFileReader{fileName: file.Name()})
 		}
@@ -180,12 +171,21 @@ func (b *ReactHydrate) HydrationFile() []embedutils.FileReader {
 }
 
 func NewReactHydrate(bundler *BaseBundler) JSWebWrapper {
+	return newReactHydrate(bundler, false)
+}
+
+// newReactHydrate creates the hydrate wrapper, pages are streamed by the server renderer when the react root api is used
+func newReactHydrate(bundler *BaseBundler, reactRoot bool) *ReactHydrate {
+	csr := NewReactCSR(bundler)
+	csr.reactRoot = reactRoot
+
 	return &ReactHydrate{
-		csr: NewReactCSR(bundler),
+		csr: csr,
 		ssr: NewReactSSRPartial(&NewReactSSROpts{
 			Bundler:      bundler,
 			SourceMapDoc: jsparse.NewEmptyDocument(),
 			InitDoc:      jsparse.NewEmptyDocument(),
+			Streaming:    reactRoot,
 		}),
 	}
 }
@@ -0,0 +1,201 @@
+// Copyright (c) 2021 Guy A. Ross
+// This source code is licensed under the GNU GPLv3 found in the
+// license file in the root directory of this source tree.
+
+package webwrap
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/GuyARoss/orbit/pkg/embedutils"
+	"github.com/GuyARoss/orbit/pkg/jsparse"
+)
+
+// ReactIslands server side renders react pages like ReactHydrate, but rather than hydrating the entire page
+// only the components declared as islands (e.g "// orbit:island Counter") are bundled for & hydrated on the client.
+type ReactIslands struct {
+	*ReactHydrate
+}
+
+var ErrIslandNotImported = errors.New("island is not a default import of the page")
+
+// islandSource creates the island components of the server page, each island is rendered within
+// an element that marks it for hydration along with its props. the props of an island are expected to be serializable.
+const islandSource = `const orbitIsland = (name, Island) => (props) => React.createElement(
+	'div',
+	{ 'data-orbit-island': name, 'data-orbit-props': JSON.stringify(props) },
+	React.createElement(Island, props),
+)`
+
+// islandImportName is the name that the island component is imported as within the server page
+func islandImportName(island string) string {
+	return fmt.Sprintf("OrbitIsland%s", island)
+}
+
+// islandImport finds the default import of the island within the page
+func islandImport(page jsparse.JSDocument, island string) *jsparse.ImportDependency {
+	match := regexp.MustCompile(fmt.Sprintf(`^import\s+%s\b`, regexp.QuoteMeta(island)))
+
+	for _, imp := range page.Imports() {
+		if match.MatchString(strings.TrimSpace(imp.FinalStatement)) {
+			return imp
+		}
+	}
+
+	return nil
+}
+
+// HasIslands verifies that the page is wrapped with islands & that it declares islands, these pages
+// require their client bundle so they cannot be served as a static resource.
+func HasIslands(wrapper JSWebWrapper, page jsparse.JSDocument) bool {
+	_, ok := wrapper.(*ReactIslands)
+
+	return ok && len(page.OrbitIslands()) > 0
+}
+
+// serverPage creates the server rendered page, the islands that it imports are replaced with
+// components that mark their rendered content for hydration.
+func (s *ReactIslands) serverPage(page jsparse.JSDocument) (jsparse.JSDocument, error) {
+	doc := jsparse.NewEmptyDocument()
+
+	islands := make(map[*jsparse.ImportDependency]string)
+	for _, island := range page.OrbitIslands() {
+		imp := islandImport(page, island)
+		if imp == nil {
+			return nil, fmt.Errorf("%w: '%s'", ErrIslandNotImported, island)
+		}
+
+		islands[imp] = island
+	}
+
+	hasReactImport := false
+	for _, imp := range page.Imports() {
+		if strings.Contains(imp.FinalStatement, "import React from 'react'") {
+			hasReactImport = true
+		}
+
+		island, ok := islands[imp]
+		if !ok {
+			doc.AddImport(imp)
+			continue
+		}
+
+		doc.AddImport(&jsparse.ImportDependency{
+			FinalStatement: strings.Replace(imp.FinalStatement, island, islandImportName(island), 1),
+			InitialPath:    imp.InitialPath,
+			Type:           imp.Type,
+		})
+	}
+
+	if !hasReactImport {
+		doc.AddImport(&jsparse.ImportDependency{
+			FinalStatement: "import React from 'react'",
+			Type:           jsparse.ModuleImportType,
+		})
+	}
+
+	if len(islands) > 0 {
+		doc.AddOther(islandSource)
+	}
+
+	for _, island := range page.OrbitIslands() {
+		doc.AddOther(fmt.Sprintf("const %s = orbitIsland('%s', %s)", island, island, islandImportName(island)))
+	}
+
+	doc.AddOther(page.Other()...)
+	doc.AddOther(fmt.Sprintf("export default %s", page.Name()))
+
+	return doc, nil
+}
+
+// clientPage creates the client bundle of the page, which only includes the islands of the page. each of
+// the top level islands within the react frame of the page are hydrated with the props that they were rendered with.
+func (s *ReactIslands) clientPage(page jsparse.JSDocument) jsparse.JSDocument {
+	doc := jsparse.NewEmptyDocument()
+
+	doc.AddImport(&jsparse.ImportDependency{
+		FinalStatement: "import React from 'react'",
+		Type:           jsparse.ModuleImportType,
+	})
+
+	hydrate := "ReactDOM.hydrate(React.createElement(Island, props), element)"
+	if s.csr.reactRoot {
+		hydrate = "hydrateRoot(element, React.createElement(Island, props))"
+
+		doc.AddImport(&jsparse.ImportDependency{
+			FinalStatement: "import { hydrateRoot } from 'react-dom/client'",
+			Type:           jsparse.ModuleImportType,
+		})
+	} else {
+		doc.AddImport(&jsparse.ImportDependency{
+			FinalStatement: "import ReactDOM from 'react-dom'",
+			Type:           jsparse.ModuleImportType,
+		})
+	}
+
+	for _, island := range page.OrbitIslands() {
+		if imp := islandImport(page, island); imp != nil {
+			doc.AddImport(imp)
+		}
+	}
+
+	doc.AddOther(fmt.Sprintf(`const orbitIslands = { %s }
+
+document.querySelectorAll('#%s_react_frame [data-orbit-island]').forEach((element) => {
+	const Island = orbitIslands[element.dataset.orbitIsland]
+	if (!Island || element.parentElement.closest('[data-orbit-island]')) {
+		return
+	}
+
+	const props = JSON.parse(element.dataset.orbitProps || '{}')
+	%s
+})`, strings.Join(page.OrbitIslands(), ", "), page.Key(), hydrate))
+
+	return doc
+}
+
+func (s *ReactIslands) Apply(page jsparse.JSDocument) (map[string]jsparse.JSDocument, error) {
+	if len(page.Name()) == 0 {
+		return nil, ErrInvalidComponent
+	}
+
+	// react components should always be capitalized.
+	if string(page.Name()[0]) != strings.ToUpper(string(page.Name()[0])) {
+		return nil, ErrComponentExport
+	}
+
+	ssrPage, err := s.serverPage(page)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]jsparse.JSDocument{
+		"csr": s.clientPage(page),
+		"ssr": ssrPage,
+	}, nil
+}
+
+func (s *ReactIslands) Version() string {
+	return "reactIslands"
+}
+
+func (s *ReactIslands) Stats() *WrapStats {
+	stats := s.ReactHydrate.Stats()
+	stats.WebVersion = "React Islands"
+
+	return stats
+}
+
+func (s *ReactIslands) HydrationFile() []embedutils.FileReader {
+	return append(s.ReactHydrate.HydrationFile(), &embedFileReader{fileName: "react_islands.go"})
+}
+
+// NewReactIslands creates the islands wrapper, the islands are hydrated with the react 18 root api when it is targeted
+func NewReactIslands(bundler *BaseBundler) *ReactIslands {
+	return &ReactIslands{
+		ReactHydrate: newReactHydrate(bundler, bundler.ReactMajorVersion() >= 18),
+	}
+}
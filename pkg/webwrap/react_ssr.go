@@ -6,10 +6,27 @@ package webwrap
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
 	"strings"
 
+	"github.com/GuyARoss/orbit/pkg/experiments"
 	"github.com/GuyARoss/orbit/pkg/jsparse"
+	parseerror "github.com/GuyARoss/orbit/pkg/parse_error"
+	ewrap "github.com/GuyARoss/orbit/pkg/webwrap/embed"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	ssrWebpackBaseConfig    = "const baseConfig = require('../../assets/ssr-base.config.js')"
+	ssrWebpackSWCBaseConfig = "const baseConfig = require('../../assets/ssr-swc-base.config.js')"
 )
 
 type PartialWrapReactSSR struct {
@@ -18,13 +35,21 @@ type PartialWrapReactSSR struct {
 	bundlerProcessStarted bool
 	sourceMapDoc          *jsparse.DefaultJSDocument
 	initDoc               *jsparse.DefaultJSDocument
+	vmDoc                 *jsparse.DefaultJSDocument
 	jsSwitch              *jsparse.JsDocSwitch
+	streaming             bool
+	// vue is whether vue single-file components are rendered alongside the react pages
+	vue bool
 }
 
 type NewReactSSROpts struct {
 	SourceMapDoc *jsparse.DefaultJSDocument
 	InitDoc      *jsparse.DefaultJSDocument
 	Bundler      *BaseBundler
+	// Streaming renders the pages with the react 18 "renderToPipeableStream" api, streamed responses are sent the
+	// shell of the page once it is ready, while other renders complete once each of the suspense boundaries of the
+	// page have resolved. the embedded vm entry does not support node streams, so it continues to render to a string.
+	Streaming bool
 }
 
 func (r *PartialWrapReactSSR) VerifyRequirements() error {
@@ -33,32 +58,303 @@ func (r *PartialWrapReactSSR) VerifyRequirements() error {
 }
 
 func (r *PartialWrapReactSSR) Setup(ctx context.Context, settings *BundleOpts) (*BundledResource, error) {
-	bundleFilePath := fmt.Sprintf("%s/%s.ssr.js", r.PageOutputDir, settings.BundleKey)
+	serverFileName := fmt.Sprintf("%s.ssr.%s", settings.BundleKey, settings.EntryExtension())
+	bundleFilePath := fmt.Sprintf("%s/%s", r.PageOutputDir, serverFileName)
 	r.sourceMapDoc.AddImport(&jsparse.ImportDependency{
-		FinalStatement: fmt.Sprintf("import %s from '%s'", settings.Name, fmt.Sprintf("./%s.ssr.js", settings.BundleKey)),
-		Type:           jsparse.LocalImportType,
-	})
-
-	r.sourceMapDoc.AddOther(fmt.Sprintf(`export const %s = (d) => ReactDOMServer.renderToString(<%s {...d}/>)`, strings.ToLower(settings.Name), settings.Name))
-	r.initDoc.AddImport(&jsparse.ImportDependency{
-		FinalStatement: fmt.Sprintf("import { %s } from '%s'", strings.ToLower(settings.Name), fmt.Sprintf("./%s", "react_ssr.map.js")),
+		FinalStatement: fmt.Sprintf("import %s from './%s'", settings.Name, serverFileName),
 		Type:           jsparse.LocalImportType,
 	})
 
-	r.jsSwitch.Add(jsparse.JSString, settings.BundleKey, fmt.Sprintf(`return %s(JSON.parse(JSONData))`, strings.ToLower(settings.Name)))
+	r.addRenderFunc(settings)
 
 	return &BundledResource{
 		BundleOpFileDescriptor: map[string]string{"normal": bundleFilePath},
-		Configurators: []BundleConfigurator{
-			{
-				FilePath: fmt.Sprintf("%s/react_ssr.map.js", r.PageOutputDir),
-				Page:     r.sourceMapDoc,
-			}, {
-				FilePath: fmt.Sprintf("%s/react_ssr.js", r.PageOutputDir),
-				Page:     r.initDoc,
+		Configurators:          r.serverConfigurators(),
+	}, nil
+}
+
+// serverConfigurators are the configurators of the server bundles, which are shared by each of the server rendered pages.
+// the webpack config is the last of these, so that the server bundles are bundled once each of the entries has been written.
+func (r *PartialWrapReactSSR) serverConfigurators() []BundleConfigurator {
+	return []BundleConfigurator{
+		{
+			FilePath: fmt.Sprintf("%s/react_ssr.map.js", r.PageOutputDir),
+			Page:     r.sourceMapDoc,
+			Shared:   true,
+		}, {
+			FilePath: fmt.Sprintf("%s/react_ssr.js", r.PageOutputDir),
+			Page:     r.initDoc,
+			Shared:   true,
+		}, {
+			FilePath: fmt.Sprintf("%s/react_ssr.vm.js", r.PageOutputDir),
+			Page:     r.vmDoc,
+			Shared:   true,
+		}, {
+			FilePath: fmt.Sprintf("%s/react_ssr.config.js", r.PageOutputDir),
+			Page:     r.bundleConfig(),
+			Shared:   true,
+		},
+	}
+}
+
+// bundleConfig creates the webpack config of the server bundles, both the node server & the
+// embedded vm entries are bundled to a single file so that node_modules is not required to render.
+func (r *PartialWrapReactSSR) bundleConfig() jsparse.JSDocument {
+	page := jsparse.NewEmptyDocument()
+
+	page.AddImport(&jsparse.ImportDependency{
+		FinalStatement: "const {merge} = require('webpack-merge')",
+		Type:           jsparse.ModuleImportType,
+	})
+
+	baseConfig := ssrWebpackBaseConfig
+	if experiments.GlobalExperimentalFeatures.PreferSWCCompiler {
+		baseConfig = ssrWebpackSWCBaseConfig
+	}
+
+	page.AddImport(&jsparse.ImportDependency{
+		FinalStatement: baseConfig,
+		Type:           jsparse.ModuleImportType,
+	})
+
+	config := "baseConfig"
+	if r.vue {
+		page.AddImport(&jsparse.ImportDependency{
+			FinalStatement: "const { VueLoaderPlugin } = require('vue-loader')",
+			Type:           jsparse.ModuleImportType,
+		})
+
+		page.AddOther(fmt.Sprintf("const ssrConfig = merge(baseConfig, %s)", vueLoaderConfig))
+		config = "ssrConfig"
+	}
+
+	page.AddOther(fmt.Sprintf(`module.exports = [
+		merge(%s, {
+			entry: ['./%s/react_ssr.js'],
+			mode: '%s',
+			output: {
+				filename: 'react_ssr.js'
+			},
+			node: {
+				__dirname: false,
+			},
+		}),
+		merge(%s, {
+			entry: ['./%s/react_ssr.vm.js'],
+			mode: '%s',
+			target: 'web',
+			output: {
+				filename: 'react_ssr.vm.js'
+			},
+		}),
+	]`, config, r.PageOutputDir, string(r.Mode), config, r.PageOutputDir, string(r.Mode)))
+
+	return page
+}
+
+// Bundle bundles the server entries once the webpack config has been written, the
+// entries themselves are written by the preceding configurators & are not bundled alone.
+func (r *PartialWrapReactSSR) Bundle(configuratorFilePath string, filePath string) error {
+	if !strings.HasSuffix(configuratorFilePath, ".config.js") {
+		return nil
+	}
+
+	// the config is written again as it was created by the setup of the first page, the pages
+	// that have been setup since can require other loaders (e.g the vue-loader of vue pages).
+	if err := r.bundleConfig().WriteFile(configuratorFilePath); err != nil {
+		return err
+	}
+
+	webpackPath := fmt.Sprintf("%s%c%s%c%s", r.NodeModulesDir, os.PathSeparator, ".bin", os.PathSeparator, "webpack")
+
+	// due to a "bug" with windows, it has an issue with shebang cmds, so we prefer the webpack.js file instead.
+	if runtime.GOOS == "windows" {
+		webpackPath = r.NodeModulesDir + "/webpack/bin/webpack.js"
+	}
+
+	cmd := exec.Command("node", webpackPath, "--config", configuratorFilePath)
+	output, err := cmd.Output()
+
+	if err != nil {
+		if r.Logger != nil {
+			r.Logger.Warn(fmt.Sprintf(`invalid pack: "node %s --config %s"\n "%s"`, webpackPath, configuratorFilePath, string(output)))
+		}
+
+		return parseerror.New("failed to bundle the server render, this could denote a syntax error", filePath)
+	}
+
+	return nil
+}
+
+// addRenderFunc adds the render function of the component to the source map & imports it into both the
+// node & embedded vm entries, the component is provided with the ssr context as the "orbit" prop.
+// when streaming, the render function creates the element of the component which is rendered by each entry.
+func (r *PartialWrapReactSSR) addRenderFunc(settings *BundleOpts) {
+	element := fmt.Sprintf(`<%s {...d} orbit={orbit}/>`, settings.Name)
+	if !r.streaming {
+		element = fmt.Sprintf(`ReactDOMServer.renderToString(%s)`, element)
+	}
+
+	r.sourceMapDoc.AddOther(fmt.Sprintf(`export const %s = (d, orbit) => %s`, strings.ToLower(settings.Name), element))
+	r.addRenderCase(settings)
+}
+
+// addVueRenderFunc adds the render function of a vue single-file component to the source map, the component is
+// rendered to a string with "@vue/server-renderer" & the ssr context is provided to the component as "orbit".
+// vue renders asynchronously, the render is awaited by the node entry & resolved by the job queue of the embedded vm.
+func (r *PartialWrapReactSSR) addVueRenderFunc(settings *BundleOpts) {
+	if !r.vue {
+		r.vue = true
+
+		r.sourceMapDoc.AddImport(&jsparse.ImportDependency{
+			FinalStatement: "import { createSSRApp } from 'vue'",
+			Type:           jsparse.ModuleImportType,
+		})
+
+		r.sourceMapDoc.AddImport(&jsparse.ImportDependency{
+			FinalStatement: "import { renderToString as renderVueToString } from '@vue/server-renderer'",
+			Type:           jsparse.ModuleImportType,
+		})
+	}
+
+	r.sourceMapDoc.AddImport(jsparse.NewSourceImport(settings.Name, settings.FileName))
+	r.sourceMapDoc.AddOther(fmt.Sprintf(
+		`export const %s = (d, orbit) => renderVueToString(createSSRApp(%s, d).provide('orbit', orbit))`,
+		strings.ToLower(settings.Name), settings.Name),
+	)
+
+	r.addRenderCase(settings)
+}
+
+// addRenderCase imports the render function of the component from the source map into both
+// the node & embedded vm entries, where it is rendered when the bundle key of the component is requested.
+func (r *PartialWrapReactSSR) addRenderCase(settings *BundleOpts) {
+	for _, doc := range []*jsparse.DefaultJSDocument{r.initDoc, r.vmDoc} {
+		doc.AddImport(&jsparse.ImportDependency{
+			FinalStatement: fmt.Sprintf("import { %s } from '%s'", strings.ToLower(settings.Name), fmt.Sprintf("./%s", "react_ssr.map.js")),
+			Type:           jsparse.LocalImportType,
+		})
+	}
+
+	r.jsSwitch.Add(jsparse.JSString, settings.BundleKey, fmt.Sprintf(`return %s(JSON.parse(JSONData), orbit)`, strings.ToLower(settings.Name)))
+}
+
+// ssrContextSource creates the "buildStaticContent" entry point of the server bundles, the ssr context of each render
+// allows the page to read the request & to set the head elements, status or redirect of the response.
+// the static content is created from the result of "renderBundle" with the render expression.
+func ssrContextSource(declaration string, render string) string {
+	return fmt.Sprintf(ssrContextTemplate, declaration, render)
+}
+
+// bufferedStreamSource sends the render of the page as a single response once it has completed,
+// which is used by the "RenderStream" call of the node entry when the pages are not streamed.
+const bufferedStreamSource = `
+const streamStaticContent = async (request, write) => write(await buildStaticContent(request))
+`
+
+const ssrContextTemplate = `
+const ssrContext = (request) => {
+	const orbit = {
+		request: request.Request || {},
+		head: [],
+		status: 0,
+		redirect: "",
+		addHead: (element) => {
+			orbit.head.push(element)
+		},
+		setStatus: (status) => {
+			orbit.status = status
+		},
+		redirectTo: (url, status) => {
+			orbit.redirect = url
+			orbit.status = status || 302
+		},
+	}
+
+	return orbit
+}
+
+const ssrResponse = (orbit, StaticContent) => ({
+	StaticContent,
+	Head: orbit.head,
+	Status: orbit.status,
+	Redirect: orbit.redirect,
+})
+
+const buildStaticContent = %s(request) => {
+	const orbit = ssrContext(request)
+	return ssrResponse(orbit, %s)
+}
+`
+
+// vmRenderSource renders the element of the page to a string for the embedded vm entry, which cannot stream.
+// pages that are not react elements (e.g vue pages) resolve to their rendered content.
+const vmRenderSource = `
+const renderVMElement = async (element) => {
+	const rendered = await element
+	return typeof rendered === 'string' ? rendered : ReactDOMServer.renderToString(rendered)
+}
+`
+
+// streamSource renders the element with "renderToPipeableStream". "renderToStream" resolves the static content once
+// each of the suspense boundaries of the page have resolved, which is used for static renders & crawlers, while
+// "streamStaticContent" writes the shell of the page once it is ready & each of the following chunks as they arrive.
+// pages that are not react elements (e.g vue pages) have already been rendered.
+const streamSource = `
+const renderToStream = (element) => !React.isValidElement(element) ? element : new Promise((resolve, reject) => {
+	const chunks = []
+	const stream = ReactDOMServer.renderToPipeableStream(element, {
+		onAllReady() {
+			stream.pipe(new Writable({
+				write(chunk, encoding, callback) {
+					chunks.push(chunk)
+					callback()
+				},
+				final(callback) {
+					resolve(Buffer.concat(chunks).toString())
+					callback()
+				},
+			}))
+		},
+		onShellError: reject,
+	})
+})
+
+const isCrawler = (request) => /bot|crawl|spider|slurp|preview/i.test(((request.Request || {}).Headers || {})["User-Agent"] || "")
+
+const streamStaticContent = async (request, write) => {
+	const orbit = ssrContext(request)
+	const element = renderBundle(request, orbit)
+
+	if (isCrawler(request) || !React.isValidElement(element)) {
+		write(ssrResponse(orbit, await renderToStream(element)))
+		return
+	}
+
+	await new Promise((resolve, reject) => {
+		const decoder = new TextDecoder()
+		const stream = ReactDOMServer.renderToPipeableStream(element, {
+			onShellReady() {
+				write(ssrResponse(orbit, ""))
+				stream.pipe(new Writable({
+					write(chunk, encoding, callback) {
+						write({ StaticContent: decoder.decode(chunk, { stream: true }) })
+						callback()
+					},
+					final(callback) {
+						const rest = decoder.decode()
+						if (rest) write({ StaticContent: rest })
+
+						resolve()
+						callback()
+					},
+				}))
 			},
-		}}, nil
+			onShellError: reject,
+		})
+	})
 }
+`
 
 func (r *PartialWrapReactSSR) Apply(doc jsparse.JSDocument) (jsparse.JSDocument, error) {
 	hasImport := false
@@ -83,6 +379,18 @@ func (r *PartialWrapReactSSR) Apply(doc jsparse.JSDocument) (jsparse.JSDocument,
 	return doc, nil
 }
 
+// ssrProtoDescriptors is the base64 encoded descriptor set of the renderer & health services of the node server entry
+func ssrProtoDescriptors() string {
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, f := range []protoreflect.FileDescriptor{ewrap.File_src_com_proto, grpc_health_v1.File_grpc_health_v1_health_proto} {
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(f))
+	}
+
+	// the descriptors are compiled into the binary, so they can always be marshalled
+	data, _ := proto.Marshal(set)
+	return base64.StdEncoding.EncodeToString(data)
+}
+
 func NewReactSSRPartial(opts *NewReactSSROpts) *PartialWrapReactSSR {
 	opts.SourceMapDoc.AddImport(&jsparse.ImportDependency{
 		FinalStatement: "import React from 'react'",
@@ -101,14 +409,51 @@ func NewReactSSRPartial(opts *NewReactSSROpts) *PartialWrapReactSSR {
 
 	opts.InitDoc.AddImport(&jsparse.ImportDependency{
 		Type:           jsparse.ModuleImportType,
-		FinalStatement: `import { loadSync } from "@grpc/proto-loader"`,
+		FinalStatement: `import { loadFileDescriptorSetFromBuffer } from "@grpc/proto-loader"`,
 	})
 
 	jsSwitch := jsparse.NewSwitch(`BundleID`)
-	fn := jsparse.NewFunc(`const buildStaticContent = ({ BundleID, JSONData }) => `, jsSwitch)
+	fn := jsparse.NewFunc(`const renderBundle = ({ BundleID, JSONData }, orbit) => `, jsSwitch)
+
+	// the embedded vm entry exposes the same entry point as the node server, but it does not depend on node
+	// so that it can be run by the embedded ssr renderer.
+	vmDoc := jsparse.NewEmptyDocument()
+	vmDoc.AddSerializable(fn)
 
 	opts.InitDoc.AddSerializable(fn)
 
+	if opts.Streaming {
+		for _, doc := range []*jsparse.DefaultJSDocument{opts.InitDoc, vmDoc} {
+			doc.AddImport(&jsparse.ImportDependency{
+				FinalStatement: "import ReactDOMServer from 'react-dom/server'",
+				Type:           jsparse.ModuleImportType,
+			})
+		}
+
+		opts.InitDoc.AddImport(&jsparse.ImportDependency{
+			FinalStatement: "import React from 'react'",
+			Type:           jsparse.ModuleImportType,
+		})
+
+		opts.InitDoc.AddImport(&jsparse.ImportDependency{
+			FinalStatement: "import { Writable } from 'stream'",
+			Type:           jsparse.ModuleImportType,
+		})
+
+		opts.InitDoc.AddOther(streamSource, ssrContextSource("async ", "await renderToStream(renderBundle(request, orbit))"))
+		vmDoc.AddOther(vmRenderSource, ssrContextSource("async ", "await renderVMElement(renderBundle(request, orbit))"))
+	} else {
+		// the render functions of vue pages resolve asynchronously, which is awaited by both of the entries.
+		// the promise of the vm entry is resolved by the job queue of the vm before the render returns.
+		opts.InitDoc.AddOther(ssrContextSource("async ", "await renderBundle(request, orbit)"), bufferedStreamSource)
+		vmDoc.AddOther(ssrContextSource("async ", "await renderBundle(request, orbit)"))
+	}
+
+	vmDoc.AddOther(`globalThis.buildStaticContent = buildStaticContent`)
+
+	// the descriptors of the renderer & health services are inlined, so that the bundle does not load the proto files from disk
+	opts.InitDoc.AddOther(fmt.Sprintf(`const PROTO_DESCRIPTORS = "%s"`, ssrProtoDescriptors()))
+
 	// TODO: this should be in a embed file
 	opts.InitDoc.AddOther(`
 
@@ -120,24 +465,46 @@ func NewReactSSRPartial(opts *NewReactSSROpts) *PartialWrapReactSSR {
 		oneofs: true,
 	}
 	
-	const PROTO_PATH = "./.orbit/assets/com.proto"
-	
-	var packageDefinition = loadSync(PROTO_PATH, options)
-	const proto = grpc.loadPackageDefinition(packageDefinition)
+	const proto = grpc.loadPackageDefinition(loadFileDescriptorSetFromBuffer(Buffer.from(PROTO_DESCRIPTORS, "base64"), options))
 	
 	try {
 		const server = new grpc.Server()
 	
 		server.addService(proto.main.ReactRenderer.service, {
+			// the static content of streamed renders is resolved once the stream has completed
 			Render: ({ request }, callback) => {
-				callback(null, {
-					StaticContent: buildStaticContent(request),
-				})
+				Promise.resolve()
+					.then(() => buildStaticContent(request))
+					.then((response) => callback(null, response), (err) => callback(err))
 			},
+			// each of the pages of a request are rendered with a single call
+			RenderMany: ({ request }, callback) => {
+				Promise.resolve()
+					.then(() => Promise.all(request.Renders.map(buildStaticContent)))
+					.then((Renders) => callback(null, { Renders }), (err) => callback(err))
+			},
+			// the shell of streamed renders is written once it is ready & the rest of the page as it resolves
+			RenderStream: (call) => {
+				Promise.resolve()
+					.then(() => streamStaticContent(call.request, (response) => call.write(response)))
+					.then(() => call.end(), (err) => call.emit("error", err))
+			},
+		})
+
+		// the health of each renderer is checked by the orbit ssr pool
+		server.addService(proto.grpc.health.v1.Health.service, {
+			Check: (_, callback) => {
+				callback(null, { status: "SERVING" })
+			},
+		})
+
+		// in-flight renders are completed before the renderer exits
+		process.on("SIGTERM", () => {
+			server.tryShutdown(() => process.exit(0))
 		})
 		
 		server.bindAsync(
-			"0.0.0.0:3024",
+			process.env.ORBIT_SSR_ADDRESS || "unix:.orbit/ssr.sock",
 			grpc.ServerCredentials.createInsecure(),
 			(error, port) => {
 				if (!!error) {
@@ -157,7 +524,9 @@ func NewReactSSRPartial(opts *NewReactSSROpts) *PartialWrapReactSSR {
 	return &PartialWrapReactSSR{
 		sourceMapDoc: opts.SourceMapDoc,
 		initDoc:      opts.InitDoc,
+		vmDoc:        vmDoc,
 		BaseBundler:  opts.Bundler,
 		jsSwitch:     jsSwitch,
+		streaming:    opts.Streaming,
 	}
 }
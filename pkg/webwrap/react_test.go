@@ -5,9 +5,15 @@
 package webwrap
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"os"
+	"strings"
 	"testing"
 
+	"github.com/GuyARoss/orbit/pkg/experiments"
+	"github.com/GuyARoss/orbit/pkg/jsparse"
 	"github.com/GuyARoss/orbit/pkg/jsparse/mock"
 )
 
@@ -43,3 +49,313 @@ func TestApplyReact(t *testing.T) {
 		t.Errorf("expected name 'Thing' got '%s'", p["normal"].Name())
 	}
 }
+
+func TestReactSSRSetup_BundleConfig(t *testing.T) {
+	r := NewReactSSRPartial(&NewReactSSROpts{
+		Bundler: &BaseBundler{
+			Mode:          ProductionBundle,
+			PageOutputDir: ".orbit/base/pages",
+		},
+		SourceMapDoc: jsparse.NewEmptyDocument(),
+		InitDoc:      jsparse.NewEmptyDocument(),
+	})
+
+	resource, err := r.Setup(context.Background(), &BundleOpts{BundleKey: "thing", Name: "Thing"})
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	// the server bundle should be configured after each of its entries have been written
+	last := resource.Configurators[len(resource.Configurators)-1]
+	if last.FilePath != ".orbit/base/pages/react_ssr.config.js" {
+		t.Errorf("expected the bundle config to be the last configurator got '%s'", last.FilePath)
+	}
+
+	config := last.Page.Other()
+	for _, expected := range []string{"./.orbit/base/pages/react_ssr.js", "./.orbit/base/pages/react_ssr.vm.js", "mode: 'production'"} {
+		if !strings.Contains(strings.Join(config, ""), expected) {
+			t.Errorf("expected bundle config to contain '%s'", expected)
+		}
+	}
+
+	if err := r.Bundle(".orbit/base/pages/react_ssr.map.js", ""); err != nil {
+		t.Errorf("expected server entries to not be bundled alone got '%s'", err)
+	}
+
+	// the server bundle is shared by each of the pages, so it should only be bundled once all of the pages have been setup
+	for _, c := range resource.Configurators {
+		if !c.Shared {
+			t.Errorf("expected the server configurator '%s' to be shared", c.FilePath)
+		}
+	}
+
+	// the node entry should not load the proto files from disk, so that the server bundle can be deployed on its own
+	init := strings.Join(r.initDoc.Other(), "")
+	if strings.Contains(init, ".proto") || !strings.Contains(init, fmt.Sprintf(`"%s"`, ssrProtoDescriptors())) {
+		t.Error("expected the proto descriptors to be inlined in the node entry")
+	}
+}
+
+func TestReactSSRSetup_SWCBundleConfig(t *testing.T) {
+	experiments.GlobalExperimentalFeatures.PreferSWCCompiler = true
+	t.Cleanup(func() {
+		experiments.GlobalExperimentalFeatures.PreferSWCCompiler = false
+	})
+
+	r := NewReactSSRPartial(&NewReactSSROpts{
+		Bundler:      &BaseBundler{PageOutputDir: ".orbit/base/pages"},
+		SourceMapDoc: jsparse.NewEmptyDocument(),
+		InitDoc:      jsparse.NewEmptyDocument(),
+	})
+
+	config := r.bundleConfig()
+	if len(config.Imports()) != 2 || config.Imports()[1].FinalStatement != ssrWebpackSWCBaseConfig {
+		t.Errorf("expected the server bundle to be compiled by swc got '%v'", config.Imports())
+	}
+}
+
+// islandsPage parses the page source, the parser expects the page to be relative to the working directory
+func islandsPage(t *testing.T, source string) jsparse.JSDocument {
+	wd, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	if err := os.WriteFile("home.jsx", []byte(source), 0644); err != nil {
+		t.Fatalf("cannot write page '%s'", err)
+	}
+
+	parser := &jsparse.JSFileParser{}
+	page, err := parser.Parse("home.jsx", "./")
+	if err != nil {
+		t.Fatalf("cannot parse page '%s'", err)
+	}
+
+	return page
+}
+
+func TestApplyReactIslands(t *testing.T) {
+	page := islandsPage(t, `import React from 'react'
+import Counter from './counter.jsx'
+import Header from './header.jsx'
+// orbit:island Counter
+
+const Home = ({ count }) => {
+	return <div><Header /><Counter start={count} /></div>
+}
+
+export default Home`)
+
+	r := NewReactIslands(&BaseBundler{})
+
+	pages, err := r.Apply(page)
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	server := pages["ssr"]
+	serverImports := make([]string, 0)
+	for _, imp := range server.Imports() {
+		serverImports = append(serverImports, imp.FinalStatement)
+	}
+
+	if !strings.Contains(strings.Join(serverImports, "\n"), "import OrbitIslandCounter from") {
+		t.Errorf("expected the island to be renamed within the server page got '%v'", serverImports)
+	}
+
+	serverSource := strings.Join(server.Other(), "\n")
+	for _, expected := range []string{"data-orbit-island", "const Counter = orbitIsland('Counter', OrbitIslandCounter)", "export default Home"} {
+		if !strings.Contains(serverSource, expected) {
+			t.Errorf("expected server page to contain '%s'", expected)
+		}
+	}
+
+	client := pages["csr"]
+	for _, imp := range client.Imports() {
+		if strings.Contains(imp.FinalStatement, "Header") {
+			t.Errorf("expected static components to not be included within the client bundle")
+		}
+	}
+
+	if !strings.Contains(strings.Join(client.Other(), ""), "const orbitIslands = { Counter }") {
+		t.Errorf("expected the client bundle to hydrate the island got '%v'", client.Other())
+	}
+
+	if !HasIslands(r, page) {
+		t.Errorf("expected page to have islands")
+	}
+}
+
+func TestApplyReactIslands_NotImported(t *testing.T) {
+	page := islandsPage(t, `import React from 'react'
+// orbit:island Counter
+
+const Home = () => {
+	return <div></div>
+}
+
+export default Home`)
+
+	if _, err := NewReactIslands(&BaseBundler{}).Apply(page); !errors.Is(err, ErrIslandNotImported) {
+		t.Errorf("expected island import error got '%v'", err)
+	}
+}
+
+func TestReactMajorVersion(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(fmt.Sprintf("%s/react", dir), 0755)
+	os.WriteFile(fmt.Sprintf("%s/react/package.json", dir), []byte(`{"name": "react", "version": "18.2.0"}`), 0644)
+
+	tt := []struct {
+		bundler  *BaseBundler
+		expected int
+	}{
+		{&BaseBundler{NodeModulesDir: dir}, 18},
+		{&BaseBundler{NodeModulesDir: dir, ReactVersion: 17}, 17},
+		{&BaseBundler{NodeModulesDir: fmt.Sprintf("%s/missing", dir)}, 0},
+	}
+
+	for i, d := range tt {
+		if got := d.bundler.ReactMajorVersion(); got != d.expected {
+			t.Errorf("(%d) expected version %d got %d", i, d.expected, got)
+		}
+	}
+}
+
+func TestNewActiveMap_React18(t *testing.T) {
+	wrappers := NewActiveMap(&BaseBundler{ReactVersion: 18})
+	if wrappers[0].Version() != "react18CSR" {
+		t.Errorf("expected react 18 wrapper got '%s'", wrappers[0].Version())
+	}
+
+	wrappers = NewActiveMap(&BaseBundler{ReactVersion: 16})
+	if wrappers[0].Version() != "reactCSR" {
+		t.Errorf("expected legacy react wrapper got '%s'", wrappers[0].Version())
+	}
+}
+
+func TestApplyReact18(t *testing.T) {
+	csr, err := NewReact18CSR(&BaseBundler{}).Apply(jsparse.NewImportDocument())
+	if !errors.Is(err, ErrInvalidComponent) {
+		t.Errorf("expected invalid component error got '%v'", err)
+	}
+
+	page := islandsPage(t, `import React from 'react'
+
+const Home = ({ count }) => {
+	return <div>{count}</div>
+}
+
+export default Home`)
+
+	csr, err = NewReact18CSR(&BaseBundler{}).Apply(page.Clone())
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	if !strings.Contains(strings.Join(csr["normal"].Other(), ""), "createRoot(document.getElementById") {
+		t.Errorf("expected page to be rendered with the root api got '%v'", csr["normal"].Other())
+	}
+
+	hydrate, err := NewReact18Hydrate(&BaseBundler{}).Apply(page.Clone())
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	if !strings.Contains(strings.Join(hydrate["csr"].Other(), ""), "hydrateRoot(orbitFrame") {
+		t.Errorf("expected page to be hydrated with the root api got '%v'", hydrate["csr"].Other())
+	}
+
+	if !strings.Contains(strings.Join(hydrate["csr"].Other(), ""), "createRoot(orbitFrame)") {
+		t.Errorf("expected page to be rendered with the root api when ssr falls back got '%v'", hydrate["csr"].Other())
+	}
+}
+
+func TestReactSSR_Streaming(t *testing.T) {
+	r := NewReactSSRPartial(&NewReactSSROpts{
+		Bundler:      &BaseBundler{PageOutputDir: ".orbit/base/pages"},
+		SourceMapDoc: jsparse.NewEmptyDocument(),
+		InitDoc:      jsparse.NewEmptyDocument(),
+		Streaming:    true,
+	})
+
+	r.Setup(context.Background(), &BundleOpts{BundleKey: "thing", Name: "Thing"})
+
+	if source := strings.Join(r.sourceMapDoc.Other(), ""); strings.Contains(source, "renderToString") {
+		t.Errorf("expected the source map to create the element of the page got '%s'", source)
+	}
+
+	if source := strings.Join(r.initDoc.Other(), ""); !strings.Contains(source, "await renderToStream(renderBundle(request, orbit))") {
+		t.Errorf("expected the node entry to stream the render got '%s'", source)
+	}
+
+	if source := strings.Join(r.initDoc.Other(), ""); !strings.Contains(source, "onShellReady()") || !strings.Contains(source, "RenderStream: (call)") {
+		t.Errorf("expected the node entry to stream the shell of the render got '%s'", source)
+	}
+
+	if source := strings.Join(r.vmDoc.Other(), ""); !strings.Contains(source, "await renderVMElement(renderBundle(request, orbit))") || !strings.Contains(source, "ReactDOMServer.renderToString(rendered)") {
+		t.Errorf("expected the vm entry to render to a string got '%s'", source)
+	}
+}
+
+func TestSetup_TypeScriptEntry(t *testing.T) {
+	bundler := &BaseBundler{PageOutputDir: ".orbit/base/pages"}
+	settings := &BundleOpts{FileName: "pages/home.tsx", BundleKey: "abc", Name: "Home"}
+
+	csr, err := NewReactCSR(bundler).Setup(context.Background(), settings)
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	if csr.BundleOpFileDescriptor["normal"] != ".orbit/base/pages/abc.tsx" {
+		t.Errorf("expected the entry to keep the extension of the page got '%s'", csr.BundleOpFileDescriptor["normal"])
+	}
+
+	hydrate := newReactHydrate(bundler, false)
+	ssr, err := hydrate.Setup(context.Background(), settings)
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	if ssr.BundleOpFileDescriptor["ssr"] != ".orbit/base/pages/abc.ssr.tsx" {
+		t.Errorf("expected the server entry to keep the extension of the page got '%s'", ssr.BundleOpFileDescriptor["ssr"])
+	}
+
+	imports := make([]string, 0)
+	for _, imp := range hydrate.ssr.sourceMapDoc.Imports() {
+		imports = append(imports, imp.FinalStatement)
+	}
+
+	if !strings.Contains(strings.Join(imports, "\n"), "import Home from './abc.ssr.tsx'") {
+		t.Errorf("expected the source map to import the server entry got '%v'", imports)
+	}
+
+	js, _ := (&JavascriptWrap{BaseBundler: bundler}).Setup(context.Background(), &BundleOpts{FileName: "pages/util.js", BundleKey: "abc"})
+	if js.BundleOpFileDescriptor["normal"] != ".orbit/base/pages/abc.js" {
+		t.Errorf("expected javascript entry got '%s'", js.BundleOpFileDescriptor["normal"])
+	}
+}
+
+func TestNewActiveMap_TypeScript(t *testing.T) {
+	wrappers := NewActiveMap(&BaseBundler{ReactVersion: 16})
+
+	tt := []struct {
+		page     *mock.MockJsDocument
+		expected string
+	}{
+		{mock.NewMockJSDocument("Home", "tsx", "Home"), "reactCSR"},
+		{mock.NewMockJSDocument("Home", "tsx", "Home").WithFramework("preact"), "preactCSR"},
+		{mock.NewMockJSDocument("Util", "ts", "Util"), "javascriptWebpack"},
+	}
+
+	for i, d := range tt {
+		if wrapper := wrappers.FindFirst(d.page); wrapper == nil || wrapper.Version() != d.expected {
+			t.Errorf("(%d) expected '%s' got '%v'", i, d.expected, wrapper)
+		}
+	}
+}
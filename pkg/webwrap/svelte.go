@@ -0,0 +1,152 @@
+// Copyright (c) 2021 Guy A. Ross
+// This source code is licensed under the GNU GPLv3 found in the
+// license file in the root directory of this source tree.
+
+package webwrap
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/GuyARoss/orbit/pkg/embedutils"
+	"github.com/GuyARoss/orbit/pkg/jsparse"
+	parseerror "github.com/GuyARoss/orbit/pkg/parse_error"
+)
+
+const svelteExtension string = "svelte"
+
+// svelteLoaderConfig is the webpack config that compiles svelte components with "svelte-loader",
+// it is merged with the base config of the bundle.
+const svelteLoaderConfig = `{
+	module: {
+		rules: [
+			{
+				test: /\.svelte$/,
+				use: { loader: 'svelte-loader' },
+			},
+		],
+	},
+	resolve: {
+		extensions: ['.svelte'],
+		mainFields: ['svelte', 'browser', 'module', 'main'],
+	},
+}`
+
+// SvelteCSR renders svelte components on the client, the source file of the component
+// is bundled as is (by "svelte-loader") & mounted with the props of the page.
+type SvelteCSR struct {
+	*BaseWebWrapper
+	*BaseBundler
+}
+
+func (s *SvelteCSR) DocumentTag(key string) string {
+	return fmt.Sprintf(`<div id="%s_svelte_frame"></div>`, key)
+}
+
+func (s *SvelteCSR) Apply(page jsparse.JSDocument) (map[string]jsparse.JSDocument, error) {
+	if len(page.Name()) == 0 {
+		return nil, ErrInvalidComponent
+	}
+
+	doc := jsparse.NewEmptyDocument()
+	doc.AddImport(page.SourceImport())
+
+	doc.AddOther(fmt.Sprintf(
+		"new %s({ target: document.getElementById('%s_svelte_frame'), props: %s })",
+		page.Name(), page.Key(), manifestProps(page.Key())),
+	)
+
+	return map[string]jsparse.JSDocument{"normal": doc}, nil
+}
+
+func (r *SvelteCSR) VerifyRequirements() error {
+	// svelte components are compiled by the svelte-loader of webpack, which has no equivalent in esbuild
+	if r.usesESBuild() {
+		return ErrESBuildUnsupported
+	}
+
+	webpackPath := fmt.Sprintf("%s%c%s%c%s", r.NodeModulesDir, os.PathSeparator, ".bin", os.PathSeparator, "webpack")
+
+	// due to a "bug" with windows, it has an issue with shebang cmds, so we prefer the webpack.js file instead.
+	if runtime.GOOS == "windows" {
+		webpackPath = r.NodeModulesDir + "/webpack/bin/webpack.js"
+	}
+
+	if _, err := os.Stat(webpackPath); err != nil {
+		return fmt.Errorf("node module not found: webpack. It is possible that you need to run `npm i` in your workspace directory to remedy this issue")
+	}
+
+	if _, err := os.Stat(fmt.Sprintf("%s%csvelte-loader", r.NodeModulesDir, os.PathSeparator)); err != nil {
+		return fmt.Errorf("node module not found: svelte-loader. It is possible that you need to run `npm i svelte svelte-loader` in your workspace directory to remedy this issue")
+	}
+
+	return nil
+}
+
+func (s *SvelteCSR) Version() string {
+	return "svelteCSR"
+}
+
+func (s *SvelteCSR) Stats() *WrapStats {
+	return &WrapStats{
+		WebVersion: "Svelte CSR",
+		Bundler:    "webpack",
+	}
+}
+
+// RequiredBodyDOMElements svelte compiles the runtime into each of the pages, so it does not require any elements
+func (s *SvelteCSR) RequiredBodyDOMElements(ctx context.Context, cache *CacheDOMOpts) []string {
+	return []string{}
+}
+
+func (b *SvelteCSR) Setup(ctx context.Context, settings *BundleOpts) (*BundledResource, error) {
+	bundleFilePath := fmt.Sprintf("%s/%s.js", b.PageOutputDir, settings.BundleKey)
+
+	return &BundledResource{
+		BundleOpFileDescriptor: map[string]string{"normal": bundleFilePath},
+		Configurators: []BundleConfigurator{
+			b.webpackConfigurator(&WebpackEntry{
+				BundleKey: settings.BundleKey,
+				FilePath:  bundleFilePath,
+				Requires:  []string{webpackBaseConfig},
+				Config:    svelteLoaderConfig,
+			}),
+		},
+	}, nil
+}
+
+func (b *SvelteCSR) Bundle(configuratorFilePath string, filePath string) error {
+	webpackPath := fmt.Sprintf("%s%c%s%c%s", b.NodeModulesDir, os.PathSeparator, ".bin", os.PathSeparator, "webpack")
+
+	// due to a "bug" with windows, it has an issue with shebang cmds, so we prefer the webpack.js file instead.
+	if runtime.GOOS == "windows" {
+		webpackPath = b.NodeModulesDir + "/webpack/bin/webpack.js"
+	}
+
+	cmd := exec.Command("node", webpackPath, "--config", configuratorFilePath)
+	output, err := cmd.Output()
+
+	if err != nil {
+		b.Logger.Warn(fmt.Sprintf(`invalid pack: "node %s --config %s"\n "%s"`, webpackPath, configuratorFilePath, string(output)))
+		return parseerror.New("failed to bundle, this could denote a syntax error", filePath)
+	}
+
+	return nil
+}
+
+func (b *SvelteCSR) HydrationFile() []embedutils.FileReader {
+	return []embedutils.FileReader{&embedFileReader{fileName: "svelte_csr.go"}}
+}
+
+func (b *SvelteCSR) DoesSatisfyConstraints(page jsparse.JSDocument) bool {
+	return page.Extension() == svelteExtension && page.DefaultExport() != nil
+}
+
+func NewSvelteCSR(bundler *BaseBundler) *SvelteCSR {
+	return &SvelteCSR{
+		BaseBundler: bundler,
+	}
+}
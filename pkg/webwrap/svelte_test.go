@@ -0,0 +1,54 @@
+// Copyright (c) 2021 Guy A. Ross
+// This source code is licensed under the GNU GPLv3 found in the
+// license file in the root directory of this source tree.
+
+package webwrap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/GuyARoss/orbit/pkg/jsparse/mock"
+)
+
+func TestApplySvelte(t *testing.T) {
+	if _, err := NewSvelteCSR(&BaseBundler{}).Apply(mock.NewMockJSDocument("", "svelte", "")); !errors.Is(err, ErrInvalidComponent) {
+		t.Errorf("expected invalid component error got '%v'", err)
+	}
+
+	pages, err := NewSvelteCSR(&BaseBundler{}).Apply(mock.NewMockJSDocument("Counter", "svelte", "Counter"))
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	page := pages["normal"]
+	if len(page.Imports()) != 1 || page.Imports()[0].FinalStatement != "import Counter from '../../../Counter.svelte'" {
+		t.Errorf("expected the source file to be bundled got '%v'", page.Imports())
+	}
+
+	if other := strings.Join(page.Other(), ""); !strings.Contains(other, "new Counter({ target: document.getElementById('_svelte_frame'), props: JSON.parse(") {
+		t.Errorf("expected component to be mounted with the props of the page got '%s'", other)
+	}
+}
+
+func TestSvelteSetup_BundleConfig(t *testing.T) {
+	resource, err := NewSvelteCSR(&BaseBundler{PageOutputDir: ".orbit/base/pages", Mode: ProductionBundle}).Setup(context.Background(), &BundleOpts{
+		FileName:  "pages/counter.svelte",
+		BundleKey: "abc",
+		Name:      "Counter",
+	})
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	config := strings.Join(resource.Configurators[0].Page.Other(), "")
+	for _, expected := range []string{"loader: 'svelte-loader'", "mainFields: ['svelte'", "entry: ['./.orbit/base/pages/abc.js']", "mode: 'production'"} {
+		if !strings.Contains(config, expected) {
+			t.Errorf("expected bundle config to contain '%s' got '%s'", expected, config)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+// Copyright (c) 2021 Guy A. Ross
+// This source code is licensed under the GNU GPLv3 found in the
+// license file in the root directory of this source tree.
+
+package webwrap
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/GuyARoss/orbit/pkg/embedutils"
+	"github.com/GuyARoss/orbit/pkg/jsparse"
+	parseerror "github.com/GuyARoss/orbit/pkg/parse_error"
+)
+
+const vueExtension string = "vue"
+
+// vueLoaderConfig is the webpack config that compiles vue single-file components with "vue-loader",
+// it is merged with the base config of the bundle.
+const vueLoaderConfig = `{
+	module: {
+		rules: [
+			{
+				test: /\.vue$/,
+				loader: 'vue-loader',
+			},
+		],
+	},
+	plugins: [new VueLoaderPlugin()],
+	resolve: {
+		extensions: ['.vue'],
+	},
+}`
+
+// VueCSR renders vue single-file components on the client, the source file of the component
+// is bundled as is (by "vue-loader") & mounted with the props of the page.
+type VueCSR struct {
+	*BaseWebWrapper
+	*BaseBundler
+}
+
+func (s *VueCSR) DocumentTag(key string) string {
+	return fmt.Sprintf(`<div id="%s_vue_frame"></div>`, key)
+}
+
+// mountPage creates the client bundle of the component, where createApp is either "createApp" or
+// "createSSRApp" which hydrates the server rendered page (or mounts the page when it was not server rendered).
+func (s *VueCSR) mountPage(page jsparse.JSDocument, createApp string) (jsparse.JSDocument, error) {
+	if len(page.Name()) == 0 {
+		return nil, ErrInvalidComponent
+	}
+
+	doc := jsparse.NewEmptyDocument()
+
+	doc.AddImport(&jsparse.ImportDependency{
+		FinalStatement: fmt.Sprintf("import { %s } from 'vue'", createApp),
+		Type:           jsparse.ModuleImportType,
+	})
+	doc.AddImport(page.SourceImport())
+
+	doc.AddOther(fmt.Sprintf(
+		"%s(%s, %s).mount('#%s_vue_frame')",
+		createApp, page.Name(), manifestProps(page.Key()), page.Key()),
+	)
+
+	return doc, nil
+}
+
+func (s *VueCSR) Apply(page jsparse.JSDocument) (map[string]jsparse.JSDocument, error) {
+	doc, err := s.mountPage(page, "createApp")
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]jsparse.JSDocument{"normal": doc}, nil
+}
+
+func (r *VueCSR) VerifyRequirements() error {
+	// vue single-file components are compiled by the vue-loader of webpack, which has no equivalent in esbuild
+	if r.usesESBuild() {
+		return ErrESBuildUnsupported
+	}
+
+	webpackPath := fmt.Sprintf("%s%c%s%c%s", r.NodeModulesDir, os.PathSeparator, ".bin", os.PathSeparator, "webpack")
+
+	// due to a "bug" with windows, it has an issue with shebang cmds, so we prefer the webpack.js file instead.
+	if runtime.GOOS == "windows" {
+		webpackPath = r.NodeModulesDir + "/webpack/bin/webpack.js"
+	}
+
+	if _, err := os.Stat(webpackPath); err != nil {
+		return fmt.Errorf("node module not found: webpack. It is possible that you need to run `npm i` in your workspace directory to remedy this issue")
+	}
+
+	if _, err := os.Stat(fmt.Sprintf("%s%cvue-loader", r.NodeModulesDir, os.PathSeparator)); err != nil {
+		return fmt.Errorf("node module not found: vue-loader. It is possible that you need to run `npm i vue vue-loader` in your workspace directory to remedy this issue")
+	}
+
+	return nil
+}
+
+func (s *VueCSR) Version() string {
+	return "vueCSR"
+}
+
+func (s *VueCSR) Stats() *WrapStats {
+	return &WrapStats{
+		WebVersion: "Vue CSR",
+		Bundler:    "webpack",
+	}
+}
+
+// RequiredBodyDOMElements vue is bundled with each of the pages, so it does not require any elements
+func (s *VueCSR) RequiredBodyDOMElements(ctx context.Context, cache *CacheDOMOpts) []string {
+	return []string{}
+}
+
+// bundleConfig creates the webpack config of the client bundle of the page
+func (b *VueCSR) bundleConfig(settings *BundleOpts, bundleFilePath string) BundleConfigurator {
+	return b.webpackConfigurator(&WebpackEntry{
+		BundleKey: settings.BundleKey,
+		FilePath:  bundleFilePath,
+		Requires:  []string{"const { VueLoaderPlugin } = require('vue-loader')", webpackBaseConfig},
+		Config:    vueLoaderConfig,
+	})
+}
+
+func (b *VueCSR) Setup(ctx context.Context, settings *BundleOpts) (*BundledResource, error) {
+	bundleFilePath := fmt.Sprintf("%s/%s.js", b.PageOutputDir, settings.BundleKey)
+
+	return &BundledResource{
+		BundleOpFileDescriptor: map[string]string{"normal": bundleFilePath},
+		Configurators: []BundleConfigurator{
+			b.bundleConfig(settings, bundleFilePath),
+		},
+	}, nil
+}
+
+func (b *VueCSR) Bundle(configuratorFilePath string, filePath string) error {
+	webpackPath := fmt.Sprintf("%s%c%s%c%s", b.NodeModulesDir, os.PathSeparator, ".bin", os.PathSeparator, "webpack")
+
+	// due to a "bug" with windows, it has an issue with shebang cmds, so we prefer the webpack.js file instead.
+	if runtime.GOOS == "windows" {
+		webpackPath = b.NodeModulesDir + "/webpack/bin/webpack.js"
+	}
+
+	cmd := exec.Command("node", webpackPath, "--config", configuratorFilePath)
+	output, err := cmd.Output()
+
+	if err != nil {
+		b.Logger.Warn(fmt.Sprintf(`invalid pack: "node %s --config %s"\n "%s"`, webpackPath, configuratorFilePath, string(output)))
+		return parseerror.New("failed to bundle, this could denote a syntax error", filePath)
+	}
+
+	return nil
+}
+
+func (b *VueCSR) HydrationFile() []embedutils.FileReader {
+	return []embedutils.FileReader{&embedFileReader{fileName: "vue_csr.go"}}
+}
+
+func (b *VueCSR) DoesSatisfyConstraints(page jsparse.JSDocument) bool {
+	return page.Extension() == vueExtension && page.DefaultExport() != nil
+}
+
+func NewVueCSR(bundler *BaseBundler) *VueCSR {
+	return &VueCSR{
+		BaseBundler: bundler,
+	}
+}
+
+// VueHydrate server side renders vue single-file components through the same renderer as the react pages & hydrates
+// them on the client. vue renders asynchronously, so these pages require the node ssr backend.
+type VueHydrate struct {
+	csr *VueCSR
+	ssr *PartialWrapReactSSR
+}
+
+func (s *VueHydrate) DocumentTag(key string) string {
+	return s.csr.DocumentTag(key)
+}
+
+func (s *VueHydrate) Apply(page jsparse.JSDocument) (map[string]jsparse.JSDocument, error) {
+	doc, err := s.csr.mountPage(page, "createSSRApp")
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]jsparse.JSDocument{"csr": doc}, nil
+}
+
+func (s *VueHydrate) VerifyRequirements() error {
+	return s.csr.VerifyRequirements()
+}
+
+func (s *VueHydrate) Version() string {
+	return "vueHydrate"
+}
+
+func (s *VueHydrate) Stats() *WrapStats {
+	return &WrapStats{
+		WebVersion: "Vue Hydrate",
+		Bundler:    "webpack",
+	}
+}
+
+func (s *VueHydrate) RequiredBodyDOMElements(ctx context.Context, cache *CacheDOMOpts) []string {
+	return s.csr.RequiredBodyDOMElements(ctx, cache)
+}
+
+func (b *VueHydrate) Setup(ctx context.Context, settings *BundleOpts) (*BundledResource, error) {
+	clientBundleFilePath := fmt.Sprintf("%s/%s.js", b.csr.PageOutputDir, settings.BundleKey)
+
+	b.ssr.addVueRenderFunc(settings)
+
+	return &BundledResource{
+		BundleOpFileDescriptor: map[string]string{"csr": clientBundleFilePath},
+		Configurators:          append(b.ssr.serverConfigurators(), b.csr.bundleConfig(settings, clientBundleFilePath)),
+	}, nil
+}
+
+func (b *VueHydrate) Bundle(configuratorFilePath string, filePath string) error {
+	if strings.Contains(configuratorFilePath, "ssr") {
+		return b.ssr.Bundle(configuratorFilePath, filePath)
+	}
+
+	return b.csr.Bundle(configuratorFilePath, filePath)
+}
+
+func (b *VueHydrate) DoesSatisfyConstraints(page jsparse.JSDocument) bool {
+	return b.csr.DoesSatisfyConstraints(page)
+}
+
+// HydrationFile the vue pages are rendered by the same ssr renderer as the hydrated react pages
+func (b *VueHydrate) HydrationFile() []embedutils.FileReader {
+	return append((&ReactHydrate{}).HydrationFile(), &embedFileReader{fileName: "vue_hydrate.go"})
+}
+
+func NewVueHydrate(bundler *BaseBundler) JSWebWrapper {
+	return newVueHydrate(bundler, newReactHydrate(bundler, false).ssr)
+}
+
+// newVueHydrate creates the vue hydrate wrapper with the ssr renderer of the react pages, so that both
+// the react & vue pages are rendered by the same renderer.
+func newVueHydrate(bundler *BaseBundler, ssr *PartialWrapReactSSR) *VueHydrate {
+	return &VueHydrate{
+		csr: NewVueCSR(bundler),
+		ssr: ssr,
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2021 Guy A. Ross
+// This source code is licensed under the GNU GPLv3 found in the
+// license file in the root directory of this source tree.
+
+package webwrap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/GuyARoss/orbit/pkg/experiments"
+	"github.com/GuyARoss/orbit/pkg/jsparse/mock"
+)
+
+func TestApplyVue(t *testing.T) {
+	if _, err := NewVueCSR(&BaseBundler{}).Apply(mock.NewMockJSDocument("", "vue", "")); !errors.Is(err, ErrInvalidComponent) {
+		t.Errorf("expected invalid component error got '%v'", err)
+	}
+
+	tt := []struct {
+		wrapper   JSWebWrapper
+		bundleOp  string
+		createApp string
+	}{
+		{NewVueCSR(&BaseBundler{}), "normal", "createApp(Hello, "},
+		{NewVueHydrate(&BaseBundler{}), "csr", "createSSRApp(Hello, "},
+	}
+
+	for _, d := range tt {
+		pages, err := d.wrapper.Apply(mock.NewMockJSDocument("Hello", "vue", "Hello"))
+		if err != nil {
+			t.Errorf("did not expect error '%s'", err)
+			continue
+		}
+
+		page := pages[d.bundleOp]
+		if page == nil {
+			t.Errorf("expected '%s' page got '%v'", d.bundleOp, pages)
+			continue
+		}
+
+		if len(page.Imports()) != 2 || page.Imports()[1].FinalStatement != "import Hello from '../../../Hello.vue'" {
+			t.Errorf("expected the source file to be bundled got '%v'", page.Imports())
+		}
+
+		if other := strings.Join(page.Other(), ""); !strings.Contains(other, d.createApp) || !strings.Contains(other, ".mount('#_vue_frame')") {
+			t.Errorf("expected page to be mounted with '%s' got '%s'", d.createApp, other)
+		}
+	}
+}
+
+func TestVueSetup_BundleConfig(t *testing.T) {
+	resource, err := NewVueCSR(&BaseBundler{PageOutputDir: ".orbit/base/pages", Mode: ProductionBundle}).Setup(context.Background(), &BundleOpts{
+		FileName:  "pages/hello.vue",
+		BundleKey: "abc",
+		Name:      "Hello",
+	})
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	config := strings.Join(resource.Configurators[0].Page.Other(), "")
+	for _, expected := range []string{"loader: 'vue-loader'", "new VueLoaderPlugin()", "entry: ['./.orbit/base/pages/abc.js']"} {
+		if !strings.Contains(config, expected) {
+			t.Errorf("expected config to contain '%s' got '%s'", expected, config)
+		}
+	}
+}
+
+func TestVueHydrateSetup(t *testing.T) {
+	hydrate := newReactHydrate(&BaseBundler{PageOutputDir: ".orbit/base/pages"}, false)
+	vue := newVueHydrate(hydrate.csr.BaseBundler, hydrate.ssr)
+
+	if _, err := vue.Setup(context.Background(), &BundleOpts{FileName: "./pages/hello.vue", BundleKey: "abc", Name: "Hello"}); err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	sourceMap := hydrate.ssr.sourceMapDoc
+	if !strings.Contains(strings.Join(sourceMap.Other(), ""), "export const hello = (d, orbit) => renderVueToString(createSSRApp(Hello, d).provide('orbit', orbit))") {
+		t.Errorf("expected vue render function got '%v'", sourceMap.Other())
+	}
+
+	imports := make([]string, 0)
+	for _, imp := range sourceMap.Imports() {
+		imports = append(imports, imp.FinalStatement)
+	}
+
+	if !strings.Contains(strings.Join(imports, "\n"), "import Hello from '../../../pages/hello.vue'") {
+		t.Errorf("expected the source file to be rendered got '%v'", imports)
+	}
+
+	if !strings.Contains(hydrate.ssr.jsSwitch.Serialize(), "case 'abc'") {
+		t.Errorf("expected render case for the bundle got '%s'", hydrate.ssr.jsSwitch.Serialize())
+	}
+
+	if config := strings.Join(hydrate.ssr.bundleConfig().Other(), ""); !strings.Contains(config, "loader: 'vue-loader'") {
+		t.Errorf("expected the server bundles to compile vue components got '%s'", config)
+	}
+}
+
+func TestNewActiveMap_Vue(t *testing.T) {
+	current := experiments.GlobalExperimentalFeatures
+	t.Cleanup(func() { experiments.GlobalExperimentalFeatures = current })
+
+	tt := []struct {
+		features *experiments.Features
+		expected string
+	}{
+		{&experiments.Features{}, "vueCSR"},
+		{&experiments.Features{PreferSSR: true}, "vueHydrate"},
+	}
+
+	for _, d := range tt {
+		experiments.GlobalExperimentalFeatures = d.features
+
+		wrappers := NewActiveMap(&BaseBundler{ReactVersion: 16})
+		if wrappers[1].Version() != d.expected {
+			t.Errorf("expected '%s' got '%s'", d.expected, wrappers[1].Version())
+		}
+
+		if wrappers.FindFirst(mock.NewMockJSDocument("Hello", "vue", "Hello")) != wrappers[1] {
+			t.Error("expected vue wrapper to satisfy vue components")
+		}
+	}
+}
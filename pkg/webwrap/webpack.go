@@ -0,0 +1,186 @@
+// Copyright (c) 2021 Guy A. Ross
+// This source code is licensed under the GNU GPLv3 found in the
+// license file in the root directory of this source tree.
+
+package webwrap
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/GuyARoss/orbit/pkg/experiments"
+	"github.com/GuyARoss/orbit/pkg/jsparse"
+	parseerror "github.com/GuyARoss/orbit/pkg/parse_error"
+)
+
+const (
+	webpackBaseConfig    = "const baseConfig = require('../../assets/base.config.js')"
+	webpackSWCBaseConfig = "const baseConfig = require('../../assets/swc-base.config.js')"
+)
+
+// clientBaseConfig is the base config of the client bundles of the jsx pages, which is compiled by swc when it is preferred
+func clientBaseConfig() string {
+	if experiments.GlobalExperimentalFeatures.PreferSWCCompiler {
+		return webpackSWCBaseConfig
+	}
+
+	return webpackBaseConfig
+}
+
+// WebpackEntry is the entry of the client bundle of a page that is bundled by webpack, entries that are
+// merged with the same config can be bundled together by a single multi-entry config.
+type WebpackEntry struct {
+	// BundleKey is the bundle key of the page, which names the bundle of the entry
+	BundleKey string
+	// FilePath is the path of the entry file of the page
+	FilePath string
+	// Requires are the require statements of the config, one of which requires the "baseConfig" of the entry
+	Requires []string
+	// Config is the config that is merged between the base config & the entry (e.g the loaders of the page)
+	Config string
+}
+
+// SharedChunk is the name of the chunk of the node modules that are shared between the entries of the same config
+func (e *WebpackEntry) SharedChunk() string {
+	sum := md5.Sum([]byte(fmt.Sprintf("%s\n%s", strings.Join(e.Requires, "\n"), e.Config)))
+
+	return fmt.Sprintf("orbit_shared_%s", hex.EncodeToString(sum[:])[:8])
+}
+
+// requireDoc creates the config document with the require statements of the entry
+func (e *WebpackEntry) requireDoc() jsparse.JSDocument {
+	page := jsparse.NewEmptyDocument()
+
+	page.AddImport(&jsparse.ImportDependency{
+		FinalStatement: "const {merge} = require('webpack-merge')",
+		Type:           jsparse.ModuleImportType,
+	})
+
+	for _, r := range e.Requires {
+		page.AddImport(&jsparse.ImportDependency{
+			FinalStatement: r,
+			Type:           jsparse.ModuleImportType,
+		})
+	}
+
+	return page
+}
+
+// mergedConfigs are the configs that are merged with the config of the entry
+func (e *WebpackEntry) mergedConfigs() string {
+	if e.Config == "" {
+		return "baseConfig"
+	}
+
+	return fmt.Sprintf("baseConfig, %s", e.Config)
+}
+
+// webpackConfigurator creates the configurator of the webpack config that bundles the entry on its own
+func (b *BaseBundler) webpackConfigurator(entry *WebpackEntry) BundleConfigurator {
+	page := entry.requireDoc()
+
+	page.AddOther(fmt.Sprintf(`module.exports = merge(%s, {
+		entry: ['./%s'],
+		mode: '%s',
+		output: {
+			filename: '%s'
+		},
+	})`, entry.mergedConfigs(), entry.FilePath, string(b.Mode), fmt.Sprintf("%s.js", entry.BundleKey)))
+
+	return BundleConfigurator{
+		FilePath: fmt.Sprintf("%s/%s.config.js", b.PageOutputDir, entry.BundleKey),
+		Page:     page,
+		Entry:    entry,
+	}
+}
+
+// MultiEntryConfigurators creates the configurators of the configs that bundle the entries of many pages with a single
+// webpack invocation. entries are grouped by the config that they are merged with, the node modules that the entries of
+// a group depend on are split into the shared chunk of the group rather than being duplicated in each of the bundles.
+// each group loads its chunks with its own jsonp function, so that the bundles of different groups can share a page.
+func (b *BaseBundler) MultiEntryConfigurators(entries []*WebpackEntry) []BundleConfigurator {
+	groups := make(map[string][]*WebpackEntry)
+	for _, e := range entries {
+		groups[e.SharedChunk()] = append(groups[e.SharedChunk()], e)
+	}
+
+	// the groups & their entries are sorted so that the configs of the same pages are always the same
+	chunks := make([]string, 0, len(groups))
+	for chunk := range groups {
+		chunks = append(chunks, chunk)
+	}
+	sort.Strings(chunks)
+
+	configurators := make([]BundleConfigurator, 0, len(chunks))
+	for _, chunk := range chunks {
+		group := groups[chunk]
+		sort.Slice(group, func(i, j int) bool { return group[i].BundleKey < group[j].BundleKey })
+
+		entryMap := strings.Builder{}
+		for _, e := range group {
+			entryMap.WriteString(fmt.Sprintf("\t\t\t'%s': [...baseEntry, './%s'],\n", e.BundleKey, e.FilePath))
+		}
+
+		page := group[0].requireDoc()
+
+		// the entries of the base config (e.g the polyfills) are included in each entry, as they are when the entry is bundled on its own
+		page.AddOther("const baseEntry = Array.isArray(baseConfig.entry) ? baseConfig.entry : []")
+		page.AddOther(fmt.Sprintf(`module.exports = merge(%s, {
+		entry: {
+%s		},
+		mode: '%s',
+		output: {
+			filename: '[name].js',
+			jsonpFunction: 'webpackJsonp_%s',
+		},
+		optimization: {
+			splitChunks: {
+				cacheGroups: {
+					shared: {
+						test: /[\\/]node_modules[\\/]/,
+						name: '%s',
+						chunks: 'all',
+						enforce: true,
+					},
+				},
+			},
+		},
+	})`, group[0].mergedConfigs(), entryMap.String(), string(b.Mode), chunk, chunk))
+
+		configurators = append(configurators, BundleConfigurator{
+			FilePath: fmt.Sprintf("%s/%s.config.js", b.PageOutputDir, chunk),
+			Page:     page,
+		})
+	}
+
+	return configurators
+}
+
+// BundleMultiEntry bundles the config of a multi-entry configurator
+func (b *BaseBundler) BundleMultiEntry(configuratorFilePath string) error {
+	webpackPath := fmt.Sprintf("%s%c%s%c%s", b.NodeModulesDir, os.PathSeparator, ".bin", os.PathSeparator, "webpack")
+
+	// due to a "bug" with windows, it has an issue with shebang cmds, so we prefer the webpack.js file instead.
+	if runtime.GOOS == "windows" {
+		webpackPath = b.NodeModulesDir + "/webpack/bin/webpack.js"
+	}
+
+	cmd := exec.Command("node", webpackPath, "--config", configuratorFilePath)
+	output, err := cmd.Output()
+
+	if err != nil {
+		if b.Logger != nil {
+			b.Logger.Warn(fmt.Sprintf(`invalid pack: "node %s --config %s"\n "%s"`, webpackPath, configuratorFilePath, string(output)))
+		}
+
+		return parseerror.New("failed to bundle, this could denote a syntax error", configuratorFilePath)
+	}
+
+	return nil
+}
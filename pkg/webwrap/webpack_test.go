@@ -0,0 +1,89 @@
+// Copyright (c) 2021 Guy A. Ross
+// This source code is licensed under the GNU GPLv3 found in the
+// license file in the root directory of this source tree.
+
+package webwrap
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSetup_WebpackEntry(t *testing.T) {
+	bundler := &BaseBundler{PageOutputDir: ".orbit/base/pages", Mode: ProductionBundle}
+	settings := &BundleOpts{FileName: "pages/home.jsx", BundleKey: "abc", Name: "Home"}
+
+	resource, err := NewReactCSR(bundler).Setup(context.Background(), settings)
+	if err != nil {
+		t.Errorf("did not expect error '%s'", err)
+		return
+	}
+
+	client := resource.Configurators[0]
+	if client.Entry == nil || client.Entry.FilePath != ".orbit/base/pages/abc.js" {
+		t.Errorf("expected the client config to have the entry of the page got '%v'", client.Entry)
+	}
+
+	// the page can still be bundled on its own
+	config := strings.Join(client.Page.Other(), "")
+	for _, expected := range []string{"entry: ['./.orbit/base/pages/abc.js']", "mode: 'production'", "filename: 'abc.js'"} {
+		if !strings.Contains(config, expected) {
+			t.Errorf("expected config to contain '%s' got '%s'", expected, config)
+		}
+	}
+}
+
+func TestMultiEntryConfigurators(t *testing.T) {
+	bundler := &BaseBundler{PageOutputDir: ".orbit/base/pages", Mode: ProductionBundle}
+
+	entries := make([]*WebpackEntry, 0)
+	for _, d := range []struct {
+		wrapper JSWebWrapper
+		key     string
+	}{
+		{NewReactCSR(bundler), "def"},
+		{&JavascriptWrap{BaseBundler: bundler}, "abc"},
+		{NewPreactCSR(bundler), "ghi"},
+	} {
+		resource, _ := d.wrapper.Setup(context.Background(), &BundleOpts{FileName: "pages/page.jsx", BundleKey: d.key, Name: "Page"})
+		entries = append(entries, resource.Configurators[0].Entry)
+	}
+
+	if entries[0].SharedChunk() != entries[1].SharedChunk() {
+		t.Errorf("expected entries of the same config to share a chunk")
+	}
+
+	if entries[0].SharedChunk() == entries[2].SharedChunk() {
+		t.Errorf("expected entries of different configs to not share a chunk")
+	}
+
+	configurators := bundler.MultiEntryConfigurators(entries)
+	if len(configurators) != 2 {
+		t.Errorf("expected a config per webpack config got '%d'", len(configurators))
+		return
+	}
+
+	var base string
+	for _, c := range configurators {
+		if c.FilePath == fmt.Sprintf(".orbit/base/pages/%s.config.js", entries[0].SharedChunk()) {
+			base = strings.Join(c.Page.Other(), "\n")
+		}
+	}
+
+	for _, expected := range []string{
+		"'abc': [...baseEntry, './.orbit/base/pages/abc.js'],\n\t\t\t'def': [...baseEntry, './.orbit/base/pages/def.js'],",
+		"filename: '[name].js'",
+		fmt.Sprintf("name: '%s'", entries[0].SharedChunk()),
+		fmt.Sprintf("jsonpFunction: 'webpackJsonp_%s'", entries[0].SharedChunk()),
+	} {
+		if !strings.Contains(base, expected) {
+			t.Errorf("expected multi-entry config to contain '%s' got '%s'", expected, base)
+		}
+	}
+
+	if strings.Contains(base, "ghi") {
+		t.Errorf("expected the preact entry to be bundled by its own config")
+	}
+}
@@ -56,17 +56,57 @@ func (j JSWebWrapperList) FindFirst(page jsparse.JSDocument) JSWebWrapper {
 }
 
 func NewActiveMap(bundler *BaseBundler) JSWebWrapperList {
+	// react 18 deprecates the legacy render api in favor of the root api
+	react18 := bundler.ReactMajorVersion() >= 18
+
+	// vue & svelte pages are not supported by esbuild, so they are always bundled by webpack
+	webpack := bundler.withBackend(WebpackBackend)
+
+	// islands are server side rendered, so they are preferred over the ssr wrapper when both are enabled
+	if experiments.GlobalExperimentalFeatures.PreferIslands {
+		islands := NewReactIslands(bundler)
+
+		return []JSWebWrapper{
+			islands,
+			newVueHydrate(webpack, islands.ssr),
+			NewPreactCSR(bundler),
+			NewSvelteCSR(webpack),
+			&JavascriptWrap{
+				BaseBundler: bundler,
+			},
+		}
+	}
+
 	if experiments.GlobalExperimentalFeatures.PreferSSR {
+		hydrate := newReactHydrate(bundler, react18)
+
+		var react JSWebWrapper = hydrate
+		if react18 {
+			react = &React18Hydrate{ReactHydrate: hydrate}
+		}
+
+		// the vue pages are rendered by the same ssr renderer as the react pages
 		return []JSWebWrapper{
-			NewReactHydrate(bundler),
+			react,
+			newVueHydrate(webpack, hydrate.ssr),
+			NewPreactCSR(bundler),
+			NewSvelteCSR(webpack),
 			&JavascriptWrap{
 				BaseBundler: bundler,
 			},
 		}
 	}
 
+	var csr JSWebWrapper = NewReactCSR(bundler)
+	if react18 {
+		csr = NewReact18CSR(bundler)
+	}
+
 	return []JSWebWrapper{
-		NewReactCSR(bundler),
+		csr,
+		NewVueCSR(webpack),
+		NewPreactCSR(bundler),
+		NewSvelteCSR(webpack),
 		&JavascriptWrap{
 			BaseBundler: bundler,
 		},
@@ -106,6 +146,39 @@ type BaseBundler struct {
 	PageOutputDir  string
 	NodeModulesDir string
 	Logger         log.Logger
+	// ReactVersion is the major version of react that the react wrappers target, when left
+	// blank the version is detected from the react package that is installed in "NodeModulesDir"
+	ReactVersion int
+	// JSXFramework is the framework that renders the jsx pages which do not declare their own framework
+	// with the "orbit:framework" comment token, when left blank the pages are rendered by react
+	JSXFramework string
+	// Backend is the bundler of the client bundles of the wrapper, the pages are bundled by webpack when left blank
+	Backend BundlerBackend
+}
+
+// BundleOutputDir is the directory that the bundles of the pages are output to, which is
+// ".orbit/dist" for the default ".orbit/base/pages" output directory of the pages.
+func (b *BaseBundler) BundleOutputDir() string {
+	return path.Join(path.Dir(path.Dir(b.PageOutputDir)), "dist")
+}
+
+const (
+	ReactFramework  = "react"
+	PreactFramework = "preact"
+)
+
+// PageFramework finds the framework that renders the jsx page, the framework that is declared by the page
+// is preferred over the configured "JSXFramework"
+func (b *BaseBundler) PageFramework(page jsparse.JSDocument) string {
+	if framework := page.OrbitFramework(); framework != "" {
+		return framework
+	}
+
+	if b != nil && b.JSXFramework != "" {
+		return b.JSXFramework
+	}
+
+	return ReactFramework
 }
 
 type BundleOpts struct {
@@ -114,10 +187,43 @@ type BundleOpts struct {
 	Name      string
 }
 
+// EntryExtension is the extension of the bundled entry of the page, typescript pages keep their
+// extension so that the loaders of the bundler parse the type annotations of the page.
+func (o *BundleOpts) EntryExtension() string {
+	switch path.Ext(o.FileName) {
+	case ".tsx":
+		return "tsx"
+	case ".ts":
+		return "ts"
+	}
+
+	return "js"
+}
+
+// isJSXExtension reports whether the extension is either jsx or tsx
+func isJSXExtension(extension string) bool {
+	return extension == "jsx" || extension == "tsx"
+}
+
+// manifestProps creates the javascript expression that reads the props of the page from its orbit manifest,
+// pages that are rendered with the same props (rather than their own) read them from the shared manifest.
+func manifestProps(key string) string {
+	return fmt.Sprintf(
+		`JSON.parse((document.getElementById('orbit_manifest_%s') || document.getElementById('orbit_manifest'))?.textContent || '{}')`,
+		key,
+	)
+}
+
 type BundleConfigurator struct {
 	// ConfiguratorPage represents a bundler setup file
 	Page     jsparse.JSDocument
 	FilePath string
+	// Entry is the webpack entry of the configurator, which is set for the configurators of the client bundles
+	// that can be bundled along with the entries of the other pages by a multi-entry config.
+	Entry *WebpackEntry
+	// Shared is set for the configurators that are shared between the pages of the wrapper (e.g the server bundle),
+	// which should only be written & bundled once each of the pages has been setup.
+	Shared bool
 }
 
 type BundledResource struct {